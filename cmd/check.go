@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/check"
+	"github.com/d0ugal/brother-exporter/internal/config"
+)
+
+// thresholdFlag collects consumable=percent pairs, e.g. toner=15 or
+// toner_cyan=20, from repeated or comma-separated flags
+type thresholdFlag map[string]float64
+
+func (f thresholdFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for key, value := range f {
+		pairs = append(pairs, key+"="+strconv.FormatFloat(value, 'f', -1, 64))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (f thresholdFlag) Set(value string) error {
+	for pair := range strings.SplitSeq(value, ",") {
+		key, level, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected consumable=percent, got %q", pair)
+		}
+
+		percent, err := strconv.ParseFloat(level, 64)
+		if err != nil {
+			return fmt.Errorf("invalid percent for %s: %w", key, err)
+		}
+
+		f[key] = percent
+	}
+
+	return nil
+}
+
+// runCheck runs the check subcommand, a Nagios/Icinga plugin that collects
+// from the printer once and returns the plugin state as exit code
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+
+	warning := thresholdFlag{}
+	critical := thresholdFlag{}
+
+	configPath := flags.String("config", "config.yaml", "Path to configuration file (optional)")
+	host := flags.String("host", "", "Printer host (default: from the configuration)")
+	community := flags.String("community", "", "SNMP community (default: from the configuration)")
+	timeout := flags.Duration("timeout", time.Minute, "Time allowed for the collection")
+	verbose := flags.Bool("verbose", false, "Log the collection to stderr")
+
+	flags.Var(warning, "warning", "Warning level of a consumable, e.g. toner=15 (repeatable)")
+	flags.Var(critical, "critical", "Critical level of a consumable, e.g. toner=5 (repeatable)")
+
+	if err := flags.Parse(args); err != nil {
+		return int(check.Unknown)
+	}
+
+	// Plugin output goes to stdout, where the exporter's logs would mix in
+	configureOneShotLogging(*verbose)
+
+	var thresholdErr error
+
+	cfg, err := config.LoadConfigWith(oneShotConfigPath(*configPath), func(cfg *config.Config) {
+		if *host != "" {
+			cfg.Printer.Host = *host
+		}
+
+		if *community != "" {
+			cfg.Printer.Community = *community
+		}
+
+		thresholdErr = cfg.Printer.OverrideThresholds(warning, critical)
+	})
+	if err == nil {
+		err = thresholdErr
+	}
+
+	if err != nil {
+		fmt.Printf("BROTHER %s - %v\n", check.Unknown, err)
+		return int(check.Unknown)
+	}
+
+	result := collectCheck(cfg, *timeout)
+
+	if err := result.Write(os.Stdout); err != nil {
+		return int(check.Unknown)
+	}
+
+	return int(result.State)
+}
+
+// collectCheck collects from the printer once, without starting any
+// server, and evaluates the result
+func collectCheck(cfg *config.Config, timeout time.Duration) check.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	collector := newOneShotCollector(cfg)
+
+	// SNMP requests don't follow the context, so the collection may
+	// outlive the timeout
+	result := make(chan check.Result, 1)
+
+	go func() {
+		result <- check.Evaluate(collector.CollectOnce(ctx), cfg.Printer)
+	}()
+
+	select {
+	case r := <-result:
+		return r
+	case <-ctx.Done():
+		return check.Failed(cfg.Printer.Host, errors.New("timed out after "+timeout.String()))
+	}
+}
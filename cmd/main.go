@@ -4,11 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
+	"strconv"
 
+	"github.com/d0ugal/brother-exporter/internal/api"
 	"github.com/d0ugal/brother-exporter/internal/collectors"
 	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/events"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/influx"
 	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/mqtt"
+	"github.com/d0ugal/brother-exporter/internal/notify"
+	"github.com/d0ugal/brother-exporter/internal/otlp"
+	"github.com/d0ugal/brother-exporter/internal/push"
+	"github.com/d0ugal/brother-exporter/internal/report"
 	"github.com/d0ugal/brother-exporter/internal/version"
 	"github.com/d0ugal/promexporter/app"
 	"github.com/d0ugal/promexporter/logging"
@@ -16,6 +27,18 @@ import (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "report":
+			os.Exit(runReport(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "probe":
+			os.Exit(runProbe(os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags
 	var showVersion bool
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
@@ -56,19 +79,120 @@ func main() {
 	metricsRegistry := promexporter_metrics.NewRegistry("brother_exporter_info")
 
 	// Add custom metrics to the registry
-	brotherRegistry := metrics.NewBrotherRegistry(metricsRegistry)
+	brotherRegistry := metrics.NewBrotherRegistry(metricsRegistry, cfg.Printer.LabelNames()...)
 
 	// Create and build application using promexporter
 	application := app.New("Brother Exporter").
-		WithConfig(&cfg.BaseConfig).
+		WithConfig(cfg).
 		WithMetrics(metricsRegistry).
 		WithVersionInfo(version.Version, version.Commit, version.BuildDate).
 		Build()
 
 	// Create collector with app reference for tracing
 	brotherCollector := collectors.NewBrotherCollector(cfg, brotherRegistry, application)
+
+	// Publishers start before the collector so they see the first cycle
+	if cfg.MQTT.Enabled {
+		publisher := mqtt.NewPublisher(cfg.MQTT)
+		brotherCollector.Store().Subscribe(publisher.Publish)
+		application.WithCollector(publisher)
+	}
+
+	if cfg.Push.RemoteWrite.Enabled {
+		writer := push.NewRemoteWriter(cfg.Push.RemoteWrite, brotherRegistry.Gatherer())
+		brotherCollector.Store().Subscribe(writer.Observe)
+		application.WithCollector(writer)
+	}
+
+	if cfg.Push.Pushgateway.Enabled {
+		pusher := push.NewPushgateway(cfg.Push.Pushgateway, brotherRegistry.Gatherer())
+		brotherCollector.Store().Subscribe(pusher.Observe)
+		application.WithCollector(pusher)
+	}
+
+	if cfg.Influx.Enabled && cfg.Influx.Mode == config.InfluxModeWrite {
+		writer := influx.NewWriter(cfg.Influx)
+		brotherCollector.Store().Subscribe(writer.Observe)
+		application.WithCollector(writer)
+	}
+
+	var historyDB *history.DB
+
+	if cfg.History.Enabled {
+		historyDB, err = history.Open(cfg.History.Path)
+		if err != nil {
+			slog.Error("Failed to open history", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Notifications.Enabled() {
+		notifications, err := notify.NewManager(cfg.Notifications)
+		if err != nil {
+			slog.Error("Failed to set up notifications", "error", err)
+			os.Exit(1)
+		}
+
+		if historyDB != nil {
+			notifications.WithHistory(historyDB)
+		}
+
+		brotherCollector.Store().Subscribe(notifications.Observe)
+		application.WithCollector(notifications)
+	}
+
+	var eventLog *events.Log
+
+	if cfg.Events.Enabled {
+		eventLog, err = events.Open(cfg.Events)
+		if err != nil {
+			slog.Error("Failed to open event log", "error", err)
+			os.Exit(1)
+		}
+
+		brotherCollector.Store().Subscribe(eventLog.Observe)
+		application.WithCollector(eventLog)
+	}
+
 	application.WithCollector(brotherCollector)
 
+	if cfg.OTLP.Enabled {
+		exporter, err := otlp.NewExporter(cfg.OTLP, brotherRegistry)
+		if err != nil {
+			slog.Error("Failed to set up the OTLP exporter", "error", err)
+			os.Exit(1)
+		}
+
+		application.WithCollector(exporter)
+	}
+
+	if cfg.API.Enabled {
+		addr := net.JoinHostPort(cfg.API.Host, strconv.Itoa(cfg.API.Port))
+		server := api.NewServer(addr, brotherCollector.Store(), cfg.Metrics.Collection.DefaultInterval.Duration)
+
+		if historyDB != nil {
+			server.WithHistory(historyDB).WithReports(report.NewGenerator(historyDB, cfg.Report))
+		}
+
+		if eventLog != nil {
+			server.WithEvents(eventLog)
+		}
+
+		if cfg.Influx.Enabled && cfg.Influx.Mode == config.InfluxModeServe {
+			server.WithInflux(cfg.Influx.Tags)
+		}
+
+		application.WithCollector(server)
+	}
+
+	// The recorder closes the database, so it stops after the API server and
+	// the notifications
+	if historyDB != nil {
+		recorder := history.NewRecorder(historyDB, cfg.History)
+		brotherCollector.Store().Subscribe(recorder.Observe)
+		application.WithCollector(recorder)
+	}
+
 	if err := application.Run(); err != nil {
 		slog.Error("Application failed", "error", err)
 		os.Exit(1)
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/d0ugal/brother-exporter/internal/collectors"
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/promexporter/app"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+)
+
+// configureOneShotLogging keeps the collectors' logs out of a subcommand's
+// output: discarded, or on stderr with debug logs when verbose
+func configureOneShotLogging(verbose bool) {
+	var handler slog.Handler = slog.NewTextHandler(io.Discard, nil)
+	if verbose {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+// oneShotConfigPath applies CONFIG_PATH when the default path is given
+func oneShotConfigPath(path string) string {
+	if path == "config.yaml" {
+		if envConfig := os.Getenv("CONFIG_PATH"); envConfig != "" {
+			return envConfig
+		}
+	}
+
+	return path
+}
+
+// newOneShotCollector creates a collector for a subcommand that collects
+// once, with its own registry and without starting the application
+func newOneShotCollector(cfg *config.Config) *collectors.BrotherCollector {
+	metricsRegistry := promexporter_metrics.NewRegistry("brother_exporter_info")
+	brotherRegistry := metrics.NewBrotherRegistry(metricsRegistry, cfg.Printer.LabelNames()...)
+
+	return collectors.NewBrotherCollector(cfg, brotherRegistry, app.New("Brother Exporter"))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/collectors"
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/probe"
+)
+
+// runProbe runs the probe subcommand, which collects every section from
+// the printer once and prints what it answered, and returns the exit code
+func runProbe(args []string) int {
+	flags := flag.NewFlagSet("probe", flag.ContinueOnError)
+
+	var v3 config.SNMPv3Config
+
+	configPath := flags.String("config", "config.yaml", "Path to configuration file (optional)")
+	host := flags.String("host", "", "Printer host (default: from the configuration)")
+	community := flags.String("community", "", "SNMP community (default: from the configuration)")
+	flags.StringVar(&v3.Username, "v3-username", "", "SNMPv3 user, to probe over v3 instead of v2c")
+	flags.StringVar(&v3.AuthProtocol, "v3-auth-protocol", "", "SNMPv3 auth protocol: MD5, SHA, SHA224, SHA256, SHA384 or SHA512")
+	flags.StringVar(&v3.AuthPassphrase, "v3-auth-passphrase", "", "SNMPv3 auth passphrase")
+	flags.StringVar(&v3.PrivProtocol, "v3-priv-protocol", "", "SNMPv3 privacy protocol: DES, AES, AES192, AES256, AES192C or AES256C")
+	flags.StringVar(&v3.PrivPassphrase, "v3-priv-passphrase", "", "SNMPv3 privacy passphrase")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	timeout := flags.Duration("timeout", 2*time.Minute, "Time allowed for the probe")
+	verbose := flags.Bool("verbose", false, "Log the collection to stderr")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	configureOneShotLogging(*verbose)
+
+	cfg, err := config.LoadConfigWith(oneShotConfigPath(*configPath), func(cfg *config.Config) {
+		if *host != "" {
+			cfg.Printer.Host = *host
+		}
+
+		if *community != "" {
+			cfg.Printer.Community = *community
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "probe: failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	if v3.Username != "" {
+		if err := v3.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "probe: v3: %v\n", err)
+			return 2
+		}
+	}
+
+	format := probe.FormatText
+	if *asJSON {
+		format = probe.FormatJSON
+	}
+
+	startedAt := time.Now()
+
+	result, err := runProbeCollection(cfg, v3, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "probe: %v\n", err)
+		return 1
+	}
+
+	if err := probe.NewReport(cfg.Printer.Host, startedAt, time.Since(startedAt), result).Write(os.Stdout, format); err != nil {
+		fmt.Fprintf(os.Stderr, "probe: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runProbeCollection probes the printer, over SNMPv3 when v3 has a user,
+// giving up after timeout
+func runProbeCollection(cfg *config.Config, v3 config.SNMPv3Config, timeout time.Duration) (collectors.Probe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	collector := newOneShotCollector(cfg)
+
+	// SNMP requests don't follow the context, so the probe may outlive
+	// the timeout
+	result := make(chan collectors.Probe, 1)
+
+	go func() {
+		result <- collector.Probe(ctx, v3)
+	}()
+
+	select {
+	case r := <-result:
+		return r, nil
+	case <-ctx.Done():
+		return collectors.Probe{}, fmt.Errorf("timed out after %s", timeout)
+	}
+}
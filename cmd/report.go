@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/report"
+)
+
+// reportFetchTimeout bounds fetching the report from a running exporter
+const reportFetchTimeout = 30 * time.Second
+
+// runReport runs the report subcommand, which writes a usage report from
+// the history database, and returns the exit code
+func runReport(args []string) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+
+	configPath := flags.String("config", "config.yaml", "Path to configuration file")
+	month := flags.String("month", "", "Month to report, YYYY-MM (default: last month)")
+	from := flags.String("from", "", "First day to report, YYYY-MM-DD, instead of a month")
+	to := flags.String("to", "", "Last day to report, YYYY-MM-DD, instead of a month")
+	format := flags.String("format", report.FormatCSV, "Output format: csv or json")
+	output := flags.String("output", "", "File to write the report to (default: stdout)")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	if err := writeReport(*configPath, *month, *from, *to, *format, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "report: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func writeReport(configPath, month, from, to, format, output string) error {
+	if format != report.FormatCSV && format != report.FormatJSON {
+		return fmt.Errorf("format must be %s or %s", report.FormatCSV, report.FormatJSON)
+	}
+
+	if configPath == "config.yaml" {
+		if envConfig := os.Getenv("CONFIG_PATH"); envConfig != "" {
+			configPath = envConfig
+		}
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	start, end, err := report.Period(month, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var write func(w io.Writer) error
+
+	db, err := history.OpenReadOnly(cfg.History.Path)
+
+	switch {
+	case err == nil:
+		defer func() { _ = db.Close() }()
+
+		usage, err := report.NewGenerator(db, cfg.Report).Generate(start, end)
+		if err != nil {
+			return err
+		}
+
+		write = func(w io.Writer) error { return usage.Write(w, format) }
+	case errors.Is(err, history.ErrInUse) && cfg.API.Enabled:
+		// The running exporter holds the database, so it serves the report
+		write = func(w io.Writer) error { return fetchReport(w, cfg.API.URL(), month, from, to, format) }
+	case errors.Is(err, history.ErrInUse):
+		return fmt.Errorf("%w, enable api.enabled to fetch the report from the running exporter", err)
+	default:
+		return err
+	}
+
+	if output == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// fetchReport writes the report served by the API of a running exporter
+func fetchReport(w io.Writer, baseURL, month, from, to, format string) error {
+	query := url.Values{"format": {format}}
+
+	for key, value := range map[string]string{"month": month, "from": from, "to": to} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	client := &http.Client{Timeout: reportFetchTimeout}
+
+	resp, err := client.Get(baseURL + "/api/v1/report?" + query.Encode())
+	if err != nil {
+		return fmt.Errorf("failed to fetch the report from the exporter: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+
+		return fmt.Errorf("exporter returned %s: %s", resp.Status, body.Error)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+
+	return err
+}
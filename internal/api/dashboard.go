@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// supplyOrder and colorOrder sort the consumable bars of a card
+var (
+	supplyOrder = []string{"toner", "ink", "drum", "belt_unit", "fuser_unit", "laser_unit", "paper_feeding_kit"}
+	colorOrder  = []string{"black", "cyan", "magenta", "yellow"}
+)
+
+// colorFills are the bar colours of per-colour supplies, other supplies
+// use the neutral fill from the stylesheet
+var colorFills = map[string]string{
+	"black":   "#333333",
+	"cyan":    "#00aeef",
+	"magenta": "#ec008c",
+	"yellow":  "#f5c400",
+}
+
+// pageTotals are the counters shown on a card, in order
+var pageTotals = []struct{ counter, label string }{
+	{printer.CounterTotal, "Total"},
+	{printer.CounterBlack, "Black"},
+	{printer.CounterColor, "Colour"},
+	{printer.CounterDuplex, "Duplex"},
+}
+
+type dashboardPage struct {
+	Refresh int
+	Cards   []card
+}
+
+type card struct {
+	Title    string
+	Host     string
+	Model    string
+	Location string
+
+	Status    string
+	Message   string
+	Reachable bool
+	LastSeen  string
+
+	Bars   []bar
+	Trays  []Tray
+	Alerts []Alert
+	Totals []total
+	Errors []string
+}
+
+type bar struct {
+	Label   string
+	Percent float64
+	State   string
+	Fill    template.CSS
+}
+
+type total struct {
+	Label string
+	Value float64
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, _ *http.Request) {
+	now := s.now()
+	page := dashboardPage{Refresh: int(s.refresh.Seconds())}
+
+	for _, state := range s.store.List() {
+		page.Cards = append(page.Cards, newCard(NewPrinter(state), now))
+	}
+
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, page); err != nil {
+		slog.Error("Failed to render dashboard", "error", err)
+		http.Error(w, "failed to render dashboard", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// newCard builds the dashboard card of a printer
+func newCard(p Printer, now time.Time) card {
+	c := card{
+		Title:     p.Host,
+		Host:      p.Host,
+		Status:    "unknown",
+		Reachable: p.Reachable,
+		LastSeen:  "never",
+		Trays:     p.Trays,
+		Errors:    p.Errors,
+	}
+
+	if p.Info != nil {
+		c.Model = p.Info.Model
+		c.Location = p.Info.Location
+
+		if p.Info.Name != "" {
+			c.Title = p.Info.Name
+		}
+	}
+
+	if p.Status != nil {
+		c.Status = p.Status.State
+		c.Message = p.Status.Message
+		c.Alerts = p.Status.Alerts
+	}
+
+	if p.LastSuccess != nil {
+		c.LastSeen = since(now.Sub(*p.LastSuccess))
+	}
+
+	supplies := slices.Clone(p.Supplies)
+	slices.SortStableFunc(supplies, func(a, b Supply) int {
+		if order := rank(supplyOrder, a.Kind) - rank(supplyOrder, b.Kind); order != 0 {
+			return order
+		}
+
+		return rank(colorOrder, a.Color) - rank(colorOrder, b.Color)
+	})
+
+	for _, supply := range supplies {
+		c.Bars = append(c.Bars, bar{
+			Label:   printer.SupplyName(printer.Supply{Kind: supply.Kind, Color: supply.Color}),
+			Percent: max(0, min(100, supply.Percent)),
+			State:   supply.State,
+			Fill:    template.CSS(colorFills[supply.Color]),
+		})
+	}
+
+	for _, pageTotal := range pageTotals {
+		if value, ok := p.Counters[pageTotal.counter]; ok {
+			c.Totals = append(c.Totals, total{Label: pageTotal.label, Value: value})
+		}
+	}
+
+	return c
+}
+
+// rank returns the position of value in order, unknown values last
+func rank(order []string, value string) int {
+	if i := slices.Index(order, value); i >= 0 {
+		return i
+	}
+
+	return len(order)
+}
+
+// since formats the time since the last successful poll
+func since(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(max(0, d.Seconds())))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
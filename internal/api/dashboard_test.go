@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	collectedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	store := printer.NewStore()
+	store.Update("192.168.1.100", "192.168.1.100", &printer.Snapshot{
+		CollectedAt: collectedAt,
+		Identity:    &printer.Identity{Model: "HL-L3270CDW", Name: "BRW<office>", Location: "Office"},
+		Status:      &printer.Status{State: "stopped"},
+		Alerts:      []printer.Alert{{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam"}},
+		Supplies: []printer.Supply{
+			{Kind: "fuser_unit", Percent: 80, State: "ok"},
+			{Kind: "toner", Color: "cyan", Percent: 5, State: "critical"},
+			{Kind: "toner", Color: "black", Percent: 60, State: "ok"},
+		},
+		Trays:    []printer.Tray{{Name: "tray1", Status: "empty"}},
+		Counters: map[string]float64{printer.CounterTotal: 5432, printer.CounterDrumBlack: 100},
+	}, collectedAt, nil)
+
+	server := NewServer("127.0.0.1:0", store, 30*time.Second)
+	server.now = func() time.Time { return collectedAt.Add(3 * time.Minute) }
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `<meta http-equiv="refresh" content="30">`)
+	assert.Contains(t, body, "BRW&lt;office&gt;")
+	assert.Contains(t, body, "HL-L3270CDW · Office · 192.168.1.100")
+	assert.Contains(t, body, `<span class="badge stopped">stopped</span>`)
+	assert.Contains(t, body, `<li class="alert critical">Paper Jam</li>`)
+	assert.Contains(t, body, `<div class="bar critical">`)
+	assert.Contains(t, body, "background: #00aeef;")
+	assert.Contains(t, body, `<span class="empty">tray1: empty</span>`)
+	assert.Contains(t, body, "<b>5432</b>Total")
+	assert.Contains(t, body, "Last polled 3m ago")
+	assert.NotContains(t, body, "http://", "no external assets")
+	assert.NotContains(t, body, "https://", "no external assets")
+
+	// Toner first, black before cyan, then parts
+	assert.Less(t, strings.Index(body, "Toner black"), strings.Index(body, "Toner cyan"))
+	assert.Less(t, strings.Index(body, "Toner cyan"), strings.Index(body, "Fuser unit"))
+
+	// Only the root path is the dashboard
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDashboardUnreachable(t *testing.T) {
+	store := printer.NewStore()
+	store.Update("192.168.1.100", "192.168.1.100", nil, time.Now(), nil)
+
+	rec := httptest.NewRecorder()
+	NewServer("127.0.0.1:0", store, 0).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `<div class="card unreachable">`)
+	assert.Contains(t, body, "Unreachable, last polled never")
+	assert.NotContains(t, body, `http-equiv="refresh"`)
+}
+
+func TestSince(t *testing.T) {
+	assert.Equal(t, "0s ago", since(-time.Second))
+	assert.Equal(t, "42s ago", since(42*time.Second))
+	assert.Equal(t, "5m ago", since(5*time.Minute+30*time.Second))
+	assert.Equal(t, "2h ago", since(2*time.Hour))
+	assert.Equal(t, "3d ago", since(80*time.Hour))
+}
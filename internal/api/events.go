@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/events"
+)
+
+const (
+	// defaultEventLimit and maxEventLimit bound how many events the events
+	// endpoint returns
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+
+	// streamKeepalive is how often an idle event stream sends a comment, so
+	// proxies don't close it
+	streamKeepalive = 30 * time.Second
+)
+
+// WithEvents serves the events of log from the event endpoints
+func (s *Server) WithEvents(log *events.Log) *Server {
+	s.events = log
+	return s
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	if s.events == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "events are not enabled"})
+		return
+	}
+
+	filter, err := eventFilter(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	filter.From, err = parseTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid from: " + err.Error()})
+		return
+	}
+
+	filter.To, err = parseTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid to: " + err.Error()})
+		return
+	}
+
+	limit := defaultEventLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxEventLimit {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("limit must be between 1 and %d", maxEventLimit)})
+			return
+		}
+	}
+
+	found, err := s.events.Query(filter, limit)
+	if err != nil {
+		slog.Error("Failed to query events", "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to query events"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(found))
+}
+
+// streamEvents sends new events as Server-Sent Events until the client
+// goes away or the server shuts down
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if s.events == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "events are not enabled"})
+		return
+	}
+
+	filter, err := eventFilter(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming is not supported"})
+		return
+	}
+
+	updates, cancel := s.events.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// A comment first, so clients see the stream is open
+	_, _ = fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		case event, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			if !filter.Match(event) {
+				continue
+			}
+
+			err = writeEvent(w, event)
+		}
+
+		if err != nil {
+			slog.Debug("Event stream closed", "error", err)
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one event in the Server-Sent Events format, named by
+// its type
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+
+	return err
+}
+
+// eventFilter reads the printer and type filters shared by both event
+// endpoints. type is a comma-separated list.
+func eventFilter(r *http.Request) (events.Filter, error) {
+	filter := events.Filter{Printer: r.URL.Query().Get("printer")}
+
+	if value := r.URL.Query().Get("type"); value != "" {
+		for eventType := range strings.SplitSeq(value, ",") {
+			if !slices.Contains(events.Types, eventType) {
+				return events.Filter{}, fmt.Errorf("unknown event type %q", eventType)
+			}
+
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/events"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openEvents(t *testing.T) *events.Log {
+	t.Helper()
+
+	log, err := events.Open(config.EventsConfig{Path: filepath.Join(t.TempDir(), "events.jsonl"), MaxSizeMB: 1, MaxFiles: 1})
+	require.NoError(t, err)
+	t.Cleanup(log.Stop)
+
+	return log
+}
+
+func observeTray(log *events.Log, at time.Time, status string) {
+	log.Observe(printer.State{
+		ID:             "192.168.1.100",
+		Host:           "192.168.1.100",
+		Reachable:      true,
+		LastCollection: at,
+		Snapshot:       &printer.Snapshot{CollectedAt: at, Trays: []printer.Tray{{Name: "tray2", Status: status}}},
+	})
+}
+
+func TestEvents(t *testing.T) {
+	log := openEvents(t)
+
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, status := range []string{"ok", "low", "empty", "ok"} {
+		observeTray(log, day.AddDate(0, 0, i), status)
+	}
+
+	handler := NewServer("", printer.NewStore(), 0).WithEvents(log).Handler()
+
+	var body []events.Event
+
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/events?printer=192.168.1.100&type=tray_changed", &body))
+
+	if assert.Len(t, body, 3) {
+		assert.Equal(t, "empty", body[1].To)
+		assert.Equal(t, "tray2", body[1].Subject)
+	}
+
+	body = nil
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/events?from=2026-03-03&limit=1", &body))
+
+	if assert.Len(t, body, 1) {
+		assert.Equal(t, day.AddDate(0, 0, 3), body[0].Time)
+	}
+
+	body = nil
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/events?printer=reception", &body))
+	assert.NotNil(t, body)
+	assert.Empty(t, body)
+
+	var errBody errorResponse
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/events?type=exploded", &errBody))
+	assert.Contains(t, errBody.Error, "unknown event type")
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/events?limit=0", &errBody))
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/events?to=tomorrow", &errBody))
+}
+
+func TestEventsDisabled(t *testing.T) {
+	var errBody errorResponse
+
+	handler := NewServer("", printer.NewStore(), 0).Handler()
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/api/v1/events", &errBody))
+	assert.Equal(t, "events are not enabled", errBody.Error)
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/api/v1/events/stream", &errBody))
+}
+
+func TestEventStream(t *testing.T) {
+	log := openEvents(t)
+
+	server := httptest.NewServer(NewServer("", printer.NewStore(), 0).WithEvents(log).Handler())
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/api/v1/events/stream?type=tray_changed", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readMessage := func() []string {
+		var lines []string
+
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return lines
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	// Subscribed once the stream is open
+	assert.Equal(t, []string{": connected"}, readMessage())
+
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	observeTray(log, at, "ok")
+	observeTray(log, at.Add(time.Minute), "empty")
+
+	message := readMessage()
+	require.Len(t, message, 2)
+	assert.Equal(t, "event: tray_changed", message[0])
+
+	var event events.Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(message[1], "data: ")), &event))
+	assert.Equal(t, "empty", event.To)
+	assert.Equal(t, "192.168.1.100", event.Printer)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/history"
+)
+
+// dateLayout is accepted for from and to besides RFC 3339
+const dateLayout = time.DateOnly
+
+// History is the response of the history endpoint
+type History struct {
+	ID        string             `json:"id"`
+	Snapshots []history.Snapshot `json:"snapshots"`
+}
+
+// WithHistory serves the snapshots in db from the history endpoint
+func (s *Server) WithHistory(db *history.DB) *Server {
+	s.history = db
+	return s
+}
+
+func (s *Server) getHistory(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "history is not enabled"})
+		return
+	}
+
+	id := r.PathValue("id")
+
+	from, err := parseTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid from: " + err.Error()})
+		return
+	}
+
+	to, err := parseTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid to: " + err.Error()})
+		return
+	}
+
+	snaps, err := s.history.Query(id, from, to)
+	if err != nil {
+		slog.Error("Failed to query history", "printer", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to query history"})
+
+		return
+	}
+
+	if len(snaps) == 0 && !s.knownPrinter(id) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "printer not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, History{ID: id, Snapshots: nonNil(snaps)})
+}
+
+// knownPrinter reports whether id is a printer being collected or one with
+// history
+func (s *Server) knownPrinter(id string) bool {
+	if _, ok := s.store.Get(id); ok {
+		return true
+	}
+
+	ids, err := s.history.Printers()
+
+	return err == nil && slices.Contains(ids, id)
+}
+
+// parseTime parses an RFC 3339 time or a date. A date as the end of a
+// range covers the whole day. Empty is the zero time, an open end.
+func parseTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor YYYY-MM-DD", value)
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	db, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	day := time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)
+	for i := range 3 {
+		require.NoError(t, db.Add("192.168.1.100", history.Snapshot{
+			Time:     day.AddDate(0, 0, i),
+			Counters: map[string]float64{"total": float64(1000 + i)},
+		}))
+	}
+
+	store := printer.NewStore()
+	store.Update("192.168.1.101", "192.168.1.101", &printer.Snapshot{}, day, nil)
+
+	handler := NewServer("", store, 0).WithHistory(db).Handler()
+
+	var body History
+
+	// A date as the end covers the whole day
+	assert.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers/192.168.1.100/history?from=2026-01-02&to=2026-01-03", &body))
+	assert.Equal(t, "192.168.1.100", body.ID)
+
+	if assert.Len(t, body.Snapshots, 2) {
+		assert.Equal(t, 1001.0, body.Snapshots[0].Counters["total"])
+		assert.Equal(t, day.AddDate(0, 0, 2), body.Snapshots[1].Time)
+	}
+
+	body = History{}
+	assert.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers/192.168.1.100/history?to=2026-01-01T12:00:00Z", &body))
+	assert.Len(t, body.Snapshots, 1)
+
+	// A collected printer without history yet has an empty list
+	body = History{}
+	assert.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers/192.168.1.101/history", &body))
+	assert.NotNil(t, body.Snapshots)
+	assert.Empty(t, body.Snapshots)
+
+	var errBody errorResponse
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/api/v1/printers/unknown/history", &errBody))
+	assert.Equal(t, "printer not found", errBody.Error)
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/printers/192.168.1.100/history?from=yesterday", &errBody))
+	assert.Contains(t, errBody.Error, "invalid from")
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	var errBody errorResponse
+
+	handler := NewServer("", printer.NewStore(), 0).Handler()
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/api/v1/printers/192.168.1.100/history", &errBody))
+	assert.Equal(t, "history is not enabled", errBody.Error)
+}
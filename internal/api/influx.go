@@ -0,0 +1,35 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/d0ugal/brother-exporter/internal/influx"
+)
+
+// WithInflux serves every printer's state as InfluxDB line protocol from
+// the influx endpoint, with tags added to every line
+func (s *Server) WithInflux(tags map[string]string) *Server {
+	s.influx = true
+	s.influxTags = tags
+
+	return s
+}
+
+func (s *Server) getInflux(w http.ResponseWriter, _ *http.Request) {
+	if !s.influx {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "influx is not enabled"})
+		return
+	}
+
+	var body []byte
+	for _, state := range s.store.List() {
+		body = influx.AppendState(body, state, s.influxTags)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if _, err := w.Write(body); err != nil {
+		slog.Error("Failed to write line protocol", "error", err)
+	}
+}
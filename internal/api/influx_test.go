@@ -0,0 +1,35 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflux(t *testing.T) {
+	store := printer.NewStore()
+	store.Update("192.168.1.100", "192.168.1.100", nil, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), []error{io.EOF})
+
+	handler := NewServer("", store, 0).WithInflux(map[string]string{"site": "hq"}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/influx", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "brother_printer,host=192.168.1.100,site=hq reachable=false 1772366400000000000\n", rec.Body.String())
+}
+
+func TestInfluxDisabled(t *testing.T) {
+	var errBody errorResponse
+
+	handler := NewServer("", printer.NewStore(), 0).Handler()
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/influx", &errBody))
+	assert.Equal(t, "influx is not enabled", errBody.Error)
+}
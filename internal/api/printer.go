@@ -0,0 +1,159 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Printer is the JSON representation of a printer's latest state. Sections
+// that were never collected are omitted.
+type Printer struct {
+	ID   string `json:"id"`
+	Host string `json:"host"`
+
+	Reachable      bool       `json:"reachable"`
+	LastCollection *time.Time `json:"last_collection,omitempty"`
+	LastSuccess    *time.Time `json:"last_success,omitempty"`
+	Errors         []string   `json:"errors"`
+
+	Info        *Info              `json:"info,omitempty"`
+	Status      *Status            `json:"status,omitempty"`
+	BootTime    *time.Time         `json:"boot_time,omitempty"`
+	Supplies    []Supply           `json:"supplies"`
+	Maintenance []Part             `json:"maintenance"`
+	Trays       []Tray             `json:"trays"`
+	Counters    map[string]float64 `json:"counters"`
+
+	// Updated holds when each section was last refreshed
+	Updated map[string]time.Time `json:"updated"`
+}
+
+// Info describes the printer
+type Info struct {
+	Model       string `json:"model"`
+	Serial      string `json:"serial"`
+	Firmware    string `json:"firmware"`
+	MAC         string `json:"mac"`
+	Name        string `json:"name"`
+	Location    string `json:"location"`
+	Contact     string `json:"contact"`
+	Description string `json:"description"`
+}
+
+// Status is the printer state and its active alerts
+type Status struct {
+	State   string  `json:"state"`
+	Message string  `json:"message,omitempty"`
+	Alerts  []Alert `json:"alerts"`
+}
+
+// Alert is a condition reported by the printer
+type Alert struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Supply is a consumable or maintenance part level. State is the level
+// against the configured thresholds; NearEnd is the printer's own near-end
+// flag for the supply kind, where it reports one.
+type Supply struct {
+	Kind    string  `json:"kind"`
+	Color   string  `json:"color,omitempty"`
+	Percent float64 `json:"percent"`
+	State   string  `json:"state,omitempty"`
+	NearEnd *bool   `json:"near_end,omitempty"`
+}
+
+// Part is the remaining page count of a maintenance part
+type Part struct {
+	Kind           string  `json:"kind"`
+	RemainingPages float64 `json:"remaining_pages"`
+}
+
+// Tray is a paper tray status
+type Tray struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// NewPrinter converts a stored state to its JSON representation
+func NewPrinter(state printer.State) Printer {
+	p := Printer{
+		ID:             state.ID,
+		Host:           state.Host,
+		Reachable:      state.Reachable,
+		LastCollection: optionalTime(state.LastCollection),
+		LastSuccess:    optionalTime(state.LastSuccess),
+		Errors:         nonNil(state.Errors),
+		Supplies:       []Supply{},
+		Maintenance:    []Part{},
+		Trays:          []Tray{},
+		Counters:       map[string]float64{},
+		Updated:        map[string]time.Time{},
+	}
+
+	snap := state.Snapshot
+	if snap == nil {
+		return p
+	}
+
+	if snap.Identity != nil {
+		info := Info(*snap.Identity)
+		p.Info = &info
+	}
+
+	if snap.Status != nil {
+		p.Status = &Status{State: snap.Status.State, Message: snap.Status.Message, Alerts: []Alert{}}
+		for _, alert := range snap.Alerts {
+			p.Status.Alerts = append(p.Status.Alerts, Alert(alert))
+		}
+	}
+
+	p.BootTime = optionalTime(snap.BootTime)
+
+	for _, supply := range snap.Supplies {
+		s := Supply{Kind: supply.Kind, Color: supply.Color, Percent: supply.Percent, State: supply.State}
+		if nearEnd, ok := snap.NearEnd[supply.Kind]; ok {
+			s.NearEnd = &nearEnd
+		}
+
+		p.Supplies = append(p.Supplies, s)
+	}
+
+	for _, part := range snap.Maintenance {
+		p.Maintenance = append(p.Maintenance, Part(part))
+	}
+
+	for _, tray := range snap.Trays {
+		p.Trays = append(p.Trays, Tray(tray))
+	}
+
+	for name, value := range snap.Counters {
+		p.Counters[name] = value
+	}
+
+	for section, updated := range snap.Updated {
+		p.Updated[section] = updated
+	}
+
+	return p
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+
+	return slices.Clone(s)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/d0ugal/brother-exporter/internal/report"
+)
+
+// WithReports serves the usage reports of g from the report endpoint
+func (s *Server) WithReports(g *report.Generator) *Server {
+	s.reports = g
+	return s
+}
+
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "history is not enabled"})
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = report.FormatJSON
+	}
+
+	if format != report.FormatJSON && format != report.FormatCSV {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "format must be json or csv"})
+		return
+	}
+
+	from, to, err := report.Period(query.Get("month"), query.Get("from"), query.Get("to"), s.now())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	usage, err := s.reports.Generate(from, to)
+	if err != nil {
+		slog.Error("Failed to generate report", "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to generate report"})
+
+		return
+	}
+
+	if format == report.FormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="usage-`+from.Format("2006-01-02")+`.csv"`)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	if err := usage.Write(w, format); err != nil {
+		slog.Error("Failed to write report", "error", err)
+	}
+}
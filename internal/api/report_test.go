@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	db, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	for i, total := range []float64{1000, 1250} {
+		require.NoError(t, db.Add("192.168.1.100", history.Snapshot{
+			Time:     time.Date(2026, 2, 1+i*27, 0, 0, 30, 0, time.UTC),
+			Counters: map[string]float64{"total": total},
+		}))
+	}
+
+	server := NewServer("", printer.NewStore(), 0).WithReports(report.NewGenerator(db, config.ReportConfig{}))
+	server.now = func() time.Time { return time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC) }
+	handler := server.Handler()
+
+	// Last month by default
+	var body report.Report
+
+	assert.Equal(t, http.StatusOK, get(t, handler, "/api/v1/report", &body))
+
+	if assert.Len(t, body.Printers, 1) {
+		assert.Equal(t, int64(250), body.Printers[0].Total)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/report?month=2026-02&format=csv", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 2)
+
+	var errBody errorResponse
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/report?month=February", &errBody))
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/api/v1/report?format=xml", &errBody))
+}
@@ -0,0 +1,132 @@
+// Package api serves the latest decoded printer state as JSON and as an
+// HTML dashboard, next to the Prometheus metrics, and the stored history
+// and event log when enabled.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/events"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/report"
+)
+
+// shutdownTimeout bounds how long Stop waits for in-flight requests
+const shutdownTimeout = 5 * time.Second
+
+// Server is the HTTP server of the JSON API. It implements the promexporter
+// collector interface so it starts and stops with the application.
+type Server struct {
+	store  *printer.Store
+	server *http.Server
+
+	// history and reports serve the history and report endpoints when
+	// enabled
+	history *history.DB
+	reports *report.Generator
+	// events serves the event endpoints when enabled
+	events *events.Log
+	// influx serves the line protocol endpoint when enabled, with
+	// influxTags on every line
+	influx     bool
+	influxTags map[string]string
+
+	// closing is closed on shutdown to end the event streams, which would
+	// otherwise hold Stop up
+	closing chan struct{}
+
+	// refresh is how often the dashboard reloads itself
+	refresh time.Duration
+	now     func() time.Time
+}
+
+// NewServer creates an API server listening on addr that reads from store.
+// The dashboard reloads every refresh, usually the collection interval.
+func NewServer(addr string, store *printer.Store, refresh time.Duration) *Server {
+	s := &Server{store: store, refresh: refresh, now: time.Now, closing: make(chan struct{})}
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	s.server.RegisterOnShutdown(func() { close(s.closing) })
+
+	return s
+}
+
+// Handler returns the dashboard and API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.dashboard)
+	mux.HandleFunc("GET /api/v1/printers", s.listPrinters)
+	mux.HandleFunc("GET /api/v1/printers/{id}", s.getPrinter)
+	mux.HandleFunc("GET /api/v1/printers/{id}/history", s.getHistory)
+	mux.HandleFunc("GET /api/v1/report", s.getReport)
+	mux.HandleFunc("GET /api/v1/events", s.listEvents)
+	mux.HandleFunc("GET /api/v1/events/stream", s.streamEvents)
+	mux.HandleFunc("GET /influx", s.getInflux)
+
+	return mux
+}
+
+// Start serves the API in the background
+func (s *Server) Start(_ context.Context) {
+	slog.Info("Starting API server", "address", s.server.Addr)
+
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("API server failed", "error", err)
+		}
+	}()
+}
+
+// Stop shuts the server down, waiting briefly for in-flight requests
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		slog.Error("API server shutdown error", "error", err)
+	}
+}
+
+func (s *Server) listPrinters(w http.ResponseWriter, _ *http.Request) {
+	states := s.store.List()
+
+	printers := make([]Printer, 0, len(states))
+	for _, state := range states {
+		printers = append(printers, NewPrinter(state))
+	}
+
+	writeJSON(w, http.StatusOK, printers)
+}
+
+func (s *Server) getPrinter(w http.ResponseWriter, r *http.Request) {
+	state, ok := s.store.Get(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "printer not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewPrinter(state))
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to write API response", "error", err)
+	}
+}
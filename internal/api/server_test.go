@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, handler http.Handler, path string, body any) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), body))
+
+	return rec.Code
+}
+
+func TestPrinters(t *testing.T) {
+	collectedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	store := printer.NewStore()
+	store.Update("192.168.1.100", "192.168.1.100", &printer.Snapshot{
+		CollectedAt: collectedAt,
+		Identity:    &printer.Identity{Model: "HL-L3270CDW", Serial: "E78096A9N123456", Location: "Office"},
+		Status:      &printer.Status{State: "stopped", Message: "Paper Jam"},
+		Alerts:      []printer.Alert{{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam"}},
+		Supplies: []printer.Supply{
+			{Kind: "toner", Color: "black", Percent: 60, State: "ok"},
+			{Kind: "belt_unit", Percent: 90},
+		},
+		NearEnd:     map[string]bool{"toner": false},
+		Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: 48000}},
+		Trays:       []printer.Tray{{Name: "tray1", Status: "ok"}},
+		Counters:    map[string]float64{printer.CounterTotal: 5432},
+	}, collectedAt, []error{errors.New("web: connection refused")})
+
+	handler := NewServer("127.0.0.1:0", store, 0).Handler()
+
+	var printers []Printer
+
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers", &printers))
+	require.Len(t, printers, 1)
+
+	var p Printer
+
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers/192.168.1.100", &p))
+	assert.Equal(t, printers[0], p)
+
+	assert.True(t, p.Reachable)
+	assert.Equal(t, collectedAt, *p.LastSuccess)
+	assert.Equal(t, []string{"web: connection refused"}, p.Errors)
+	assert.Equal(t, "HL-L3270CDW", p.Info.Model)
+	assert.Equal(t, "Office", p.Info.Location)
+	assert.Equal(t, &Status{
+		State:   "stopped",
+		Message: "Paper Jam",
+		Alerts:  []Alert{{Code: "jammed", Severity: "critical", Message: "Paper Jam"}},
+	}, p.Status)
+	assert.Nil(t, p.BootTime)
+
+	nearEnd := false
+	assert.Equal(t, []Supply{
+		{Kind: "toner", Color: "black", Percent: 60, State: "ok", NearEnd: &nearEnd},
+		{Kind: "belt_unit", Percent: 90},
+	}, p.Supplies)
+	assert.Equal(t, []Part{{Kind: "fuser_unit", RemainingPages: 48000}}, p.Maintenance)
+	assert.Equal(t, []Tray{{Name: "tray1", Status: "ok"}}, p.Trays)
+	assert.Equal(t, map[string]float64{"total": 5432}, p.Counters)
+	assert.Equal(t, collectedAt, p.Updated[printer.SectionSupplies])
+	assert.NotContains(t, p.Updated, printer.SectionUptime)
+}
+
+func TestPrinterNotFound(t *testing.T) {
+	handler := NewServer("127.0.0.1:0", printer.NewStore(), 0).Handler()
+
+	var printers []Printer
+
+	require.Equal(t, http.StatusOK, get(t, handler, "/api/v1/printers", &printers))
+	assert.Empty(t, printers)
+
+	var body errorResponse
+
+	require.Equal(t, http.StatusNotFound, get(t, handler, "/api/v1/printers/unknown", &body))
+	assert.Equal(t, "printer not found", body.Error)
+}
@@ -0,0 +1,228 @@
+// Package check evaluates a printer's state as a Nagios or Icinga plugin
+// result: a state that is also the exit code, a status line and perfdata.
+package check
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// State is a plugin state, whose value is the plugin's exit code
+type State int
+
+// Plugin states
+const (
+	OK State = iota
+	Warning
+	Critical
+	Unknown
+)
+
+func (s State) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Result is the outcome of a check
+type Result struct {
+	State State
+	// Problems are what raised the state, e.g. "Toner black 4% (critical)".
+	// Summary is the output when there are none.
+	Problems []string
+	Summary  string
+	Perfdata []Perfdata
+}
+
+// Perfdata is one performance data value. Warn and Crit are plugin
+// threshold ranges, e.g. "15:" for a level alerting below 15.
+type Perfdata struct {
+	Label string
+	Value float64
+	UOM   string
+	Warn  string
+	Crit  string
+	Min   string
+	Max   string
+}
+
+func (p Perfdata) String() string {
+	label := p.Label
+	if strings.ContainsAny(label, " '=") {
+		label = "'" + strings.ReplaceAll(label, "'", "''") + "'"
+	}
+
+	value := strconv.FormatFloat(p.Value, 'f', -1, 64) + p.UOM
+
+	return strings.TrimRight(strings.Join([]string{label + "=" + value, p.Warn, p.Crit, p.Min, p.Max}, ";"), ";")
+}
+
+// Failed is the result when the check could not run a collection at all,
+// e.g. when it timed out
+func Failed(host string, err error) Result {
+	return Result{State: Unknown, Summary: fmt.Sprintf("printer %s could not be checked: %v", host, err)}
+}
+
+// Evaluate checks the printer's status, alerts, supplies and trays. A
+// supply is judged by its state against the printer's thresholds, which
+// also become its perfdata thresholds; a stopped printer and critical
+// alerts are critical, an empty tray and warning alerts a warning. An
+// unreachable printer is critical.
+func Evaluate(state printer.State, cfg config.PrinterConfig) Result {
+	var result Result
+
+	if state.LastCollection.IsZero() {
+		result.State = Unknown
+		result.Summary = fmt.Sprintf("no data collected from %s", state.Host)
+
+		return result
+	}
+
+	if !state.Reachable || state.Snapshot == nil {
+		result.raise(Critical, fmt.Sprintf("printer %s is unreachable", state.Host))
+		result.Problems = append(result.Problems, state.Errors...)
+
+		return result
+	}
+
+	snap := state.Snapshot
+
+	if snap.Status != nil {
+		if snap.Status.State == "stopped" {
+			problem := "printer stopped"
+			if snap.Status.Message != "" {
+				problem += ": " + snap.Status.Message
+			}
+
+			result.raise(Critical, problem)
+		}
+
+		for _, alert := range snap.Alerts {
+			problem := "alert " + alert.Code
+			if alert.Message != "" {
+				problem += " (" + alert.Message + ")"
+			}
+
+			switch alert.Severity {
+			case printer.SeverityCritical:
+				result.raise(Critical, problem)
+			case printer.SeverityWarning:
+				result.raise(Warning, problem)
+			}
+		}
+
+		result.Perfdata = append(result.Perfdata, Perfdata{Label: "alerts", Value: float64(len(snap.Alerts)), Min: "0"})
+	}
+
+	for _, supply := range snap.Supplies {
+		if math.IsNaN(supply.Percent) {
+			continue
+		}
+
+		problem := fmt.Sprintf("%s %s%% (%s)", printer.SupplyName(supply), strconv.FormatFloat(supply.Percent, 'f', -1, 64), supply.State)
+
+		switch supply.State {
+		case "low":
+			result.raise(Warning, problem)
+		case "critical", "empty":
+			result.raise(Critical, problem)
+		}
+
+		label := supply.Kind
+		if supply.Color != "" {
+			label += "_" + supply.Color
+		}
+
+		t := cfg.ThresholdsFor(supply.Kind, supply.Color)
+		result.Perfdata = append(result.Perfdata, Perfdata{
+			Label: label,
+			Value: supply.Percent,
+			UOM:   "%",
+			Warn:  strconv.FormatFloat(t.Warning, 'f', -1, 64) + ":",
+			Crit:  strconv.FormatFloat(t.Critical, 'f', -1, 64) + ":",
+			Min:   "0",
+			Max:   "100",
+		})
+	}
+
+	for _, part := range snap.Maintenance {
+		result.Perfdata = append(result.Perfdata, Perfdata{Label: part.Kind + "_remaining_pages", Value: part.RemainingPages, Min: "0"})
+	}
+
+	for _, tray := range snap.Trays {
+		if tray.Status == "empty" {
+			result.raise(Warning, fmt.Sprintf("tray %s empty", tray.Name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(snap.Counters)) {
+		result.Perfdata = append(result.Perfdata, Perfdata{Label: "pages_" + name, Value: snap.Counters[name], UOM: "c"})
+	}
+
+	result.Summary = summary(state)
+
+	return result
+}
+
+// raise adds a problem, raising the result to state if it is worse
+func (r *Result) raise(state State, problem string) {
+	r.Problems = append(r.Problems, problem)
+
+	if state > r.State {
+		r.State = state
+	}
+}
+
+// summary describes a printer without problems, e.g. "HL-L3270CDW series
+// is ready"
+func summary(state printer.State) string {
+	name := state.Host
+	if identity := state.Snapshot.Identity; identity != nil && identity.Model != "" {
+		name = identity.Model
+	}
+
+	if status := state.Snapshot.Status; status != nil {
+		return fmt.Sprintf("%s is %s", name, status.State)
+	}
+
+	return name + " is reachable"
+}
+
+// Write writes the plugin output: the state and the problems, or the
+// summary, then the perfdata after a pipe
+func (r Result) Write(w io.Writer) error {
+	text := r.Summary
+	if len(r.Problems) > 0 {
+		text = strings.Join(r.Problems, ", ")
+	}
+
+	line := fmt.Sprintf("BROTHER %s - %s", r.State, text)
+
+	if len(r.Perfdata) > 0 {
+		perfdata := make([]string, 0, len(r.Perfdata))
+		for _, p := range r.Perfdata {
+			perfdata = append(perfdata, p.String())
+		}
+
+		line += " | " + strings.Join(perfdata, " ")
+	}
+
+	_, err := fmt.Fprintln(w, line)
+
+	return err
+}
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func testState(snap *printer.Snapshot) printer.State {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	return printer.State{ID: "192.168.1.100", Host: "192.168.1.100", Reachable: true, LastCollection: at, LastSuccess: at, Snapshot: snap}
+}
+
+func output(t *testing.T, result Result) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, result.Write(&buf))
+
+	return buf.String()
+}
+
+func TestEvaluateOK(t *testing.T) {
+	cfg := config.PrinterConfig{Thresholds: map[string]config.ThresholdConfig{"toner": {Warning: ptr(15), Critical: ptr(5)}}}
+
+	result := Evaluate(testState(&printer.Snapshot{
+		Identity:    &printer.Identity{Model: "HL-L3270CDW series"},
+		Status:      &printer.Status{State: "ready"},
+		Supplies:    []printer.Supply{{Kind: "toner", Color: "black", Percent: 60, State: "ok"}, {Kind: "belt_unit", Percent: math.NaN()}},
+		Maintenance: []printer.Part{{Kind: "drum_unit", RemainingPages: 12000}},
+		Trays:       []printer.Tray{{Name: "tray1", Status: "low"}},
+		Counters:    map[string]float64{printer.CounterTotal: 5432, printer.CounterColor: 1432},
+	}), cfg)
+
+	assert.Equal(t, OK, result.State)
+	assert.Equal(t, "BROTHER OK - HL-L3270CDW series is ready | alerts=0;;;0 toner_black=60%;15:;5:;0;100 "+
+		"drum_unit_remaining_pages=12000;;;0 pages_color=1432c pages_total=5432c\n", output(t, result))
+}
+
+func TestEvaluateProblems(t *testing.T) {
+	result := Evaluate(testState(&printer.Snapshot{
+		Status: &printer.Status{State: "idle"},
+		Alerts: []printer.Alert{{Code: "low_paper", Severity: printer.SeverityWarning}},
+		Supplies: []printer.Supply{
+			{Kind: "toner", Color: "cyan", Percent: 8, State: "low"},
+			{Kind: "drum", Percent: 80, State: "ok"},
+		},
+		Trays: []printer.Tray{{Name: "tray2", Status: "empty"}},
+	}), config.PrinterConfig{})
+
+	assert.Equal(t, Warning, result.State)
+	assert.Equal(t, []string{"alert low_paper", "Toner cyan 8% (low)", "tray tray2 empty"}, result.Problems)
+
+	// Critical wins over warnings
+	result = Evaluate(testState(&printer.Snapshot{
+		Status:   &printer.Status{State: "stopped", Message: "Replace Toner"},
+		Alerts:   []printer.Alert{{Code: "no_toner", Severity: printer.SeverityCritical, Message: "Replace Toner"}},
+		Supplies: []printer.Supply{{Kind: "toner", Color: "black", Percent: 0, State: "empty"}},
+	}), config.PrinterConfig{})
+
+	assert.Equal(t, Critical, result.State)
+	assert.Equal(t, "BROTHER CRITICAL - printer stopped: Replace Toner, alert no_toner (Replace Toner), Toner black 0% (empty) | "+
+		"alerts=1;;;0 toner_black=0%;10:;5:;0;100\n", output(t, result))
+}
+
+func TestEvaluateUnreachable(t *testing.T) {
+	state := testState(&printer.Snapshot{})
+	state.Reachable = false
+	state.Errors = []string{"snmp: printer did not answer any SNMP request"}
+
+	result := Evaluate(state, config.PrinterConfig{})
+	assert.Equal(t, Critical, result.State)
+	assert.Equal(t, "BROTHER CRITICAL - printer 192.168.1.100 is unreachable, snmp: printer did not answer any SNMP request\n", output(t, result))
+
+	result = Evaluate(printer.State{Host: "192.168.1.100"}, config.PrinterConfig{})
+	assert.Equal(t, Unknown, result.State)
+
+	result = Failed("192.168.1.100", errors.New("timed out after 1m0s"))
+	assert.Equal(t, "BROTHER UNKNOWN - printer 192.168.1.100 could not be checked: timed out after 1m0s\n", output(t, result))
+}
+
+func TestPerfdataString(t *testing.T) {
+	assert.Equal(t, "'toner level'=1.5%", Perfdata{Label: "toner level", Value: 1.5, UOM: "%"}.String())
+	assert.Equal(t, "pages=3c;;;0", Perfdata{Label: "pages", Value: 3, UOM: "c", Min: "0"}.String())
+}
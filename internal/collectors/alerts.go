@@ -0,0 +1,152 @@
+package collectors
+
+import (
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// snmpErrorStates are the hrPrinterDetectedErrorState bits, most
+// significant bit of the first octet first
+var snmpErrorStates = []printer.Alert{
+	{Code: "low_paper", Severity: printer.SeverityWarning},
+	{Code: "no_paper", Severity: printer.SeverityCritical},
+	{Code: "low_toner", Severity: printer.SeverityWarning},
+	{Code: "no_toner", Severity: printer.SeverityCritical},
+	{Code: "door_open", Severity: printer.SeverityCritical},
+	{Code: "jammed", Severity: printer.SeverityCritical},
+	{Code: "offline", Severity: printer.SeverityCritical},
+	{Code: "service_requested", Severity: printer.SeverityCritical},
+	{Code: "input_tray_missing", Severity: printer.SeverityCritical},
+	{Code: "output_tray_missing", Severity: printer.SeverityCritical},
+	{Code: "marker_supply_missing", Severity: printer.SeverityCritical},
+	{Code: "output_near_full", Severity: printer.SeverityWarning},
+	{Code: "output_full", Severity: printer.SeverityCritical},
+	{Code: "input_tray_empty", Severity: printer.SeverityWarning},
+	{Code: "overdue_prevent_maint", Severity: printer.SeverityWarning},
+}
+
+// snmpAlerts decodes hrPrinterDetectedErrorState
+func snmpAlerts(bits []byte) []printer.Alert {
+	var alerts []printer.Alert
+
+	for i, alert := range snmpErrorStates {
+		octet := i / 8
+		if octet < len(bits) && bits[octet]&(0x80>>(i%8)) != 0 {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts
+}
+
+// ippReasonCodes maps printer-state-reasons keywords, without their
+// -error/-warning/-report suffix, to alert codes shared with the other
+// sources. Other keywords keep their own name.
+var ippReasonCodes = map[string]string{
+	"media-low":               "low_paper",
+	"media-empty":             "no_paper",
+	"media-needed":            "no_paper",
+	"toner-low":               "low_toner",
+	"toner-empty":             "no_toner",
+	"marker-supply-low":       "low_toner",
+	"marker-supply-empty":     "no_toner",
+	"door-open":               "door_open",
+	"cover-open":              "door_open",
+	"media-jam":               "jammed",
+	"offline":                 "offline",
+	"shutdown":                "offline",
+	"output-area-almost-full": "output_near_full",
+	"output-area-full":        "output_full",
+	"input-tray-missing":      "input_tray_missing",
+	"output-tray-missing":     "output_tray_missing",
+}
+
+// ippAlerts maps printer-state-reasons to alerts. Reports are informational
+// and skipped.
+func ippAlerts(reasons []string) []printer.Alert {
+	var alerts []printer.Alert
+
+	for _, reason := range reasons {
+		severity := printer.SeverityCritical
+
+		switch {
+		case reason == "none", strings.HasSuffix(reason, "-report"):
+			continue
+		case strings.HasSuffix(reason, "-warning"):
+			severity = printer.SeverityWarning
+			reason = strings.TrimSuffix(reason, "-warning")
+		default:
+			reason = strings.TrimSuffix(reason, "-error")
+		}
+
+		code, ok := ippReasonCodes[reason]
+		if !ok {
+			code = strings.ReplaceAll(reason, "-", "_")
+		}
+
+		alerts = append(alerts, printer.Alert{Code: code, Severity: severity, Message: reason})
+	}
+
+	return alerts
+}
+
+// webAlert maps a web UI status message other than ready or sleeping to an
+// alert, or returns false
+func webAlert(message string) (printer.Alert, bool) {
+	lower := strings.ToLower(message)
+
+	var alert printer.Alert
+
+	switch {
+	case lower == "" || lower == "ready" || lower == "sleep" || lower == "deep sleep",
+		strings.Contains(lower, "printing"), strings.Contains(lower, "warming up"),
+		strings.Contains(lower, "please wait"), strings.Contains(lower, "cooling down"):
+		return alert, false
+	case strings.HasPrefix(lower, "toner low"), strings.HasPrefix(lower, "replace toner soon"):
+		alert = printer.Alert{Code: "low_toner", Severity: printer.SeverityWarning}
+	case strings.Contains(lower, "no toner"), strings.Contains(lower, "replace toner"):
+		alert = printer.Alert{Code: "no_toner", Severity: printer.SeverityCritical}
+	case strings.Contains(lower, "jam"):
+		alert = printer.Alert{Code: "jammed", Severity: printer.SeverityCritical}
+	case strings.Contains(lower, "open"):
+		alert = printer.Alert{Code: "door_open", Severity: printer.SeverityCritical}
+	case strings.Contains(lower, "no paper"):
+		alert = printer.Alert{Code: "no_paper", Severity: printer.SeverityCritical}
+	case strings.Contains(lower, "replace"):
+		alert = printer.Alert{Code: "service_requested", Severity: printer.SeverityWarning}
+	default:
+		alert = printer.Alert{Code: "other", Severity: printer.SeverityWarning}
+	}
+
+	alert.Message = message
+
+	return alert, true
+}
+
+// pjlAlert maps a PJL status code to an alert, or returns false for the
+// informational codes
+func pjlAlert(code int, display string) (printer.Alert, bool) {
+	var alert printer.Alert
+
+	switch {
+	case code == 10006:
+		alert = printer.Alert{Code: "low_toner", Severity: printer.SeverityWarning}
+	case code == 40021:
+		alert = printer.Alert{Code: "door_open", Severity: printer.SeverityCritical}
+	case code >= 41000 && code < 42000:
+		alert = printer.Alert{Code: "no_paper", Severity: printer.SeverityCritical}
+	case code >= 42000 && code < 43000:
+		alert = printer.Alert{Code: "jammed", Severity: printer.SeverityCritical}
+	case code >= 35000 && code < 36000:
+		alert = printer.Alert{Code: "other", Severity: printer.SeverityWarning}
+	case code >= 40000 && code < 50000:
+		alert = printer.Alert{Code: "other", Severity: printer.SeverityCritical}
+	default:
+		return alert, false
+	}
+
+	alert.Message = display
+
+	return alert, true
+}
@@ -0,0 +1,57 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSNMPAlerts(t *testing.T) {
+	// noPaper (bit 1) and jammed (bit 5) in the first octet, inputTrayEmpty
+	// (bit 13) in the second
+	alerts := snmpAlerts([]byte{0x44, 0x04})
+
+	assert.Equal(t, []printer.Alert{
+		{Code: "no_paper", Severity: printer.SeverityCritical},
+		{Code: "jammed", Severity: printer.SeverityCritical},
+		{Code: "input_tray_empty", Severity: printer.SeverityWarning},
+	}, alerts)
+
+	assert.Empty(t, snmpAlerts([]byte{0x00}))
+	assert.Empty(t, snmpAlerts(nil))
+}
+
+func TestIPPAlerts(t *testing.T) {
+	alerts := ippAlerts([]string{"media-empty-error", "toner-low-warning", "other-report", "cooling-down"})
+
+	assert.Equal(t, []printer.Alert{
+		{Code: "no_paper", Severity: printer.SeverityCritical, Message: "media-empty"},
+		{Code: "low_toner", Severity: printer.SeverityWarning, Message: "toner-low"},
+		{Code: "cooling_down", Severity: printer.SeverityCritical, Message: "cooling-down"},
+	}, alerts)
+
+	assert.Empty(t, ippAlerts([]string{"none"}))
+}
+
+func TestWebAlert(t *testing.T) {
+	alert, ok := webAlert("Paper Jam Tray 1")
+	assert.True(t, ok)
+	assert.Equal(t, printer.Alert{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam Tray 1"}, alert)
+
+	alert, ok = webAlert("Toner Low")
+	assert.True(t, ok)
+	assert.Equal(t, "low_toner", alert.Code)
+
+	_, ok = webAlert("Sleep")
+	assert.False(t, ok)
+}
+
+func TestPJLAlert(t *testing.T) {
+	alert, ok := pjlAlert(42000, "Paper Jam")
+	assert.True(t, ok)
+	assert.Equal(t, printer.Alert{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam"}, alert)
+
+	_, ok = pjlAlert(10001, "Ready")
+	assert.False(t, ok)
+}
@@ -5,13 +5,19 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/escl"
+	"github.com/d0ugal/brother-exporter/internal/ipp"
 	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/pjl"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/webui"
 	"github.com/d0ugal/promexporter/app"
 	"github.com/d0ugal/promexporter/tracing"
 	"github.com/gosnmp/gosnmp"
@@ -85,35 +91,19 @@ func splitIntoChunks(s string, chunkSize int) []string {
 	return chunks
 }
 
-// calculateStatusFromLevel calculates status string and value based on percentage level
-func calculateStatusFromLevel(level float64) (status string, statusValue float64) {
-	status = "ok"
-	statusValue = 1.0
-
-	if level < BrotherLowThreshold {
-		status = "low"
-		statusValue = 0.0
-	} else if level == 0 {
-		status = "empty"
-		statusValue = 0.0
-	}
-
-	return status, statusValue
-}
-
 // handleCollectionError is a helper function for the repetitive error handling pattern in collectMetrics
 func (bc *BrotherCollector) handleCollectionError(err error, operation string) {
 	if err != nil {
 		slog.Error("Failed to collect "+operation, "error", err)
-		bc.metrics.PrinterConnectionErrors.With(prometheus.Labels{
+		bc.metrics.PrinterConnectionErrors.With(bc.labels(prometheus.Labels{
 			"host":       bc.config.Printer.Host,
 			"error_type": operation,
-		}).Inc()
+		})).Inc()
 	}
 }
 
 // collectColorLevelsWithStatus collects level and status metrics for each color using the specified OID base
-func (bc *BrotherCollector) collectColorLevelsWithStatus(ctx context.Context, oidBase string, colors []string, levelMetric, statusMetric *prometheus.GaugeVec, contextName string) {
+func (bc *BrotherCollector) collectColorLevelsWithStatus(ctx context.Context, snap *printer.Snapshot, oidBase, supply string, colors []string, contextName string) {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -139,7 +129,7 @@ func (bc *BrotherCollector) collectColorLevelsWithStatus(ctx context.Context, oi
 
 		getStart := time.Now()
 
-		result, err := bc.client.Get([]string{oid})
+		result, err := bc.snmpGet([]string{oid})
 
 		getDuration := time.Since(getStart)
 
@@ -173,20 +163,7 @@ func (bc *BrotherCollector) collectColorLevelsWithStatus(ctx context.Context, oi
 
 			parseDuration := time.Since(parseStart)
 
-			// Set level metric
-			levelMetric.With(prometheus.Labels{
-				"host":  bc.config.Printer.Host,
-				"color": color,
-			}).Set(percentage)
-
-			// Set status based on level
-			status, statusValue := calculateStatusFromLevel(percentage)
-
-			statusMetric.With(prometheus.Labels{
-				"host":   bc.config.Printer.Host,
-				"color":  color,
-				"status": status,
-			}).Set(statusValue)
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: supply, Color: color, Percent: percentage})
 
 			colorsCollected++
 
@@ -195,7 +172,6 @@ func (bc *BrotherCollector) collectColorLevelsWithStatus(ctx context.Context, oi
 					attribute.Float64("color."+color+".get_duration_seconds", getDuration.Seconds()),
 					attribute.Float64("color."+color+".parse_duration_seconds", parseDuration.Seconds()),
 					attribute.Float64("color."+color+".percentage", percentage),
-					attribute.String("color."+color+".status", status),
 				)
 			}
 		}
@@ -222,6 +198,64 @@ type BrotherCollector struct {
 	client  *gosnmp.GoSNMP
 	mu      sync.RWMutex
 	done    chan struct{}
+
+	// supplyStates remembers the last derived state per consumable so
+	// threshold hysteresis can be applied across collection cycles
+	supplyStates map[string]SupplyState
+	stateMu      sync.Mutex
+
+	// customLabels holds the configured labels added to every metric
+	customLabels *customLabels
+
+	// schedule decides which subsystems run on each tick
+	schedule *schedule
+
+	// brotherUnsupported is set when the last Brother-specific collection
+	// failed, which also rules out the nextcare data
+	brotherUnsupported bool
+
+	// lastSuccess records when each collection step last succeeded
+	lastSuccess map[string]time.Time
+
+	// cycleRequests and cycleAnswered count the SNMP requests of the
+	// current cycle, to tell an unreachable printer from a partial failure
+	cycleRequests int
+	cycleAnswered int
+
+	// sources are the configured data sources in priority order
+	sources []configuredSource
+
+	// store keeps the latest decoded printer state for consumers other
+	// than Prometheus
+	store *printer.Store
+
+	// ippClient, webClient and pjlClient are created on first use for
+	// their source, under clientsMu
+	clientsMu sync.Mutex
+	ippClient *ipp.Client
+	webClient *webui.Client
+	pjlClient *pjl.Client
+
+	// esclClient polls the scanner when the scanner collector is enabled
+	esclClient          *escl.Client
+	scannerCapabilities *escl.Capabilities
+	scannerStatus       *escl.Status
+	scanJobs            scanJobs
+
+	// trapReceiver listens for SNMP traps when enabled, and queues the
+	// printer's on traps for the run loop
+	trapReceiver *trapReceiver
+	traps        chan printerTrap
+
+	// unfilled holds, per source and section, the gap the source was asked
+	// to fill and couldn't. It isn't asked again while the sources before
+	// it leave the same gap, e.g. IPP for a MAC it never reports.
+	unfilled map[string]map[string]string
+
+	// probe records every SNMP answer while Probe runs, and snmpV3 is the
+	// user it polls as instead of v2c with the community, when set
+	probe  *probeRecorder
+	snmpV3 config.SNMPv3Config
 }
 
 // Brother printer SNMP OIDs
@@ -238,6 +272,8 @@ const (
 
 	// OIDPrinterStatus is the printer status OID
 	OIDPrinterStatus = "1.3.6.1.2.1.25.3.2.1.5.1"
+	// OIDPrinterDetectedErrorState is the HOST-RESOURCES-MIB error bit string
+	OIDPrinterDetectedErrorState = "1.3.6.1.2.1.25.3.5.1.2.1"
 
 	// OIDBrotherConsumableInfo and related OIDs are Brother-specific consumable OIDs (these work better than standard MIB)
 	OIDBrotherConsumableInfo  = "1.3.6.1.4.1.2435.2.3.9.4.2.1.5.5.1.0"  // Consumable info
@@ -258,6 +294,8 @@ const (
 	OIDTonerLevelBase      = "1.3.6.1.2.1.43.11.1.1.9.1"
 	OIDDrumLevelBase       = "1.3.6.1.2.1.43.11.1.1.8.1"
 	OIDPaperTrayStatusBase = "1.3.6.1.2.1.43.8.2.1.10.1"
+	// OIDSuppliesDescriptionBase names the supplies rows, only read by a probe
+	OIDSuppliesDescriptionBase = "1.3.6.1.2.1.43.11.1.1.6.1"
 
 	// OIDPageCountTotal is a page counter OID (standard MIB - these work reliably)
 	OIDPageCountTotal = "1.3.6.1.2.1.43.10.2.1.4.1.1" // Standard MIB total page count
@@ -267,7 +305,49 @@ const (
 const (
 	BrotherChunkSize     = 14  // Size of data chunks in Brother hex strings
 	BrotherPercentageDiv = 100 // Divisor for percentage values from Brother data
-	BrotherLowThreshold  = 10  // Threshold for "low" status (percentage)
+)
+
+// Brother data record codes
+var (
+	// brotherMaintenanceCodes names the level records of the Brother
+	// maintenance data by type code (from const.py)
+	brotherMaintenanceCodes = map[string]string{
+		"6f": "black_toner_remaining",
+		"70": "cyan_toner_remaining",
+		"71": "magenta_toner_remaining",
+		"72": "yellow_toner_remaining",
+		"79": "cyan_drum_remaining",
+		"7a": "magenta_drum_remaining",
+		"7b": "yellow_drum_remaining",
+		"80": "black_drum_remaining",
+		"69": "belt_unit_remaining",
+		"6a": "fuser_unit_remaining",
+		"6b": "laser_unit_remaining",
+		"6c": "paper_feeding_kit_remaining",
+	}
+
+	// brotherNearEndCodes are the printer-reported status flags of the
+	// maintenance data; where present, a non-zero value means the printer
+	// itself considers the part near the end of its life
+	brotherNearEndCodes = map[string]string{
+		"31": "toner",
+		"63": "drum",
+	}
+
+	// brotherNextCareCodes names the remaining page records of the Brother
+	// nextcare data by type code (from const.py)
+	brotherNextCareCodes = map[string]string{
+		"73": "laser_unit_remaining_pages",
+		"77": "paper_feeding_kit_1_remaining_pages",
+		"82": "drum_remaining_pages",
+		"86": "paper_feeding_kit_mp_remaining_pages",
+		"88": "belt_unit_remaining_pages",
+		"89": "fuser_unit_remaining_pages",
+		"a4": "black_drum_remaining_pages",
+		"a5": "cyan_drum_remaining_pages",
+		"a6": "magenta_drum_remaining_pages",
+		"a7": "yellow_drum_remaining_pages",
+	}
 )
 
 // Color mappings for Brother printers
@@ -277,25 +357,50 @@ var (
 )
 
 func NewBrotherCollector(cfg *config.Config, metricsRegistry *metrics.BrotherRegistry, app *app.App) *BrotherCollector {
-	return &BrotherCollector{
+	bc := &BrotherCollector{
 		config:  cfg,
 		metrics: metricsRegistry,
 		app:     app,
 		done:    make(chan struct{}),
+
+		supplyStates: make(map[string]SupplyState),
+		customLabels: newCustomLabels(cfg.Printer.Labels),
+		schedule:     newSchedule(cfg),
+		lastSuccess:  make(map[string]time.Time),
+		store:        printer.NewStore(),
+		traps:        make(chan printerTrap, 16),
+		unfilled:     make(map[string]map[string]string),
 	}
+
+	bc.sources = bc.newSources()
+
+	return bc
+}
+
+// Store returns the store holding the latest state of the printer
+func (bc *BrotherCollector) Store() *printer.Store {
+	return bc.store
 }
 
 func (bc *BrotherCollector) Start(ctx context.Context) {
+	bc.startTraps(ctx)
+
 	go bc.run(ctx)
 }
 
-// run handles the main collection loop
+// run handles the main collection loop. Each subsystem runs on its own
+// interval; the ticker fires at the shortest one and every subsystem due at
+// a tick shares a single SNMP session. A trap updates the status at once
+// and triggers a collection of the status subsystems after the collect
+// delay, outside the schedule.
 func (bc *BrotherCollector) run(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(bc.config.GetDefaultInterval()) * time.Second)
+	ticker := time.NewTicker(bc.schedule.tick())
 	defer ticker.Stop()
 
 	// Initial collection
-	bc.collectMetrics(ctx)
+	bc.collectMetrics(ctx, bc.schedule.due(time.Now()))
+
+	var trapCollect <-chan time.Time
 
 	for {
 		select {
@@ -304,13 +409,28 @@ func (bc *BrotherCollector) run(ctx context.Context) {
 		case <-bc.done:
 			return
 		case <-ticker.C:
-			bc.collectMetrics(ctx)
+			if due := bc.schedule.due(time.Now()); len(due) > 0 {
+				bc.collectMetrics(ctx, due)
+			}
+		case trap := <-bc.traps:
+			bc.applyTrap(trap)
+
+			// Traps arriving before the collection share it
+			if trapCollect == nil {
+				trapCollect = time.After(bc.config.Traps.CollectDelay.Duration)
+			}
+		case <-trapCollect:
+			trapCollect = nil
+
+			if subsystems := bc.trapCollection(); len(subsystems) > 0 {
+				bc.collectMetrics(ctx, subsystems)
+			}
 		}
 	}
 }
 
-// collectMetrics performs a single metrics collection cycle
-func (bc *BrotherCollector) collectMetrics(ctx context.Context) {
+// collectMetrics performs a single metrics collection cycle for the given subsystems
+func (bc *BrotherCollector) collectMetrics(ctx context.Context, subsystems []string) {
 	startTime := time.Now()
 
 	// Create span for collection cycle
@@ -324,6 +444,7 @@ func (bc *BrotherCollector) collectMetrics(ctx context.Context) {
 		collectorSpan.SetAttributes(
 			attribute.String("printer.host", bc.config.Printer.Host),
 			attribute.String("printer.type", bc.config.Printer.Type),
+			attribute.StringSlice("collection.subsystems", subsystems),
 		)
 		defer collectorSpan.End()
 	}
@@ -336,86 +457,163 @@ func (bc *BrotherCollector) collectMetrics(ctx context.Context) {
 		spanCtx = ctx
 	}
 
-	if err := bc.connect(spanCtx); err != nil {
-		slog.Error("Failed to connect to Brother printer",
-			"host", bc.config.Printer.Host,
-			"error", err,
+	due := func(name string) bool {
+		return slices.Contains(subsystems, name)
+	}
+
+	// The scanner is polled over eSCL whichever source serves the printer
+	if due(config.SubsystemScanner) {
+		_ = bc.collectStep(config.SubsystemScanner, "scanner", func() error { return bc.collectScanner(spanCtx) })
+	}
+
+	if !bc.collectFromSources(spanCtx, collectorSpan, due) {
+		return
+	}
+
+	duration := time.Since(startTime).Seconds()
+
+	if collectorSpan != nil {
+		collectorSpan.SetAttributes(
+			attribute.Float64("collection.duration_seconds", duration),
+		)
+		collectorSpan.AddEvent("collection_completed",
+			attribute.String("printer.host", bc.config.Printer.Host),
+			attribute.Float64("duration_seconds", duration),
 		)
+	}
 
-		if collectorSpan != nil {
-			collectorSpan.RecordError(err, attribute.String("printer.host", bc.config.Printer.Host))
-		}
+	slog.Info("Collection cycle completed", "host", bc.config.Printer.Host, "subsystems", subsystems, "duration", duration)
+}
+
+// CollectOnce runs a single collection of every enabled subsystem but the
+// scanner, outside the run loop, and returns the resulting state. It is
+// for one-off commands that don't start the collector.
+func (bc *BrotherCollector) CollectOnce(ctx context.Context) printer.State {
+	due := func(name string) bool {
+		return name != config.SubsystemScanner && bc.config.SubsystemEnabled(name)
+	}
+
+	bc.collectFromSources(ctx, nil, due)
+
+	state, _ := bc.store.Get(bc.config.Printer.Host)
+
+	return state
+}
+
+// snmpSource reads the printer over SNMP v2c, or v3 during a probe, sharing
+// one session for every section of a cycle
+type snmpSource struct {
+	bc *BrotherCollector
+}
+
+func (s *snmpSource) Name() string { return config.SourceSNMP }
+
+// Fetch collects the requested sections. It fails when the printer could
+// not be connected to or didn't answer any request.
+func (s *snmpSource) Fetch(ctx context.Context, sections []string) (*printer.Snapshot, error) {
+	bc := s.bc
+
+	wants := func(section string) bool {
+		return slices.Contains(sections, section)
+	}
+
+	connectStart := time.Now()
+	err := bc.connect(ctx)
+
+	bc.metrics.CollectionDuration.With(bc.labels(prometheus.Labels{
+		"host": bc.config.Printer.Host,
+		"step": "connect",
+	})).Observe(time.Since(connectStart).Seconds())
 
-		bc.metrics.PrinterConnectionStatus.With(prometheus.Labels{
-			"host": bc.config.Printer.Host,
-		}).Set(0)
-		bc.metrics.PrinterConnectionErrors.With(prometheus.Labels{
+	if err != nil {
+		bc.metrics.PrinterConnectionErrors.With(bc.labels(prometheus.Labels{
 			"host":       bc.config.Printer.Host,
 			"error_type": "connect",
-		}).Inc()
+		})).Inc()
 
-		return
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
-	defer bc.disconnect(spanCtx)
+	defer bc.disconnect(ctx)
 
-	// Set connection status
-	bc.metrics.PrinterConnectionStatus.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(1)
+	bc.stateMu.Lock()
+	bc.cycleRequests, bc.cycleAnswered = 0, 0
+	bc.stateMu.Unlock()
 
-	// Reuse spanCtx from above for child operations
+	snap := &printer.Snapshot{}
 
-	// Collect printer information
-	bc.handleCollectionError(bc.collectPrinterInfo(spanCtx), "printer info")
+	if wants(printer.SectionIdentity) {
+		_ = bc.collectStep(config.SubsystemInfo, "printer info", func() error { return bc.collectPrinterInfo(ctx, snap) })
+	}
 
-	// Collect printer status
-	bc.handleCollectionError(bc.collectPrinterStatus(spanCtx), "printer status")
+	if wants(printer.SectionStatus) {
+		_ = bc.collectStep(config.SubsystemStatus, "printer status", func() error { return bc.collectPrinterStatus(ctx, snap) })
+	}
 
-	// Collect printer uptime
-	bc.handleCollectionError(bc.collectPrinterUptime(spanCtx), "printer uptime")
+	if wants(printer.SectionUptime) {
+		_ = bc.collectStep(config.SubsystemUptime, "printer uptime", func() error { return bc.collectPrinterUptime(ctx, snap) })
+	}
 
 	// Collect Brother-specific metrics (these work better than standard MIB)
-	if err := bc.collectBrotherSpecificMetrics(spanCtx); err != nil {
-		bc.handleCollectionError(err, "brother_metrics")
-
-		// Fallback to standard MIB only if Brother-specific collection fails
-		switch bc.config.Printer.Type {
-		case "laser":
-			bc.handleCollectionError(bc.collectLaserMetrics(spanCtx), "laser_metrics")
-		case "ink":
-			bc.handleCollectionError(bc.collectInkjetMetrics(spanCtx), "inkjet_metrics")
+	if wants(printer.SectionSupplies) {
+		if err := bc.collectStep(config.SubsystemBrother, "brother_metrics", func() error { return bc.collectBrotherSpecificMetrics(ctx, snap) }); err != nil {
+			bc.brotherUnsupported = true
+
+			// Fallback to standard MIB only if Brother-specific collection fails
+			switch bc.config.Printer.Type {
+			case "laser":
+				_ = bc.collectStep("laser_fallback", "laser_metrics", func() error { return bc.collectLaserMetrics(ctx, snap) })
+			case "ink":
+				_ = bc.collectStep("inkjet_fallback", "inkjet_metrics", func() error { return bc.collectInkjetMetrics(ctx, snap) })
+			}
+		} else {
+			bc.brotherUnsupported = false
 		}
-	} else {
-		// If Brother-specific collection succeeded, also collect nextcare data
-		bc.handleCollectionError(bc.collectBrotherNextCareData(spanCtx), "nextcare_metrics")
 	}
 
-	// Collect paper tray status
-	bc.handleCollectionError(bc.collectPaperTrayStatus(spanCtx), "paper_tray")
+	// Nextcare data only exists where the Brother-specific OIDs answered
+	if wants(printer.SectionMaintenance) && !bc.brotherUnsupported {
+		_ = bc.collectStep(config.SubsystemNextCare, "nextcare_metrics", func() error { return bc.collectBrotherNextCareData(ctx, snap) })
+	}
+
+	if wants(printer.SectionTrays) {
+		_ = bc.collectStep(config.SubsystemPaperTray, "paper_tray", func() error { return bc.collectPaperTrayStatus(ctx, snap) })
+	}
 
 	// Collect page counters using standard MIB OIDs
-	bc.handleCollectionError(bc.collectPageCounters(spanCtx), "page_counters")
+	if wants(printer.SectionCounters) {
+		_ = bc.collectStep(config.SubsystemPageCounters, "page_counters", func() error { return bc.collectPageCounters(ctx, snap) })
+	}
 
-	duration := time.Since(startTime).Seconds()
+	// SNMP over UDP "connects" without reaching the printer, so it only
+	// counts as reachable once at least one request got an answer
+	bc.stateMu.Lock()
+	unreachable := bc.cycleRequests > 0 && bc.cycleAnswered == 0
+	bc.stateMu.Unlock()
 
-	if collectorSpan != nil {
-		collectorSpan.SetAttributes(
-			attribute.Float64("collection.duration_seconds", duration),
-		)
-		collectorSpan.AddEvent("collection_completed",
-			attribute.String("printer.host", bc.config.Printer.Host),
-			attribute.Float64("duration_seconds", duration),
-		)
+	if unreachable {
+		bc.metrics.PrinterConnectionErrors.With(bc.labels(prometheus.Labels{
+			"host":       bc.config.Printer.Host,
+			"error_type": "unreachable",
+		})).Inc()
+
+		return nil, fmt.Errorf("printer did not answer any SNMP request")
 	}
 
-	slog.Info("Collection cycle completed", "host", bc.config.Printer.Host, "duration", duration)
+	return snap, nil
 }
 
 // connect establishes SNMP connection to the printer
 func (bc *BrotherCollector) connect(ctx context.Context) error {
 	tracer := bc.app.GetTracer()
 
+	v3 := bc.snmpV3
+
+	version := "v2c"
+	if v3.Username != "" {
+		version = "v3"
+	}
+
 	var span *tracing.CollectorSpan
 
 	if tracer != nil && tracer.IsEnabled() {
@@ -424,7 +622,7 @@ func (bc *BrotherCollector) connect(ctx context.Context) error {
 		span.SetAttributes(
 			attribute.String("snmp.host", bc.config.Printer.Host),
 			attribute.Int("snmp.port", 161),
-			attribute.String("snmp.version", "v2c"),
+			attribute.String("snmp.version", version),
 			attribute.Int("snmp.timeout_seconds", 10),
 			attribute.Int("snmp.retries", 3),
 		)
@@ -446,6 +644,12 @@ func (bc *BrotherCollector) connect(ctx context.Context) error {
 		Retries:   3,
 	}
 
+	if v3.Username != "" {
+		bc.client.Version = gosnmp.Version3
+		bc.client.SecurityModel = gosnmp.UserSecurityModel
+		bc.client.MsgFlags, bc.client.SecurityParameters = usmParameters(v3)
+	}
+
 	configDuration := time.Since(configStart)
 
 	if span != nil {
@@ -518,7 +722,7 @@ func (bc *BrotherCollector) disconnect(ctx context.Context) {
 }
 
 // collectPrinterInfo collects basic printer information using Brother-specific OIDs
-func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
+func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -527,7 +731,7 @@ func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
 		span = tracer.NewCollectorSpan(ctx, "brother-collector", "collect-printer-info")
 
 		span.SetAttributes(
-			attribute.Int("oids.count", 4),
+			attribute.Int("oids.count", 8),
 		)
 
 		defer span.End()
@@ -538,11 +742,15 @@ func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
 		OIDBrotherSerial,
 		OIDBrotherFirmware,
 		OIDBrotherMAC,
+		OIDSystemName,
+		OIDSystemLocation,
+		OIDSystemContact,
+		OIDSystemDescription,
 	}
 
 	getStart := time.Now()
 
-	result, err := bc.client.Get(oids)
+	result, err := bc.snmpGet(oids)
 
 	getDuration := time.Since(getStart)
 
@@ -563,7 +771,11 @@ func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
 		}
 	}
 
-	var model, serial, firmware, mac string
+	var (
+		model, serial, firmware, mac string
+		sysName, sysLocation         string
+		sysContact, sysDescr         string
+	)
 
 	parseStart := time.Now()
 
@@ -618,20 +830,29 @@ func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
 				mac = strings.TrimSpace(value)
 				slog.Debug("Using MAC as string", "mac", mac)
 			}
+		case OIDSystemName:
+			sysName = strings.TrimSpace(value)
+		case OIDSystemLocation:
+			sysLocation = strings.TrimSpace(value)
+		case OIDSystemContact:
+			sysContact = strings.TrimSpace(value)
+		case OIDSystemDescription:
+			sysDescr = strings.TrimSpace(value)
 		default:
 			slog.Debug("Unknown OID", "name", variable.Name, "value", value)
 		}
 	}
 
-	// Set printer info metric
-	bc.metrics.PrinterInfo.With(prometheus.Labels{
-		"host":     bc.config.Printer.Host,
-		"model":    model,
-		"serial":   serial,
-		"firmware": firmware,
-		"type":     bc.config.Printer.Type,
-		"mac":      mac,
-	}).Set(1)
+	snap.Identity = &printer.Identity{
+		Model:       model,
+		Serial:      serial,
+		Firmware:    firmware,
+		MAC:         mac,
+		Name:        sysName,
+		Location:    sysLocation,
+		Contact:     sysContact,
+		Description: sysDescr,
+	}
 
 	parseDuration := time.Since(parseStart)
 
@@ -658,7 +879,7 @@ func (bc *BrotherCollector) collectPrinterInfo(ctx context.Context) error {
 }
 
 // collectPrinterUptime collects printer uptime information
-func (bc *BrotherCollector) collectPrinterUptime(ctx context.Context) error {
+func (bc *BrotherCollector) collectPrinterUptime(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -675,7 +896,7 @@ func (bc *BrotherCollector) collectPrinterUptime(ctx context.Context) error {
 
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{OIDBrotherUptime})
+	result, err := bc.snmpGet([]string{OIDBrotherUptime})
 
 	getDuration := time.Since(getStart)
 
@@ -731,10 +952,7 @@ func (bc *BrotherCollector) collectPrinterUptime(ctx context.Context) error {
 
 	parseDuration := time.Since(parseStart)
 
-	// Set the uptime metric with the restart timestamp
-	bc.metrics.PrinterUptime.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(restartTimestamp)
+	snap.BootTime = time.Unix(int64(restartTimestamp), 0)
 
 	if span != nil {
 		span.SetAttributes(
@@ -755,7 +973,7 @@ func (bc *BrotherCollector) collectPrinterUptime(ctx context.Context) error {
 }
 
 // collectPrinterStatus collects printer status information
-func (bc *BrotherCollector) collectPrinterStatus(ctx context.Context) error {
+func (bc *BrotherCollector) collectPrinterStatus(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -772,7 +990,7 @@ func (bc *BrotherCollector) collectPrinterStatus(ctx context.Context) error {
 
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{OIDPrinterStatus})
+	result, err := bc.snmpGet([]string{OIDPrinterStatus, OIDPrinterDetectedErrorState})
 
 	getDuration := time.Since(getStart)
 
@@ -829,10 +1047,13 @@ func (bc *BrotherCollector) collectPrinterStatus(ctx context.Context) error {
 
 		parseDuration := time.Since(parseStart)
 
-		bc.metrics.PrinterStatus.With(prometheus.Labels{
-			"host":   bc.config.Printer.Host,
-			"status": statusStr,
-		}).Set(statusValue)
+		snap.Status = &printer.Status{State: statusStr}
+
+		if len(result.Variables) > 1 {
+			if bits, ok := result.Variables[1].Value.([]byte); ok {
+				snap.Alerts = snmpAlerts(bits)
+			}
+		}
 
 		if span != nil {
 			span.SetAttributes(
@@ -852,7 +1073,7 @@ func (bc *BrotherCollector) collectPrinterStatus(ctx context.Context) error {
 }
 
 // collectBrotherSpecificMetrics collects Brother-specific metrics using the proper OIDs and decoding
-func (bc *BrotherCollector) collectBrotherSpecificMetrics(ctx context.Context) error {
+func (bc *BrotherCollector) collectBrotherSpecificMetrics(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var (
@@ -876,7 +1097,7 @@ func (bc *BrotherCollector) collectBrotherSpecificMetrics(ctx context.Context) e
 	collectStart := time.Now()
 
 	// Get maintenance data (contains toner and drum levels)
-	if err := bc.collectBrotherMaintenanceData(spanCtx); err != nil {
+	if err := bc.collectBrotherMaintenanceData(spanCtx, snap); err != nil {
 		slog.Error("Failed to collect Brother maintenance data", "error", err)
 
 		if span != nil {
@@ -901,7 +1122,7 @@ func (bc *BrotherCollector) collectBrotherSpecificMetrics(ctx context.Context) e
 
 	getStart := time.Now()
 
-	result, err := bc.client.Get(oids)
+	result, err := bc.snmpGet(oids)
 
 	getDuration := time.Since(getStart)
 
@@ -966,7 +1187,7 @@ func (bc *BrotherCollector) collectBrotherSpecificMetrics(ctx context.Context) e
 }
 
 // collectBrotherMaintenanceData extracts toner and drum levels from Brother maintenance data
-func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) error {
+func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -984,7 +1205,7 @@ func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) e
 	collectStart := time.Now()
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{OIDBrotherMaintenanceData})
+	result, err := bc.snmpGet([]string{OIDBrotherMaintenanceData})
 
 	getDuration := time.Since(getStart)
 
@@ -1056,22 +1277,6 @@ func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) e
 	tonerLevels := make(map[string]int)
 	drumLevels := make(map[string]int)
 
-	// Brother laser maintenance mappings (from const.py)
-	laserMaintenanceMap := map[string]string{
-		"6f": "black_toner_remaining",
-		"70": "cyan_toner_remaining",
-		"71": "magenta_toner_remaining",
-		"72": "yellow_toner_remaining",
-		"79": "cyan_drum_remaining",
-		"7a": "magenta_drum_remaining",
-		"7b": "yellow_drum_remaining",
-		"80": "black_drum_remaining",
-		"69": "belt_unit_remaining",
-		"6a": "fuser_unit_remaining",
-		"6b": "laser_unit_remaining",
-		"6c": "paper_feeding_kit_remaining",
-	}
-
 	// Process each chunk
 	for _, chunk := range chunks {
 		if len(chunk) < 2 {
@@ -1081,8 +1286,20 @@ func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) e
 		// First 2 hex chars are the type code
 		typeCode := chunk[:2]
 
+		if supply, exists := brotherNearEndCodes[typeCode]; exists && len(chunk) >= 10 {
+			if value, err := strconv.ParseInt(chunk[len(chunk)-8:], 16, 64); err == nil {
+				if snap.NearEnd == nil {
+					snap.NearEnd = make(map[string]bool)
+				}
+
+				snap.NearEnd[supply] = value != 0
+			}
+
+			continue
+		}
+
 		// Check if this is a toner or drum level we care about
-		if sensorType, exists := laserMaintenanceMap[typeCode]; exists {
+		if sensorType, exists := brotherMaintenanceCodes[typeCode]; exists {
 			if len(chunk) >= 10 {
 				// Last 8 hex chars contain the value (as per Python library)
 				valueHex := chunk[len(chunk)-8:]
@@ -1114,21 +1331,13 @@ func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) e
 					case "yellow_drum_remaining":
 						drumLevels["yellow"] = percentage
 					case "belt_unit_remaining":
-						bc.metrics.BeltUnitRemainingPercent.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(percentage))
+						snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "belt_unit", Percent: float64(percentage)})
 					case "fuser_unit_remaining":
-						bc.metrics.FuserUnitRemainingPercent.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(percentage))
+						snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "fuser_unit", Percent: float64(percentage)})
 					case "laser_unit_remaining":
-						bc.metrics.LaserUnitRemainingPercent.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(percentage))
+						snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "laser_unit", Percent: float64(percentage)})
 					case "paper_feeding_kit_remaining":
-						bc.metrics.PaperFeedingKitRemainingPercent.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(percentage))
+						snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "paper_feeding_kit", Percent: float64(percentage)})
 					}
 
 					slog.Debug("Found sensor", "type", sensorType, "value_hex", valueHex, "value", value, "percentage", percentage)
@@ -1137,38 +1346,17 @@ func (bc *BrotherCollector) collectBrotherMaintenanceData(ctx context.Context) e
 		}
 	}
 
-	// Update toner level metrics
-	for color, level := range tonerLevels {
-		bc.metrics.TonerLevel.With(prometheus.Labels{
-			"host":  bc.config.Printer.Host,
-			"color": color,
-		}).Set(float64(level))
-
-		// Set toner status based on level
-		status, statusValue := calculateStatusFromLevel(float64(level))
-
-		bc.metrics.TonerStatus.With(prometheus.Labels{
-			"host":   bc.config.Printer.Host,
-			"color":  color,
-			"status": status,
-		}).Set(statusValue)
+	// Colours in the printer's order, maps iterate randomly
+	for _, color := range LaserColors {
+		if level, ok := tonerLevels[color]; ok {
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "toner", Color: color, Percent: float64(level)})
+		}
 	}
 
-	// Update drum level metrics
-	for color, level := range drumLevels {
-		bc.metrics.DrumLevel.With(prometheus.Labels{
-			"host":  bc.config.Printer.Host,
-			"color": color,
-		}).Set(float64(level))
-
-		// Set drum status based on level
-		status, statusValue := calculateStatusFromLevel(float64(level))
-
-		bc.metrics.DrumStatus.With(prometheus.Labels{
-			"host":   bc.config.Printer.Host,
-			"color":  color,
-			"status": status,
-		}).Set(statusValue)
+	for _, color := range LaserColors {
+		if level, ok := drumLevels[color]; ok {
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "drum", Color: color, Percent: float64(level)})
+		}
 	}
 
 	collectDuration := time.Since(collectStart)
@@ -1211,7 +1399,7 @@ func (bc *BrotherCollector) collectBrotherCountersData(ctx context.Context) erro
 	collectStart := time.Now()
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{OIDBrotherCountersData})
+	result, err := bc.snmpGet([]string{OIDBrotherCountersData})
 
 	getDuration := time.Since(getStart)
 
@@ -1294,7 +1482,7 @@ func (bc *BrotherCollector) collectBrotherCountersData(ctx context.Context) erro
 }
 
 // collectBrotherNextCareData extracts remaining pages from Brother nextcare data
-func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) error {
+func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -1312,7 +1500,7 @@ func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) erro
 	collectStart := time.Now()
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{OIDBrotherNextCareData})
+	result, err := bc.snmpGet([]string{OIDBrotherNextCareData})
 
 	getDuration := time.Since(getStart)
 
@@ -1380,20 +1568,6 @@ func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) erro
 
 	slog.Debug("Brother nextcare chunks", "chunks", chunks)
 
-	// Brother nextcare mappings (from const.py)
-	nextcareMap := map[string]string{
-		"73": "laser_unit_remaining_pages",
-		"77": "paper_feeding_kit_1_remaining_pages",
-		"82": "drum_remaining_pages",
-		"86": "paper_feeding_kit_mp_remaining_pages",
-		"88": "belt_unit_remaining_pages",
-		"89": "fuser_unit_remaining_pages",
-		"a4": "black_drum_remaining_pages",
-		"a5": "cyan_drum_remaining_pages",
-		"a6": "magenta_drum_remaining_pages",
-		"a7": "yellow_drum_remaining_pages",
-	}
-
 	// Process each chunk
 	for _, chunk := range chunks {
 		if len(chunk) < 2 {
@@ -1404,7 +1578,7 @@ func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) erro
 		typeCode := chunk[:2]
 
 		// Check if this is a nextcare metric we care about
-		if sensorType, exists := nextcareMap[typeCode]; exists {
+		if sensorType, exists := brotherNextCareCodes[typeCode]; exists {
 			if len(chunk) >= 10 {
 				// Last 8 hex chars contain the value (as per Python library)
 				valueHex := chunk[len(chunk)-8:]
@@ -1419,21 +1593,13 @@ func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) erro
 				if value >= 0 && value < 10000000 {
 					switch sensorType {
 					case "belt_unit_remaining_pages":
-						bc.metrics.BeltUnitRemainingPages.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(value))
+						snap.Maintenance = append(snap.Maintenance, printer.Part{Kind: "belt_unit", RemainingPages: float64(value)})
 					case "fuser_unit_remaining_pages":
-						bc.metrics.FuserUnitRemainingPages.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(value))
+						snap.Maintenance = append(snap.Maintenance, printer.Part{Kind: "fuser_unit", RemainingPages: float64(value)})
 					case "laser_unit_remaining_pages":
-						bc.metrics.LaserUnitRemainingPages.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(value))
+						snap.Maintenance = append(snap.Maintenance, printer.Part{Kind: "laser_unit", RemainingPages: float64(value)})
 					case "paper_feeding_kit_mp_remaining_pages":
-						bc.metrics.PaperFeedingKitRemainingPages.With(prometheus.Labels{
-							"host": bc.config.Printer.Host,
-						}).Set(float64(value))
+						snap.Maintenance = append(snap.Maintenance, printer.Part{Kind: "paper_feeding_kit", RemainingPages: float64(value)})
 					}
 
 					slog.Debug("Found nextcare sensor", "type", sensorType, "value_hex", valueHex, "value", value)
@@ -1457,7 +1623,7 @@ func (bc *BrotherCollector) collectBrotherNextCareData(ctx context.Context) erro
 }
 
 // collectLaserMetrics collects metrics specific to laser printers
-func (bc *BrotherCollector) collectLaserMetrics(ctx context.Context) error {
+func (bc *BrotherCollector) collectLaserMetrics(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var (
@@ -1482,10 +1648,10 @@ func (bc *BrotherCollector) collectLaserMetrics(ctx context.Context) error {
 	collectStart := time.Now()
 
 	// Collect toner levels and status
-	bc.collectColorLevelsWithStatus(spanCtx, OIDTonerLevelBase, LaserColors, bc.metrics.TonerLevel, bc.metrics.TonerStatus, "toner level")
+	bc.collectColorLevelsWithStatus(spanCtx, snap, OIDTonerLevelBase, "toner", LaserColors, "toner level")
 
 	// Collect drum levels and status
-	bc.collectColorLevelsWithStatus(spanCtx, OIDDrumLevelBase, LaserColors, bc.metrics.DrumLevel, bc.metrics.DrumStatus, "drum level")
+	bc.collectColorLevelsWithStatus(spanCtx, snap, OIDDrumLevelBase, "drum", LaserColors, "drum level")
 
 	collectDuration := time.Since(collectStart)
 
@@ -1500,7 +1666,7 @@ func (bc *BrotherCollector) collectLaserMetrics(ctx context.Context) error {
 }
 
 // collectInkjetMetrics collects metrics specific to inkjet printers
-func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context) error {
+func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -1535,7 +1701,7 @@ func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context) error {
 
 		getStart := time.Now()
 
-		result, err := bc.client.Get([]string{oid})
+		result, err := bc.snmpGet([]string{oid})
 
 		getDuration := time.Since(getStart)
 
@@ -1569,19 +1735,7 @@ func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context) error {
 
 			parseDuration := time.Since(parseStart)
 
-			bc.metrics.InkLevel.With(prometheus.Labels{
-				"host":  bc.config.Printer.Host,
-				"color": color,
-			}).Set(percentage)
-
-			// Set status based on level
-			status, statusValue := calculateStatusFromLevel(percentage)
-
-			bc.metrics.InkStatus.With(prometheus.Labels{
-				"host":   bc.config.Printer.Host,
-				"color":  color,
-				"status": status,
-			}).Set(statusValue)
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "ink", Color: color, Percent: percentage})
 
 			colorsCollected++
 
@@ -1590,7 +1744,6 @@ func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context) error {
 					attribute.Float64("ink."+color+".get_duration_seconds", getDuration.Seconds()),
 					attribute.Float64("ink."+color+".parse_duration_seconds", parseDuration.Seconds()),
 					attribute.Float64("ink."+color+".percentage", percentage),
-					attribute.String("ink."+color+".status", status),
 				)
 			}
 		}
@@ -1612,7 +1765,7 @@ func (bc *BrotherCollector) collectInkjetMetrics(ctx context.Context) error {
 }
 
 // collectPaperTrayStatus collects paper tray status
-func (bc *BrotherCollector) collectPaperTrayStatus(ctx context.Context) error {
+func (bc *BrotherCollector) collectPaperTrayStatus(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -1636,7 +1789,7 @@ func (bc *BrotherCollector) collectPaperTrayStatus(ctx context.Context) error {
 
 	getStart := time.Now()
 
-	result, err := bc.client.Get([]string{oid})
+	result, err := bc.snmpGet([]string{oid})
 
 	getDuration := time.Since(getStart)
 
@@ -1694,11 +1847,7 @@ func (bc *BrotherCollector) collectPaperTrayStatus(ctx context.Context) error {
 
 		parseDuration := time.Since(parseStart)
 
-		bc.metrics.PaperTrayStatus.With(prometheus.Labels{
-			"host":   bc.config.Printer.Host,
-			"tray":   "main",
-			"status": statusStr,
-		}).Set(statusValue)
+		snap.Trays = append(snap.Trays, printer.Tray{Name: "main", Status: statusStr})
 
 		if span != nil {
 			span.SetAttributes(
@@ -1726,7 +1875,7 @@ func (bc *BrotherCollector) collectPaperTrayStatus(ctx context.Context) error {
 }
 
 // collectPageCounters collects page count metrics using Brother-specific counters data
-func (bc *BrotherCollector) collectPageCounters(ctx context.Context) error {
+func (bc *BrotherCollector) collectPageCounters(ctx context.Context, snap *printer.Snapshot) error {
 	tracer := bc.app.GetTracer()
 
 	var span *tracing.CollectorSpan
@@ -1745,7 +1894,7 @@ func (bc *BrotherCollector) collectPageCounters(ctx context.Context) error {
 	getStart := time.Now()
 
 	// Get the Brother counters data which contains multiple counter types
-	result, err := bc.client.Get([]string{OIDBrotherCountersData})
+	result, err := bc.snmpGet([]string{OIDBrotherCountersData})
 
 	getDuration := time.Since(getStart)
 
@@ -1804,30 +1953,14 @@ func (bc *BrotherCollector) collectPageCounters(ctx context.Context) error {
 	// Update metrics with the parsed counter values
 	updateStart := time.Now()
 
-	bc.metrics.PageCountTotal.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["0001"])) // Total page count
-	bc.metrics.PageCountBlack.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["0101"])) // B/W count
-	bc.metrics.PageCountColor.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["0201"])) // Color count
-	bc.metrics.PageCountDuplex.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["0601"])) // Duplex count
-	bc.metrics.PageCountDrumBlack.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["1201"])) // Black drum count
-	bc.metrics.PageCountDrumCyan.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["1301"])) // Cyan drum count
-	bc.metrics.PageCountDrumMagenta.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["1401"])) // Magenta drum count
-	bc.metrics.PageCountDrumYellow.With(prometheus.Labels{
-		"host": bc.config.Printer.Host,
-	}).Set(float64(counters["1501"])) // Yellow drum count
+	snap.SetCounter(printer.CounterTotal, float64(counters["0001"]))
+	snap.SetCounter(printer.CounterBlack, float64(counters["0101"]))
+	snap.SetCounter(printer.CounterColor, float64(counters["0201"]))
+	snap.SetCounter(printer.CounterDuplex, float64(counters["0601"]))
+	snap.SetCounter(printer.CounterDrumBlack, float64(counters["1201"]))
+	snap.SetCounter(printer.CounterDrumCyan, float64(counters["1301"]))
+	snap.SetCounter(printer.CounterDrumMagenta, float64(counters["1401"]))
+	snap.SetCounter(printer.CounterDrumYellow, float64(counters["1501"]))
 
 	updateDuration := time.Since(updateStart)
 	collectDuration := time.Since(collectStart)
@@ -1906,6 +2039,7 @@ func (bc *BrotherCollector) parseBrotherCounters(hexData []byte) map[string]int
 
 // Stop stops the collector
 func (bc *BrotherCollector) Stop() {
+	bc.stopTraps()
 	close(bc.done)
 	bc.disconnect(context.Background())
 }
@@ -0,0 +1,399 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/ipp"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// ippAttributes are the printer attributes requested from an IPP source
+var ippAttributes = []string{
+	"printer-make-and-model",
+	"printer-name",
+	"printer-location",
+	"printer-info",
+	"printer-device-id",
+	"printer-firmware-string-version",
+	"printer-serial-number",
+	"printer-state",
+	"printer-state-reasons",
+	"printer-state-message",
+	"printer-up-time",
+	"marker-names",
+	"marker-types",
+	"marker-colors",
+	"marker-levels",
+	"printer-input-tray",
+	"printer-supply",
+	"printer-impressions-completed",
+	"printer-impressions-completed-col",
+}
+
+// ippColors maps marker-colors values to colour names
+var ippColors = map[string]string{
+	"#000000": "black",
+	"#00ffff": "cyan",
+	"#ff00ff": "magenta",
+	"#ffff00": "yellow",
+}
+
+// ippSupply is a consumable level read from marker-* or printer-supply
+type ippSupply struct {
+	supplyType string
+	color      string
+	level      int
+}
+
+// ippSource reads the printer with a single IPP Get-Printer-Attributes
+// request. Maintenance part page counts aren't available over IPP.
+type ippSource struct {
+	bc *BrotherCollector
+}
+
+func (s *ippSource) Name() string { return config.SourceIPP }
+
+// getIPPClient returns the IPP client, creating it on first use
+func (bc *BrotherCollector) getIPPClient() *ipp.Client {
+	bc.clientsMu.Lock()
+	defer bc.clientsMu.Unlock()
+
+	if bc.ippClient == nil {
+		bc.ippClient = ipp.NewClient(bc.config.Printer.IPPURL(), bc.config.Printer.IPP.Timeout.Duration, bc.config.Printer.IPP.InsecureSkipVerify)
+	}
+
+	return bc.ippClient
+}
+
+func (s *ippSource) Fetch(ctx context.Context, sections []string) (*printer.Snapshot, error) {
+	bc := s.bc
+	client := bc.getIPPClient()
+
+	var attrs *ipp.Group
+
+	err := bc.collectStep("ipp_request", "ipp", func() error {
+		var err error
+
+		attrs, err = client.GetPrinterAttributes(ctx, ippAttributes)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &printer.Snapshot{}
+
+	bc.fillSections(sections, map[string]func() error{
+		printer.SectionIdentity: func() error { return applyIPPInfo(attrs, snap) },
+		printer.SectionStatus:   func() error { return bc.applyIPPStatus(attrs, snap) },
+		printer.SectionUptime:   func() error { return applyIPPUptime(attrs, snap) },
+		printer.SectionSupplies: func() error { return applyIPPSupplies(attrs, snap) },
+		printer.SectionTrays:    func() error { return applyIPPTrays(attrs, snap) },
+		printer.SectionCounters: func() error { return applyIPPCounters(attrs, snap) },
+	})
+
+	return snap, nil
+}
+
+// applyIPPInfo reads the identity attributes
+func applyIPPInfo(attrs *ipp.Group, snap *printer.Snapshot) error {
+	model := firstString(attrs, "printer-make-and-model")
+	if model == "" {
+		return fmt.Errorf("no printer-make-and-model attribute")
+	}
+
+	// The device ID carries the same MDL: field the SNMP model OID does
+	if deviceID := firstString(attrs, "printer-device-id"); strings.Contains(deviceID, "MDL:") {
+		model = deviceIDField(deviceID, "MDL")
+	}
+
+	model = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(model), "Brother "), " series")
+	serial := firstString(attrs, "printer-serial-number")
+	firmware := firstString(attrs, "printer-firmware-string-version")
+
+	snap.Identity = &printer.Identity{
+		Model:       model,
+		Serial:      serial,
+		Firmware:    firmware,
+		Name:        firstString(attrs, "printer-name"),
+		Location:    firstString(attrs, "printer-location"),
+		Description: firstString(attrs, "printer-info"),
+	}
+
+	return nil
+}
+
+// applyIPPStatus maps printer-state to the printer status
+func (bc *BrotherCollector) applyIPPStatus(attrs *ipp.Group, snap *printer.Snapshot) error {
+	states := attrs.Ints("printer-state")
+	if len(states) == 0 {
+		return fmt.Errorf("no printer-state attribute")
+	}
+
+	var statusStr string
+
+	switch states[0] {
+	case 3: // idle
+		statusStr = "ready"
+	case 4: // processing
+		statusStr = "printing"
+	case 5: // stopped
+		statusStr = "stopped"
+	default:
+		statusStr = "unknown"
+	}
+
+	reasons := attrs.Strings("printer-state-reasons")
+
+	snap.Status = &printer.Status{State: statusStr, Message: firstString(attrs, "printer-state-message")}
+	snap.Alerts = ippAlerts(reasons)
+
+	if len(reasons) > 0 && reasons[0] != "none" {
+		slog.Debug("IPP printer state reasons", "host", bc.config.Printer.Host, "reasons", reasons)
+	}
+
+	return nil
+}
+
+// applyIPPUptime maps printer-up-time (seconds since boot) to the boot time
+func applyIPPUptime(attrs *ipp.Group, snap *printer.Snapshot) error {
+	upTime := attrs.Ints("printer-up-time")
+	if len(upTime) == 0 {
+		return fmt.Errorf("no printer-up-time attribute")
+	}
+
+	snap.BootTime = time.Unix(time.Now().Unix()-int64(upTime[0]), 0)
+
+	return nil
+}
+
+// applyIPPSupplies reads the toner, ink, drum and maintenance part levels
+func applyIPPSupplies(attrs *ipp.Group, snap *printer.Snapshot) error {
+	supplies := ippMarkerSupplies(attrs)
+	if len(supplies) == 0 {
+		supplies = ippPrinterSupplies(attrs)
+	}
+
+	if len(supplies) == 0 {
+		return fmt.Errorf("no marker or printer-supply attributes")
+	}
+
+	for _, supply := range supplies {
+		// Negative levels mean unavailable, unknown or "some remaining"
+		if supply.level < 0 {
+			continue
+		}
+
+		level := float64(min(supply.level, 100))
+
+		switch supply.supplyType {
+		case "toner", "tonercartridge":
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "toner", Color: supply.color, Percent: level})
+		case "ink", "inkcartridge":
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "ink", Color: supply.color, Percent: level})
+		case "opc", "drum":
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "drum", Color: supply.color, Percent: level})
+		case "transferunit", "transferbelt":
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "belt_unit", Percent: level})
+		case "fuser", "fuserkit":
+			snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "fuser_unit", Percent: level})
+		default:
+			slog.Debug("Ignoring IPP supply", "type", supply.supplyType, "color", supply.color, "level", supply.level)
+		}
+	}
+
+	return nil
+}
+
+// applyIPPTrays maps printer-input-tray to the paper tray statuses
+func applyIPPTrays(attrs *ipp.Group, snap *printer.Snapshot) error {
+	trays := attrs.Strings("printer-input-tray")
+	if len(trays) == 0 {
+		return fmt.Errorf("no printer-input-tray attribute")
+	}
+
+	for i, raw := range trays {
+		tray := ipp.ParseKeyValues(raw)
+
+		name := tray["name"]
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+
+		// level is -2 (unknown), -3 (some paper) or the sheet count
+		level, err := strconv.Atoi(tray["level"])
+		if err != nil {
+			level = -2
+		}
+
+		status := "unknown"
+
+		switch {
+		case level == 0:
+			status = "empty"
+		case level == -3 || level > 0:
+			status = "ok"
+		}
+
+		snap.Trays = append(snap.Trays, printer.Tray{Name: name, Status: status})
+	}
+
+	return nil
+}
+
+// applyIPPCounters maps printer-impressions-completed to the page counters
+func applyIPPCounters(attrs *ipp.Group, snap *printer.Snapshot) error {
+	total := attrs.Ints("printer-impressions-completed")
+	if len(total) == 0 {
+		return fmt.Errorf("no printer-impressions-completed attribute")
+	}
+
+	snap.SetCounter(printer.CounterTotal, float64(total[0]))
+
+	// The collection form splits impressions into monochrome and full-color
+	for _, value := range attrs.Get("printer-impressions-completed-col") {
+		members, ok := value.Value.([]ipp.Attribute)
+		if !ok {
+			continue
+		}
+
+		for _, member := range members {
+			if len(member.Values) == 0 {
+				continue
+			}
+
+			count, ok := member.Values[0].Int()
+			if !ok {
+				continue
+			}
+
+			switch member.Name {
+			case "monochrome":
+				snap.SetCounter(printer.CounterBlack, float64(count))
+			case "full-color":
+				snap.SetCounter(printer.CounterColor, float64(count))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ippMarkerSupplies reads the parallel marker-* attributes
+func ippMarkerSupplies(attrs *ipp.Group) []ippSupply {
+	names := attrs.Strings("marker-names")
+	types := attrs.Strings("marker-types")
+	colors := attrs.Strings("marker-colors")
+	levels := attrs.Ints("marker-levels")
+
+	supplies := make([]ippSupply, 0, len(levels))
+
+	for i, level := range levels {
+		supply := ippSupply{level: level}
+
+		if i < len(types) {
+			supply.supplyType = normaliseSupplyType(types[i])
+		}
+
+		var name, hexColor string
+		if i < len(names) {
+			name = names[i]
+		}
+
+		if i < len(colors) {
+			hexColor = colors[i]
+		}
+
+		supply.color = supplyColor(name, hexColor)
+
+		// Brother names its drum "Drum Unit" but may type it generically
+		if supply.supplyType == "" || supply.supplyType == "other" {
+			if strings.Contains(strings.ToLower(name), "drum") {
+				supply.supplyType = "drum"
+			}
+		}
+
+		supplies = append(supplies, supply)
+	}
+
+	return supplies
+}
+
+// ippPrinterSupplies reads the PWG printer-supply attribute
+func ippPrinterSupplies(attrs *ipp.Group) []ippSupply {
+	raw := attrs.Strings("printer-supply")
+	supplies := make([]ippSupply, 0, len(raw))
+
+	for _, entry := range raw {
+		fields := ipp.ParseKeyValues(entry)
+
+		level, err := strconv.Atoi(fields["level"])
+		if err != nil {
+			continue
+		}
+
+		// Convert to a percentage unless the level already is one
+		if maxCapacity, err := strconv.Atoi(fields["maxcapacity"]); err == nil && maxCapacity > 0 && fields["unit"] != "percent" && level >= 0 {
+			level = level * 100 / maxCapacity
+		}
+
+		supplies = append(supplies, ippSupply{
+			supplyType: normaliseSupplyType(fields["type"]),
+			color:      supplyColor(fields["colorantname"], ""),
+			level:      level,
+		})
+	}
+
+	return supplies
+}
+
+// normaliseSupplyType folds marker-types ("toner-cartridge") and
+// printer-supply ("tonerCartridge") spellings together
+func normaliseSupplyType(supplyType string) string {
+	return strings.ToLower(strings.ReplaceAll(supplyType, "-", ""))
+}
+
+// supplyColor derives a colour name from a supply name or marker colour
+func supplyColor(name, hexColor string) string {
+	lower := strings.ToLower(name)
+
+	for _, color := range LaserColors {
+		if strings.Contains(lower, color) {
+			return color
+		}
+	}
+
+	if color, ok := ippColors[strings.ToLower(hexColor)]; ok {
+		return color
+	}
+
+	// Single-colour parts such as the drum or belt
+	return "black"
+}
+
+// firstString returns the first value of an attribute, or ""
+func firstString(attrs *ipp.Group, name string) string {
+	if values := attrs.Strings(name); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// deviceIDField extracts a field from an IEEE 1284 device ID string
+func deviceIDField(deviceID, key string) string {
+	for _, field := range strings.Split(deviceID, ";") {
+		if name, value, ok := strings.Cut(field, ":"); ok && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
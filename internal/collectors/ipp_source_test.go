@@ -0,0 +1,178 @@
+package collectors
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/ipp"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadIPPFixture reads a Get-Printer-Attributes response in IPP wire format
+// from testdata/ipp
+func loadIPPFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/ipp/" + name)
+	require.NoError(t, err)
+
+	return data
+}
+
+// newIPPResponder answers Get-Printer-Attributes requests with response,
+// byte for byte apart from the request id
+func newIPPResponder(t *testing.T, response []byte) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		request, err := ipp.Decode(body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "application/ipp", r.Header.Get("Content-Type"))
+		assert.Equal(t, ipp.OperationGetPrinterAttributes, request.Code)
+
+		data := slices.Clone(response)
+		binary.BigEndian.PutUint32(data[4:8], request.RequestID)
+
+		w.Header().Set("Content-Type", "application/ipp")
+		_, _ = w.Write(data)
+	}))
+}
+
+func newIPPCollector(t *testing.T, url string) (*BrotherCollector, *metrics.BrotherRegistry) {
+	t.Helper()
+
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host", Type: "laser", Source: config.SourceIPP}}
+	brotherMetrics := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, brotherMetrics, nil)
+	bc.ippClient = ipp.NewClient(url, 5*time.Second, false)
+
+	return bc, brotherMetrics
+}
+
+func TestCollectFromIPP(t *testing.T) {
+	server := newIPPResponder(t, loadIPPFixture(t, "hl-l3270cdw.bin"))
+	defer server.Close()
+
+	bc, m := newIPPCollector(t, server.URL+"/ipp/print")
+
+	ok := bc.collectFromSources(t.Context(), nil, func(string) bool { return true })
+	assert.True(t, ok)
+
+	host := prometheus.Labels{"host": "test-host"}
+
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PrinterConnectionStatus.With(host)), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PrinterInfo.With(prometheus.Labels{
+		"host": "test-host", "model": "HL-L3270CDW", "serial": "E78096A9N123456",
+		"firmware": "ZC", "type": "laser", "mac": "",
+	})), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PrinterStatus.With(prometheus.Labels{"host": "test-host", "status": "ready"})), 0)
+
+	assert.InDelta(t, 60, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "black"})), 0)
+	assert.InDelta(t, 80, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "cyan"})), 0)
+	assert.InDelta(t, 70, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "magenta"})), 0)
+	// Yellow reports -3 ("some remaining"), which has no percentage
+	assert.Equal(t, 3, testutil.CollectAndCount(m.TonerLevel))
+
+	assert.InDelta(t, 9, testutil.ToFloat64(m.DrumLevel.With(prometheus.Labels{"host": "test-host", "color": "black"})), 0)
+	assert.InDelta(t, SupplyStateWarning.Level(), testutil.ToFloat64(m.SupplyState.With(prometheus.Labels{
+		"host": "test-host", "supply": "drum", "color": "black",
+	})), 0)
+	assert.InDelta(t, 90, testutil.ToFloat64(m.BeltUnitRemainingPercent.With(host)), 0)
+
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PaperTrayStatus.With(prometheus.Labels{"host": "test-host", "tray": "Tray1", "status": "ok"})), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(m.PaperTrayStatus.With(prometheus.Labels{"host": "test-host", "tray": "MP Tray", "status": "empty"})), 0)
+
+	assert.InDelta(t, 5432, testutil.ToFloat64(m.PageCountTotal.With(host)), 0)
+	assert.InDelta(t, 4000, testutil.ToFloat64(m.PageCountBlack.With(host)), 0)
+	assert.InDelta(t, 1432, testutil.ToFloat64(m.PageCountColor.With(host)), 0)
+
+	uptime := testutil.ToFloat64(m.PrinterUptime.With(host))
+	assert.InDelta(t, float64(time.Now().Unix()-86400), uptime, 5)
+}
+
+func TestCollectFromIPP_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	bc, m := newIPPCollector(t, server.URL+"/ipp/print")
+
+	ok := bc.collectFromSources(t.Context(), nil, func(string) bool { return true })
+	assert.False(t, ok)
+	assert.InDelta(t, 0, testutil.ToFloat64(m.PrinterConnectionStatus.With(prometheus.Labels{"host": "test-host"})), 0)
+}
+
+func TestIPPPrinterSupplies(t *testing.T) {
+	group := &ipp.Group{Attributes: []ipp.Attribute{{
+		Name: "printer-supply",
+		Values: []ipp.Value{
+			{Tag: ipp.TagOctetString, Value: "type=tonerCartridge;maxcapacity=100;level=40;class=supplyThatIsConsumed;unit=percent;colorantname=cyan;"},
+			{Tag: ipp.TagOctetString, Value: "type=opc;maxcapacity=200;level=50;class=receptacleThatIsFilled;unit=impressions;"},
+		},
+	}}}
+
+	supplies := ippPrinterSupplies(group)
+
+	assert.Equal(t, []ippSupply{
+		{supplyType: "tonercartridge", color: "cyan", level: 40},
+		{supplyType: "opc", color: "black", level: 25},
+	}, supplies)
+}
+
+func TestCollectFromIPP_Store(t *testing.T) {
+	server := newIPPResponder(t, loadIPPFixture(t, "hl-l3270cdw.bin"))
+	defer server.Close()
+
+	bc, _ := newIPPCollector(t, server.URL+"/ipp/print")
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(name string) bool { return name != config.SubsystemPageCounters }))
+
+	state, ok := bc.Store().Get("test-host")
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.True(t, state.Reachable)
+	assert.Equal(t, "HL-L3270CDW", state.Snapshot.Identity.Model)
+	assert.Equal(t, "ready", state.Snapshot.Status.State)
+	assert.Empty(t, state.Snapshot.Alerts)
+	assert.Contains(t, state.Snapshot.Updated, printer.SectionSupplies)
+
+	// Rendering sets each supply's threshold state
+	for _, supply := range state.Snapshot.Supplies {
+		assert.NotEmpty(t, supply.State, supply.Kind+" "+supply.Color)
+	}
+
+	// Page counters weren't due and IPP has no maintenance part data
+	assert.Empty(t, state.Snapshot.Counters)
+	assert.Equal(t, []string{"no source provided maintenance"}, state.Errors)
+
+	// A later cycle with only the counters due keeps the other sections
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(name string) bool { return name == config.SubsystemPageCounters }))
+
+	state, _ = bc.Store().Get("test-host")
+	assert.InDelta(t, 5432, state.Snapshot.Counters[printer.CounterTotal], 0)
+	assert.Equal(t, "HL-L3270CDW", state.Snapshot.Identity.Model)
+	assert.Empty(t, state.Errors)
+}
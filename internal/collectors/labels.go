@@ -0,0 +1,136 @@
+package collectors
+
+import (
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LabelData is the collected printer data available to templated labels,
+// e.g. "{{.SysLocation}}" or "{{.Serial}}"
+type LabelData struct {
+	Host        string
+	Type        string
+	Model       string
+	Serial      string
+	Firmware    string
+	MAC         string
+	SysName     string
+	SysLocation string
+	SysContact  string
+	SysDescr    string
+}
+
+// customLabels resolves the configured per-printer labels. Values containing
+// "{{" are parsed as text/template and rendered against LabelData; all other
+// values are static.
+type customLabels struct {
+	static    map[string]string
+	templates map[string]*template.Template
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// newCustomLabels parses the configured labels. Invalid templates are logged
+// and treated as static values.
+func newCustomLabels(labels map[string]string) *customLabels {
+	cl := &customLabels{
+		static:    make(map[string]string),
+		templates: make(map[string]*template.Template),
+		values:    make(map[string]string, len(labels)),
+	}
+
+	for name, value := range labels {
+		if strings.Contains(value, "{{") {
+			tmpl, err := template.New(name).Option("missingkey=zero").Parse(value)
+			if err == nil {
+				cl.templates[name] = tmpl
+				cl.values[name] = ""
+
+				continue
+			}
+
+			slog.Warn("Invalid label template, using it as a static value", "label", name, "error", err)
+		}
+
+		cl.static[name] = value
+		cl.values[name] = value
+	}
+
+	return cl
+}
+
+// update renders the templated labels against data and reports whether any
+// resolved value changed
+func (cl *customLabels) update(data LabelData) bool {
+	if len(cl.templates) == 0 {
+		return false
+	}
+
+	values := make(map[string]string, len(cl.static)+len(cl.templates))
+	maps.Copy(values, cl.static)
+
+	for name, tmpl := range cl.templates {
+		var builder strings.Builder
+		if err := tmpl.Execute(&builder, data); err != nil {
+			slog.Debug("Failed to render label template", "label", name, "error", err)
+		}
+
+		values[name] = strings.TrimSpace(builder.String())
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if maps.Equal(values, cl.values) {
+		return false
+	}
+
+	cl.values = values
+
+	return true
+}
+
+// apply adds the resolved custom labels to labels
+func (cl *customLabels) apply(labels prometheus.Labels) prometheus.Labels {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+
+	for name, value := range cl.values {
+		labels[name] = value
+	}
+
+	return labels
+}
+
+// labels adds the configured custom labels to a metric's own labels. Every
+// metric in BrotherRegistry carries the custom labels, so every With/Delete
+// call must go through here.
+func (bc *BrotherCollector) labels(labels prometheus.Labels) prometheus.Labels {
+	return bc.customLabels.apply(labels)
+}
+
+// updateLabelData re-renders templated labels from freshly collected data.
+// When a value changes, the printer data series are dropped so stale label
+// values don't linger, and set again at once from the stored state, so
+// sections not due this cycle don't go missing until their next run.
+// Counters keep their series under the previous values.
+func (bc *BrotherCollector) updateLabelData(data LabelData) {
+	if !bc.customLabels.update(data) {
+		return
+	}
+
+	slog.Info("Templated printer labels changed, resetting metrics", "host", bc.config.Printer.Host)
+	bc.metrics.ResetPrinterData()
+
+	if state, ok := bc.store.Get(bc.config.Printer.Host); ok {
+		bc.renderSnapshot(state.Snapshot)
+	}
+
+	bc.renderScanner()
+}
@@ -0,0 +1,90 @@
+package collectors
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomLabels_StaticAndTemplated(t *testing.T) {
+	cl := newCustomLabels(map[string]string{
+		"site":     "dublin",
+		"location": "{{.SysLocation}}",
+		"asset":    "{{.Model}}-{{.Serial}}",
+	})
+
+	labels := cl.apply(prometheus.Labels{"host": "10.0.0.5"})
+	assert.Equal(t, "dublin", labels["site"])
+	assert.Empty(t, labels["location"])
+
+	assert.True(t, cl.update(LabelData{SysLocation: "Floor 3", Model: "HL-L8360CDW", Serial: "E123"}))
+	assert.False(t, cl.update(LabelData{SysLocation: "Floor 3", Model: "HL-L8360CDW", Serial: "E123"}))
+
+	labels = cl.apply(prometheus.Labels{"host": "10.0.0.5"})
+	assert.Equal(t, "10.0.0.5", labels["host"])
+	assert.Equal(t, "dublin", labels["site"])
+	assert.Equal(t, "Floor 3", labels["location"])
+	assert.Equal(t, "HL-L8360CDW-E123", labels["asset"])
+}
+
+func TestCustomLabels_AppliedToRegistry(t *testing.T) {
+	baseRegistry := promexporter_metrics.NewRegistry("brother_exporter_info_test")
+	brotherMetrics := metrics.NewBrotherRegistry(baseRegistry, "location", "site")
+
+	cl := newCustomLabels(map[string]string{"site": "dublin", "location": "{{.SysLocation}}"})
+	cl.update(LabelData{SysLocation: "Floor 3"})
+
+	assert.NotPanics(t, func() {
+		brotherMetrics.TonerLevel.With(cl.apply(prometheus.Labels{"host": "h", "color": "black"})).Set(50)
+		brotherMetrics.PrinterInfo.With(cl.apply(prometheus.Labels{
+			"host": "h", "model": "m", "serial": "s", "firmware": "f", "type": "laser", "mac": "00",
+		})).Set(1)
+	})
+
+	assert.InDelta(t, 50, testutil.ToFloat64(brotherMetrics.TonerLevel.With(prometheus.Labels{
+		"host": "h", "color": "black", "site": "dublin", "location": "Floor 3",
+	})), 0)
+}
+
+func TestUpdateLabelData_KeepsStoredSections(t *testing.T) {
+	cfg := &config.Config{Printer: config.PrinterConfig{
+		Host:   "h",
+		Type:   "laser",
+		Labels: map[string]string{"location": "{{.SysLocation}}"},
+	}}
+	m := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"), cfg.Printer.LabelNames()...)
+	bc := NewBrotherCollector(cfg, m, nil)
+
+	first := &printer.Snapshot{
+		Identity: &printer.Identity{Location: "Floor 3"},
+		Supplies: []printer.Supply{{Kind: "toner", Color: "black", Percent: 60}},
+	}
+	first.SetCounter(printer.CounterTotal, 100)
+	bc.render(first)
+	bc.store.Update("h", "h", first, time.Now(), nil)
+
+	bc.handleCollectionError(errors.New("timeout"), "page_counters")
+
+	// Only the identity is due, and its location changes
+	bc.render(&printer.Snapshot{Identity: &printer.Identity{Location: "Floor 4"}})
+
+	assert.InDelta(t, 100, testutil.ToFloat64(m.PageCountTotal.With(prometheus.Labels{"host": "h", "location": "Floor 4"})), 0)
+	// The printer data moved to the new label, nothing is left under the old
+	assert.InDelta(t, 60, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "h", "color": "black", "location": "Floor 4"})), 0)
+	assert.Equal(t, 1, testutil.CollectAndCount(m.TonerLevel))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.PageCountTotal))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.PrinterInfo))
+
+	// Counters keep their series
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PrinterConnectionErrors.With(prometheus.Labels{
+		"host": "h", "error_type": "page_counters", "location": "Floor 3",
+	})), 0)
+}
@@ -0,0 +1,133 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/pjl"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// pjlSource reads status, identity and the page count over the raw
+// printing port. A port that stays busy fails the fetch, so the sections
+// keep their last values or come from the next source.
+type pjlSource struct {
+	bc *BrotherCollector
+}
+
+func (s *pjlSource) Name() string { return config.SourcePJL }
+
+// getPJLClient returns the PJL client, creating it on first use
+func (bc *BrotherCollector) getPJLClient() *pjl.Client {
+	bc.clientsMu.Lock()
+	defer bc.clientsMu.Unlock()
+
+	if bc.pjlClient == nil {
+		pjlCfg := bc.config.Printer.PJL
+		bc.pjlClient = pjl.NewClient(bc.config.Printer.PJLAddress(), pjlCfg.Timeout.Duration, pjlCfg.BusyRetries, pjlCfg.RetryDelay.Duration)
+	}
+
+	return bc.pjlClient
+}
+
+func (s *pjlSource) Fetch(ctx context.Context, sections []string) (*printer.Snapshot, error) {
+	bc := s.bc
+	client := bc.getPJLClient()
+
+	var info *pjl.Info
+
+	// A port still busy after the retries fails the fetch: it can't be told
+	// from a closed port or disabled PJL, so the printer isn't assumed to
+	// be printing
+	err := bc.collectStep("pjl_request", "pjl", func() error {
+		var err error
+
+		info, err = client.Query(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &printer.Snapshot{}
+
+	bc.fillSections(sections, map[string]func() error{
+		printer.SectionIdentity: func() error { return applyPJLInfo(info, snap) },
+		printer.SectionStatus:   func() error { return bc.applyPJLStatus(info, snap) },
+		printer.SectionCounters: func() error { return applyPJLPageCount(info, snap) },
+	})
+
+	return snap, nil
+}
+
+// applyPJLInfo reads the model from INFO ID. PJL has no serial, firmware
+// or MAC.
+func applyPJLInfo(info *pjl.Info, snap *printer.Snapshot) error {
+	if info.ID == "" {
+		return fmt.Errorf("no INFO ID answer")
+	}
+
+	snap.Identity = &printer.Identity{
+		Model: strings.TrimSuffix(strings.TrimPrefix(info.ID, "Brother "), " series"),
+	}
+
+	return nil
+}
+
+// applyPJLStatus maps the INFO STATUS code to the printer status
+func (bc *BrotherCollector) applyPJLStatus(info *pjl.Info, snap *printer.Snapshot) error {
+	if info.StatusCode == 0 {
+		return fmt.Errorf("no INFO STATUS answer")
+	}
+
+	snap.Status = &printer.Status{State: pjlStatus(info.StatusCode, info.Online), Message: info.Display}
+
+	if alert, ok := pjlAlert(info.StatusCode, info.Display); ok {
+		snap.Alerts = []printer.Alert{alert}
+	}
+
+	slog.Debug("PJL status", "host", bc.config.Printer.Host, "code", info.StatusCode, "display", info.Display, "ustatus", info.UStatus)
+
+	return nil
+}
+
+// applyPJLPageCount reads the total page count from INFO PAGECOUNT
+func applyPJLPageCount(info *pjl.Info, snap *printer.Snapshot) error {
+	if info.PageCount < 0 {
+		return fmt.Errorf("no INFO PAGECOUNT answer")
+	}
+
+	snap.SetCounter(printer.CounterTotal, float64(info.PageCount))
+
+	return nil
+}
+
+// pjlStatus maps a PJL status code to a printer status label. Codes are
+// grouped by range: 10xxx informational, 30xxx auto-continuable, 35xxx
+// warnings, 4xxxx operator intervention (paper, jams, covers).
+func pjlStatus(code int, online bool) string {
+	if !online {
+		return "stopped"
+	}
+
+	switch {
+	case code == 10001, code == 10006: // ready, toner low
+		return "ready"
+	case code == 10002: // offline
+		return "stopped"
+	case code == 10003, code == 10004, code == 10005: // warming up, self test, reset
+		return "warmup"
+	case code == 10007, code == 10023, code == 10024: // cancelling, printing, form feeding
+		return "printing"
+	case code >= 35000 && code < 36000: // warnings, still printing
+		return "ready"
+	case code >= 40000 && code < 50000:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
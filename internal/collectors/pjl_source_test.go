@@ -0,0 +1,95 @@
+package collectors
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/pjl"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPJLCollector(address string) (*BrotherCollector, *metrics.BrotherRegistry) {
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host", Type: "laser", Source: config.SourcePJL}}
+	brotherMetrics := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, brotherMetrics, nil)
+	bc.pjlClient = pjl.NewClient(address, 500*time.Millisecond, 0, 0)
+
+	return bc, brotherMetrics
+}
+
+func TestCollectFromPJL(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		_, _ = conn.Read(make([]byte, 1024))
+		_, _ = conn.Write([]byte("@PJL INFO ID\r\n\"Brother HL-5250DN series\"\r\n\f" +
+			"@PJL INFO STATUS\r\nCODE=42000\r\nDISPLAY=\"Paper Jam\"\r\nONLINE=TRUE\r\n\f" +
+			"@PJL INFO PAGECOUNT\r\nPAGECOUNT=12345\r\n\f" +
+			"@PJL INFO USTATUS\r\nDEVICE=OFF\r\n\f"))
+	}()
+
+	bc, m := newPJLCollector(listener.Addr().String())
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PrinterConnectionStatus.With(prometheus.Labels{"host": "test-host"})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PrinterInfo.With(prometheus.Labels{
+		"host": "test-host", "model": "HL-5250DN", "serial": "", "firmware": "", "type": "laser", "mac": "",
+	})))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.PrinterStatus.With(prometheus.Labels{"host": "test-host", "status": "stopped"})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Alert.With(prometheus.Labels{"host": "test-host", "code": "jammed", "severity": "critical"})))
+	assert.Equal(t, 12345.0, testutil.ToFloat64(m.PageCountTotal.With(prometheus.Labels{"host": "test-host"})))
+}
+
+func TestCollectFromPJL_Busy(t *testing.T) {
+	// A refused connection may be a job holding the port, or the port
+	// being closed; neither is reported as printing
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	bc, m := newPJLCollector(address)
+	m.PageCountTotal.With(prometheus.Labels{"host": "test-host"}).Set(100)
+
+	assert.False(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.PrinterConnectionStatus.With(prometheus.Labels{"host": "test-host"})))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.SourceUp.With(prometheus.Labels{"host": "test-host", "source": "pjl"})))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.PrinterStatus))
+	// Previously collected values are kept, the default on_unreachable
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.PageCountTotal.With(prometheus.Labels{"host": "test-host"})))
+
+	state, _ := bc.Store().Get("test-host")
+	assert.False(t, state.Reachable)
+	assert.Contains(t, state.Errors[0], "busy or closed")
+}
+
+func TestPJLStatus(t *testing.T) {
+	assert.Equal(t, "ready", pjlStatus(10001, true))
+	assert.Equal(t, "ready", pjlStatus(10006, true))
+	assert.Equal(t, "stopped", pjlStatus(10001, false))
+	assert.Equal(t, "warmup", pjlStatus(10003, true))
+	assert.Equal(t, "printing", pjlStatus(10023, true))
+	assert.Equal(t, "stopped", pjlStatus(41000, true))
+	assert.Equal(t, "unknown", pjlStatus(99999, true))
+}
@@ -0,0 +1,342 @@
+package collectors
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/gosnmp/gosnmp"
+)
+
+// Probe is what probing a printer found: the state a collection of every
+// section produced, each SNMP OID requested with its answer, and the
+// records of the Brother data blobs
+type Probe struct {
+	State printer.State
+	OIDs  []ProbeOID
+	Blobs []ProbeBlob
+}
+
+// Probe collects every section once, outside the run loop, and reports
+// what the printer answered, for diagnosing a model the exporter doesn't
+// read correctly. Sections are collected whether or not their subsystem
+// is enabled. SNMP is polled as the v3 user when its username is set, the
+// only place the exporter speaks SNMPv3 to the printer.
+func (bc *BrotherCollector) Probe(ctx context.Context, v3 config.SNMPv3Config) Probe {
+	bc.probe = newProbeRecorder()
+	bc.snmpV3 = v3
+
+	defer func() {
+		bc.probe = nil
+		bc.snmpV3 = config.SNMPv3Config{}
+	}()
+
+	bc.collectFromSources(ctx, nil, func(string) bool { return true })
+
+	if bc.hasSource(config.SourceSNMP) {
+		bc.walkTables(ctx)
+	}
+
+	state, _ := bc.store.Get(bc.config.Printer.Host)
+
+	return Probe{State: state, OIDs: bc.probe.oids, Blobs: bc.probe.decodedBlobs()}
+}
+
+// ProbeOID is an SNMP OID requested during a probe and its answer. Error
+// is set when there is no value: the request failed, or the printer
+// doesn't know the OID (NoSuchObject, NoSuchInstance).
+type ProbeOID struct {
+	OID   string `json:"oid"`
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ProbeBlob is a Brother data blob, in hex, split into its records
+type ProbeBlob struct {
+	OID     string        `json:"oid"`
+	Name    string        `json:"name"`
+	Raw     string        `json:"raw"`
+	Records []ProbeRecord `json:"records"`
+}
+
+// ProbeRecord is one record of a blob: its type code, its raw hex and its
+// value. Name and Decoded are what the exporter reads from it, empty for
+// codes it doesn't use.
+type ProbeRecord struct {
+	Code    string `json:"code"`
+	Raw     string `json:"raw"`
+	Value   int64  `json:"value"`
+	Name    string `json:"name,omitempty"`
+	Decoded string `json:"decoded,omitempty"`
+}
+
+// blobNames names the Brother blob OIDs a probe decodes
+var blobNames = map[string]string{
+	OIDBrotherMaintenanceData: "maintenance",
+	OIDBrotherCountersData:    "counters",
+	OIDBrotherNextCareData:    "nextcare",
+}
+
+// brotherCounterCodes names the records of the Brother counters data,
+// as read by collectPageCounters
+var brotherCounterCodes = map[string]string{
+	"0001": printer.CounterTotal,
+	"0101": printer.CounterBlack,
+	"0201": printer.CounterColor,
+	"0601": printer.CounterDuplex,
+	"1201": printer.CounterDrumBlack,
+	"1301": printer.CounterDrumCyan,
+	"1401": printer.CounterDrumMagenta,
+	"1501": printer.CounterDrumYellow,
+}
+
+// oidNames names the OIDs the collectors request
+var oidNames = map[string]string{
+	OIDSystemDescription:         "sysDescr",
+	OIDSystemUpTime:              "sysUpTime",
+	OIDSystemContact:             "sysContact",
+	OIDSystemName:                "sysName",
+	OIDSystemLocation:            "sysLocation",
+	OIDPrinterStatus:             "hrPrinterStatus",
+	OIDPrinterDetectedErrorState: "hrPrinterDetectedErrorState",
+	OIDBrotherConsumableInfo:     "Brother serial",
+	OIDBrotherConsumableLevel:    "Brother consumable level",
+	OIDBrotherFirmware:           "Brother firmware",
+	OIDBrotherMaintenanceData:    "Brother maintenance data",
+	OIDBrotherCountersData:       "Brother counters data",
+	OIDBrotherNextCareData:       "Brother nextcare data",
+	OIDBrotherModel:              "Brother model",
+	OIDBrotherMAC:                "ifPhysAddress",
+	OIDPageCountTotal:            "prtMarkerLifeCount",
+}
+
+// oidTables names the table columns the collectors request rows of, which
+// a probe walks to list every row, with the supplies' descriptions to tell
+// the rows apart
+var oidTables = []struct{ base, name string }{
+	{OIDTonerLevelBase, "prtMarkerSuppliesLevel"},
+	{OIDDrumLevelBase, "prtMarkerSuppliesMaxCapacity"},
+	{OIDPaperTrayStatusBase, "prtInputStatus"},
+	{OIDSuppliesDescriptionBase, "prtMarkerSuppliesDescription"},
+}
+
+// walkTables walks the table columns in oidTables, so the probe lists every
+// row the printer has rather than the ones the collectors read
+func (bc *BrotherCollector) walkTables(ctx context.Context) {
+	if err := bc.connect(ctx); err != nil {
+		slog.Debug("Failed to connect to walk tables", "error", err)
+		return
+	}
+
+	defer bc.disconnect(ctx)
+
+	for _, table := range oidTables {
+		if _, err := bc.snmpWalk(table.base); err != nil {
+			slog.Debug("Failed to walk table", "table", table.name, "error", err)
+		}
+	}
+}
+
+func oidName(oid string) string {
+	if name, ok := oidNames[oid]; ok {
+		return name
+	}
+
+	for _, table := range oidTables {
+		if oid == table.base {
+			return table.name
+		}
+
+		if row, ok := strings.CutPrefix(oid, table.base+"."); ok {
+			return table.name + "." + row
+		}
+	}
+
+	return ""
+}
+
+// probeRecorder keeps the answer to each OID requested, the latest one
+// when an OID is requested more than once, and the Brother blobs
+type probeRecorder struct {
+	oids  []ProbeOID
+	index map[string]int
+	blobs map[string][]byte
+	order []string
+}
+
+func newProbeRecorder() *probeRecorder {
+	return &probeRecorder{index: make(map[string]int), blobs: make(map[string][]byte)}
+}
+
+// record keeps the outcome of a get of oids
+func (r *probeRecorder) record(oids []string, result *gosnmp.SnmpPacket, err error) {
+	answers := make(map[string]ProbeOID, len(oids))
+
+	if err == nil && result != nil {
+		for _, variable := range result.Variables {
+			answer := r.answer(variable)
+			answers[answer.OID] = answer
+		}
+	}
+
+	for _, oid := range oids {
+		answer, ok := answers[oid]
+
+		switch {
+		case err != nil:
+			answer = ProbeOID{OID: oid, Error: err.Error()}
+		case !ok:
+			answer = ProbeOID{OID: oid, Error: "no varbind returned"}
+		}
+
+		r.keep(answer)
+	}
+}
+
+// recordWalk keeps every row of a table walk, or the failure of the walk
+// under its root
+func (r *probeRecorder) recordWalk(root string, rows []gosnmp.SnmpPDU, err error) {
+	switch {
+	case err != nil:
+		r.keep(ProbeOID{OID: root, Error: err.Error()})
+	case len(rows) == 0:
+		r.keep(ProbeOID{OID: root, Error: "no rows"})
+	}
+
+	for _, row := range rows {
+		r.keep(r.answer(row))
+	}
+}
+
+// answer turns a varbind into its answer, keeping it when it is a blob
+func (r *probeRecorder) answer(variable gosnmp.SnmpPDU) ProbeOID {
+	oid := strings.TrimPrefix(variable.Name, ".")
+	answer := ProbeOID{OID: oid, Type: variable.Type.String()}
+
+	switch variable.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		answer.Error = variable.Type.String()
+	default:
+		answer.Value = formatSNMPValue(variable.Value)
+	}
+
+	if raw, ok := variable.Value.([]byte); ok && blobNames[oid] != "" {
+		if _, seen := r.blobs[oid]; !seen {
+			r.order = append(r.order, oid)
+		}
+
+		r.blobs[oid] = raw
+	}
+
+	return answer
+}
+
+// keep adds an answer, or replaces the earlier answer for the same OID
+func (r *probeRecorder) keep(answer ProbeOID) {
+	answer.Name = oidName(answer.OID)
+
+	if i, seen := r.index[answer.OID]; seen {
+		r.oids[i] = answer
+		return
+	}
+
+	r.index[answer.OID] = len(r.oids)
+	r.oids = append(r.oids, answer)
+}
+
+// decodedBlobs decodes the blobs in the order they were first received
+func (r *probeRecorder) decodedBlobs() []ProbeBlob {
+	blobs := make([]ProbeBlob, 0, len(r.order))
+
+	for _, oid := range r.order {
+		blobs = append(blobs, decodeBlob(oid, r.blobs[oid]))
+	}
+
+	return blobs
+}
+
+// formatSNMPValue shows an SNMP value: text as is, other bytes in hex
+func formatSNMPValue(value any) string {
+	raw, ok := value.([]byte)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	if utf8.Valid(raw) && strings.IndexFunc(string(raw), func(r rune) bool { return !unicode.IsPrint(r) }) == -1 {
+		return string(raw)
+	}
+
+	return hex.EncodeToString(raw)
+}
+
+// decodeBlob splits a Brother blob into records the way the collectors
+// read it: the counters data into 7 byte records of a 2 byte code, a flag
+// and a 4 byte value, the maintenance and nextcare data, without their
+// checksum byte, into 7 byte records of a 1 byte code and a value in the
+// last 4 bytes
+func decodeBlob(oid string, raw []byte) ProbeBlob {
+	blob := ProbeBlob{OID: oid, Name: blobNames[oid], Raw: hex.EncodeToString(raw), Records: []ProbeRecord{}}
+
+	if oid == OIDBrotherCountersData {
+		for i := 0; i+6 < len(raw); i += 7 {
+			record := ProbeRecord{
+				Code:  fmt.Sprintf("%02X%02X", raw[i], raw[i+1]),
+				Raw:   hex.EncodeToString(raw[i : i+7]),
+				Value: int64(raw[i+3])<<24 | int64(raw[i+4])<<16 | int64(raw[i+5])<<8 | int64(raw[i+6]),
+			}
+
+			if name, ok := brotherCounterCodes[record.Code]; ok {
+				record.Name = name
+
+				if raw[i+2] == 0x04 {
+					record.Decoded = fmt.Sprintf("%d pages", record.Value)
+				} else {
+					record.Decoded = fmt.Sprintf("skipped, flag %02x is not 04", raw[i+2])
+				}
+			}
+
+			blob.Records = append(blob.Records, record)
+		}
+
+		return blob
+	}
+
+	for _, chunk := range splitIntoChunks(bytesToHexString(raw), BrotherChunkSize) {
+		if len(chunk) < 2 {
+			continue
+		}
+
+		record := ProbeRecord{Code: chunk[:2], Raw: chunk}
+
+		if len(chunk) >= 10 {
+			record.Value, _ = strconv.ParseInt(chunk[len(chunk)-8:], 16, 64)
+		}
+
+		switch {
+		case oid == OIDBrotherNextCareData:
+			if name, ok := brotherNextCareCodes[record.Code]; ok {
+				record.Name = name
+				record.Decoded = fmt.Sprintf("%d pages", record.Value)
+			}
+		case brotherNearEndCodes[record.Code] != "":
+			record.Name = brotherNearEndCodes[record.Code] + "_near_end"
+			record.Decoded = strconv.FormatBool(record.Value != 0)
+		case brotherMaintenanceCodes[record.Code] != "":
+			record.Name = brotherMaintenanceCodes[record.Code]
+			record.Decoded = fmt.Sprintf("%d%%", record.Value/BrotherPercentageDiv)
+		}
+
+		blob.Records = append(blob.Records, record)
+	}
+
+	return blob
+}
@@ -0,0 +1,90 @@
+package collectors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBlob(t *testing.T) {
+	// Black toner at 60%, the toner near-end flag set, an unknown record
+	// and the checksum byte
+	maintenance := decodeBlob(OIDBrotherMaintenanceData, []byte{
+		0x6f, 0x01, 0x04, 0x00, 0x00, 0x17, 0x70,
+		0x31, 0x01, 0x04, 0x00, 0x00, 0x00, 0x01,
+		0x99, 0x01, 0x04, 0x00, 0x00, 0x00, 0x2a,
+		0xff,
+	})
+
+	assert.Equal(t, "maintenance", maintenance.Name)
+	assert.Equal(t, []ProbeRecord{
+		{Code: "6f", Raw: "6f010400001770", Value: 6000, Name: "black_toner_remaining", Decoded: "60%"},
+		{Code: "31", Raw: "31010400000001", Value: 1, Name: "toner_near_end", Decoded: "true"},
+		{Code: "99", Raw: "9901040000002a", Value: 42},
+	}, maintenance.Records)
+
+	nextcare := decodeBlob(OIDBrotherNextCareData, []byte{0x89, 0x01, 0x04, 0x00, 0x00, 0xbb, 0x80, 0xff})
+	assert.Equal(t, []ProbeRecord{
+		{Code: "89", Raw: "8901040000bb80", Value: 48000, Name: "fuser_unit_remaining_pages", Decoded: "48000 pages"},
+	}, nextcare.Records)
+
+	// Counters have no checksum; a record without the 04 flag is skipped
+	counters := decodeBlob(OIDBrotherCountersData, []byte{
+		0x00, 0x01, 0x04, 0x00, 0x00, 0x15, 0x38,
+		0x02, 0x01, 0x05, 0x00, 0x00, 0x05, 0x98,
+	})
+	assert.Equal(t, []ProbeRecord{
+		{Code: "0001", Raw: "00010400001538", Value: 5432, Name: "total", Decoded: "5432 pages"},
+		{Code: "0201", Raw: "02010500000598", Value: 1432, Name: "color", Decoded: "skipped, flag 05 is not 04"},
+	}, counters.Records)
+}
+
+func TestProbeRecorder(t *testing.T) {
+	r := newProbeRecorder()
+
+	r.record([]string{OIDBrotherModel, OIDSystemName, OIDBrotherMaintenanceData}, &gosnmp.SnmpPacket{Variables: []gosnmp.SnmpPDU{
+		{Name: "." + OIDBrotherModel, Type: gosnmp.OctetString, Value: []byte("MFG:Brother;MDL:HL-L3270CDW series")},
+		{Name: "." + OIDSystemName, Type: gosnmp.NoSuchObject},
+		{Name: "." + OIDBrotherMaintenanceData, Type: gosnmp.OctetString, Value: []byte{0x6f, 0x01, 0x04, 0x00, 0x00, 0x17, 0x70, 0xff}},
+	}}, nil)
+
+	r.record([]string{OIDPaperTrayStatusBase + ".1"}, nil, errors.New("request timeout"))
+
+	assert.Equal(t, []ProbeOID{
+		{OID: OIDBrotherModel, Name: "Brother model", Type: "OctetString", Value: "MFG:Brother;MDL:HL-L3270CDW series"},
+		{OID: OIDSystemName, Name: "sysName", Type: "NoSuchObject", Error: "NoSuchObject"},
+		{OID: OIDBrotherMaintenanceData, Name: "Brother maintenance data", Type: "OctetString", Value: "6f010400001770ff"},
+		{OID: OIDPaperTrayStatusBase + ".1", Name: "prtInputStatus.1", Error: "request timeout"},
+	}, r.oids)
+
+	blobs := r.decodedBlobs()
+	if assert.Len(t, blobs, 1) {
+		assert.Equal(t, "6f010400001770ff", blobs[0].Raw)
+		assert.Equal(t, "60%", blobs[0].Records[0].Decoded)
+	}
+}
+
+func TestProbeRecorderWalk(t *testing.T) {
+	r := newProbeRecorder()
+
+	r.record([]string{OIDTonerLevelBase + ".1"}, &gosnmp.SnmpPacket{Variables: []gosnmp.SnmpPDU{
+		{Name: "." + OIDTonerLevelBase + ".1", Type: gosnmp.Integer, Value: -3},
+	}}, nil)
+
+	// Every row of a walk is listed, replacing what a get answered
+	r.recordWalk(OIDTonerLevelBase, []gosnmp.SnmpPDU{
+		{Name: "." + OIDTonerLevelBase + ".1", Type: gosnmp.Integer, Value: 60},
+		{Name: "." + OIDTonerLevelBase + ".2", Type: gosnmp.Integer, Value: 40},
+	}, nil)
+	r.recordWalk(OIDPaperTrayStatusBase, nil, nil)
+	r.recordWalk(OIDSuppliesDescriptionBase, nil, errors.New("request timeout"))
+
+	assert.Equal(t, []ProbeOID{
+		{OID: OIDTonerLevelBase + ".1", Name: "prtMarkerSuppliesLevel.1", Type: "Integer", Value: "60"},
+		{OID: OIDTonerLevelBase + ".2", Name: "prtMarkerSuppliesLevel.2", Type: "Integer", Value: "40"},
+		{OID: OIDPaperTrayStatusBase, Name: "prtInputStatus", Error: "no rows"},
+		{OID: OIDSuppliesDescriptionBase, Name: "prtMarkerSuppliesDescription", Error: "request timeout"},
+	}, r.oids)
+}
@@ -0,0 +1,208 @@
+package collectors
+
+import (
+	"log/slog"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// render sets the printer metrics from a snapshot, and the state of each
+// supply in it. Sections the snapshot doesn't hold keep their previous
+// values.
+func (bc *BrotherCollector) render(snap *printer.Snapshot) {
+	// Identity first, it feeds any templated labels
+	if identity := snap.Identity; identity != nil {
+		bc.updateLabelData(LabelData{
+			Host:        bc.config.Printer.Host,
+			Type:        bc.config.Printer.Type,
+			Model:       identity.Model,
+			Serial:      identity.Serial,
+			Firmware:    identity.Firmware,
+			MAC:         identity.MAC,
+			SysName:     identity.Name,
+			SysLocation: identity.Location,
+			SysContact:  identity.Contact,
+			SysDescr:    identity.Description,
+		})
+	}
+
+	bc.renderSnapshot(snap)
+}
+
+// renderSnapshot sets the metrics of every section snap holds
+func (bc *BrotherCollector) renderSnapshot(snap *printer.Snapshot) {
+	if identity := snap.Identity; identity != nil {
+		bc.metrics.PrinterInfo.Reset()
+		bc.metrics.PrinterInfo.With(bc.labels(prometheus.Labels{
+			"host":     bc.config.Printer.Host,
+			"model":    identity.Model,
+			"serial":   identity.Serial,
+			"firmware": identity.Firmware,
+			"type":     bc.config.Printer.Type,
+			"mac":      identity.MAC,
+		})).Set(1)
+	}
+
+	if snap.Status != nil {
+		bc.setPrinterStatus(snap.Status.State)
+
+		// Alerts come with the status, cleared ones disappear
+		bc.metrics.Alert.Reset()
+
+		for _, alert := range snap.Alerts {
+			bc.metrics.Alert.With(bc.labels(prometheus.Labels{
+				"host":     bc.config.Printer.Host,
+				"code":     alert.Code,
+				"severity": alert.Severity,
+			})).Set(1)
+		}
+	}
+
+	if !snap.BootTime.IsZero() {
+		bc.metrics.PrinterUptime.With(bc.labels(prometheus.Labels{
+			"host": bc.config.Printer.Host,
+		})).Set(float64(snap.BootTime.Unix()))
+	}
+
+	for i, supply := range snap.Supplies {
+		snap.Supplies[i].State = bc.renderSupply(supply)
+	}
+
+	for supply, nearEnd := range snap.NearEnd {
+		value := 0.0
+		if nearEnd {
+			value = 1.0
+		}
+
+		bc.metrics.SupplyNearEnd.With(bc.labels(prometheus.Labels{
+			"host":   bc.config.Printer.Host,
+			"supply": supply,
+		})).Set(value)
+	}
+
+	partPages := map[string]*prometheus.GaugeVec{
+		"belt_unit":         bc.metrics.BeltUnitRemainingPages,
+		"fuser_unit":        bc.metrics.FuserUnitRemainingPages,
+		"laser_unit":        bc.metrics.LaserUnitRemainingPages,
+		"paper_feeding_kit": bc.metrics.PaperFeedingKitRemainingPages,
+	}
+
+	for _, part := range snap.Maintenance {
+		if metric, ok := partPages[part.Kind]; ok {
+			metric.With(bc.labels(prometheus.Labels{"host": bc.config.Printer.Host})).Set(part.RemainingPages)
+		}
+	}
+
+	for _, tray := range snap.Trays {
+		value := 0.0
+		if tray.Status == "ok" {
+			value = 1.0
+		}
+
+		bc.setPaperTrayStatus(tray.Name, tray.Status, value)
+	}
+
+	counters := map[string]*prometheus.GaugeVec{
+		printer.CounterTotal:       bc.metrics.PageCountTotal,
+		printer.CounterBlack:       bc.metrics.PageCountBlack,
+		printer.CounterColor:       bc.metrics.PageCountColor,
+		printer.CounterDuplex:      bc.metrics.PageCountDuplex,
+		printer.CounterDrumBlack:   bc.metrics.PageCountDrumBlack,
+		printer.CounterDrumCyan:    bc.metrics.PageCountDrumCyan,
+		printer.CounterDrumMagenta: bc.metrics.PageCountDrumMagenta,
+		printer.CounterDrumYellow:  bc.metrics.PageCountDrumYellow,
+	}
+
+	for name, value := range snap.Counters {
+		if metric, ok := counters[name]; ok {
+			metric.With(bc.labels(prometheus.Labels{"host": bc.config.Printer.Host})).Set(value)
+		}
+	}
+}
+
+// renderSupply sets a supply's level metric and derived state, and returns
+// the state
+func (bc *BrotherCollector) renderSupply(supply printer.Supply) string {
+	colorLevels := map[string]struct{ level, status *prometheus.GaugeVec }{
+		"toner": {bc.metrics.TonerLevel, bc.metrics.TonerStatus},
+		"drum":  {bc.metrics.DrumLevel, bc.metrics.DrumStatus},
+		"ink":   {bc.metrics.InkLevel, bc.metrics.InkStatus},
+	}
+
+	partLevels := map[string]*prometheus.GaugeVec{
+		"belt_unit":         bc.metrics.BeltUnitRemainingPercent,
+		"fuser_unit":        bc.metrics.FuserUnitRemainingPercent,
+		"laser_unit":        bc.metrics.LaserUnitRemainingPercent,
+		"paper_feeding_kit": bc.metrics.PaperFeedingKitRemainingPercent,
+	}
+
+	if metrics, ok := colorLevels[supply.Kind]; ok {
+		metrics.level.With(bc.labels(prometheus.Labels{
+			"host":  bc.config.Printer.Host,
+			"color": supply.Color,
+		})).Set(supply.Percent)
+		status, _ := bc.updateSupplyState(supply.Kind, supply.Color, supply.Percent, metrics.status)
+
+		return status
+	}
+
+	if metric, ok := partLevels[supply.Kind]; ok {
+		metric.With(bc.labels(prometheus.Labels{"host": bc.config.Printer.Host})).Set(supply.Percent)
+		status, _ := bc.updateSupplyState(supply.Kind, "", supply.Percent, nil)
+
+		return status
+	}
+
+	slog.Debug("Ignoring supply without a metric", "kind", supply.Kind, "color", supply.Color)
+
+	return ""
+}
+
+// printerStatuses and paperTrayStatuses are the status label values a
+// source can report, so the previous value's series can be removed
+var (
+	printerStatuses   = []string{"ready", "printing", "warmup", "stopped", "unknown"}
+	paperTrayStatuses = []string{"ok", "empty", "low", "unknown"}
+)
+
+// setPrinterStatus sets the printer status series and removes the others
+func (bc *BrotherCollector) setPrinterStatus(status string) {
+	for _, other := range printerStatuses {
+		if other != status {
+			bc.metrics.PrinterStatus.Delete(bc.labels(prometheus.Labels{
+				"host":   bc.config.Printer.Host,
+				"status": other,
+			}))
+		}
+	}
+
+	value := 0.0
+	if status == "ready" {
+		value = 1.0
+	}
+
+	bc.metrics.PrinterStatus.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"status": status,
+	})).Set(value)
+}
+
+// setPaperTrayStatus sets a tray's status series and removes the others
+func (bc *BrotherCollector) setPaperTrayStatus(tray, status string, value float64) {
+	for _, other := range paperTrayStatuses {
+		if other != status {
+			bc.metrics.PaperTrayStatus.Delete(bc.labels(prometheus.Labels{
+				"host":   bc.config.Printer.Host,
+				"tray":   tray,
+				"status": other,
+			}))
+		}
+	}
+
+	bc.metrics.PaperTrayStatus.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"tray":   tray,
+		"status": status,
+	})).Set(value)
+}
@@ -0,0 +1,160 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/escl"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Scanner and document feeder states exported as state sets
+var (
+	scannerStates    = []string{"idle", "processing", "testing", "stopped", "down"}
+	scannerAdfStates = []string{"loaded", "empty", "jammed", "door_open", "processing", "other"}
+)
+
+// esclAdfStates maps eSCL AdfState values to brother_scanner_adf_state
+// labels. Other values, such as the duplex page length errors, are "other".
+var esclAdfStates = map[string]string{
+	"ScannerAdfLoaded":            "loaded",
+	"ScannerAdfEmpty":             "empty",
+	"ScannerAdfJam":               "jammed",
+	"ScannerAdfMispick":           "jammed",
+	"ScannerAdfMultipickDetected": "jammed",
+	"ScannerAdfHatchOpen":         "door_open",
+	"ScannerAdfProcessing":        "processing",
+}
+
+// scanJobs tracks the scan jobs seen in ScannerStatus so each finished job
+// is counted once, although the scanner lists it for a while
+type scanJobs struct {
+	finished map[string]bool
+	primed   bool
+}
+
+// collectScanner polls the eSCL endpoints. Capabilities are only fetched
+// once as they describe the hardware.
+func (bc *BrotherCollector) collectScanner(ctx context.Context) error {
+	if bc.esclClient == nil {
+		esclCfg := bc.config.Printer.ESCL
+		bc.esclClient = escl.NewClient(bc.config.Printer.ESCLURL(), esclCfg.Timeout.Duration, esclCfg.InsecureSkipVerify)
+	}
+
+	if bc.scannerCapabilities == nil {
+		capabilities, err := bc.esclClient.Capabilities(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get scanner capabilities: %w", err)
+		}
+
+		bc.scannerCapabilities = capabilities
+	}
+
+	status, err := bc.esclClient.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get scanner status: %w", err)
+	}
+
+	bc.scannerStatus = status
+	bc.renderScanner()
+	bc.updateScanJobs(status.Jobs)
+
+	return nil
+}
+
+// renderScanner sets the scanner metrics from the last status polled
+func (bc *BrotherCollector) renderScanner() {
+	capabilities, status := bc.scannerCapabilities, bc.scannerStatus
+	if capabilities == nil || status == nil {
+		return
+	}
+
+	bc.metrics.ScannerInfo.Reset()
+	bc.metrics.ScannerInfo.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"model":  strings.TrimSuffix(strings.TrimPrefix(capabilities.MakeAndModel, "Brother "), " series"),
+		"serial": capabilities.SerialNumber,
+		"adf":    strconv.FormatBool(capabilities.HasAdf()),
+		"duplex": strconv.FormatBool(capabilities.HasDuplexAdf()),
+	})).Set(1)
+
+	state := strings.ToLower(status.State)
+	if state == "" {
+		state = "down"
+	}
+
+	bc.setStateSet(bc.metrics.ScannerState, scannerStates, state)
+
+	if capabilities.HasAdf() || status.AdfState != "" {
+		adfState, ok := esclAdfStates[status.AdfState]
+		if !ok {
+			adfState = "other"
+		}
+
+		bc.setStateSet(bc.metrics.ScannerAdfState, scannerAdfStates, adfState)
+	}
+
+	active := 0
+
+	for _, job := range status.Jobs {
+		if job.JobState == "Pending" || job.JobState == "Processing" {
+			active++
+		}
+	}
+
+	bc.metrics.ScannerActiveJobs.With(bc.labels(prometheus.Labels{
+		"host": bc.config.Printer.Host,
+	})).Set(float64(active))
+}
+
+// updateScanJobs counts newly finished jobs. Jobs already finished on the
+// first poll happened before the exporter started and aren't counted.
+func (bc *BrotherCollector) updateScanJobs(jobs []escl.JobInfo) {
+	finished := make(map[string]bool, len(jobs))
+
+	for _, job := range jobs {
+		var result string
+
+		switch job.JobState {
+		case "Completed":
+			result = "completed"
+		case "Canceled":
+			result = "canceled"
+		case "Aborted":
+			result = "aborted"
+		default:
+			continue
+		}
+
+		id := job.ID()
+		finished[id] = true
+
+		if bc.scanJobs.primed && !bc.scanJobs.finished[id] {
+			bc.metrics.ScannerJobsCompleted.With(bc.labels(prometheus.Labels{
+				"host":   bc.config.Printer.Host,
+				"result": result,
+			})).Inc()
+		}
+	}
+
+	// Jobs that dropped out of the list are forgotten
+	bc.scanJobs.finished = finished
+	bc.scanJobs.primed = true
+}
+
+// setStateSet sets the current state's series to 1 and the others to 0
+func (bc *BrotherCollector) setStateSet(metric *prometheus.GaugeVec, states []string, current string) {
+	for _, state := range states {
+		value := 0.0
+		if state == current {
+			value = 1.0
+		}
+
+		metric.With(bc.labels(prometheus.Labels{
+			"host":  bc.config.Printer.Host,
+			"state": state,
+		})).Set(value)
+	}
+}
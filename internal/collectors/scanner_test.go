@@ -0,0 +1,113 @@
+package collectors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/escl"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectScanner(t *testing.T) {
+	capabilities, err := os.ReadFile("testdata/escl/ScannerCapabilities.xml")
+	require.NoError(t, err)
+
+	firstStatus, err := os.ReadFile("testdata/escl/ScannerStatus.xml")
+	require.NoError(t, err)
+
+	// On the second poll job 3 has finished and job 4 was cancelled
+	secondStatus := strings.Replace(string(firstStatus), "<pwg:JobState>Processing</pwg:JobState>", "<pwg:JobState>Completed</pwg:JobState>", 1)
+	secondStatus = strings.Replace(secondStatus, "<pwg:State>Processing</pwg:State>", "<pwg:State>Idle</pwg:State>", 1)
+	secondStatus = strings.Replace(secondStatus, "ScannerAdfLoaded", "ScannerAdfJam", 1)
+	secondStatus = strings.Replace(secondStatus, "<scan:Jobs>",
+		"<scan:Jobs><scan:JobInfo><pwg:JobUuid>4</pwg:JobUuid><pwg:JobState>Canceled</pwg:JobState></scan:JobInfo>", 1)
+
+	var polls atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eSCL/ScannerCapabilities", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(capabilities)
+	})
+	mux.HandleFunc("/eSCL/ScannerStatus", func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) == 1 {
+			_, _ = w.Write(firstStatus)
+			return
+		}
+
+		_, _ = w.Write([]byte(secondStatus))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host"}}
+	m := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, m, nil)
+	bc.esclClient = escl.NewClient(server.URL+"/eSCL", 5*time.Second, false)
+
+	state := func(vec *prometheus.GaugeVec, state string) float64 {
+		return testutil.ToFloat64(vec.With(prometheus.Labels{"host": "test-host", "state": state}))
+	}
+	jobs := func(result string) float64 {
+		return testutil.ToFloat64(m.ScannerJobsCompleted.With(prometheus.Labels{"host": "test-host", "result": result}))
+	}
+
+	require.NoError(t, bc.collectScanner(t.Context()))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ScannerInfo.With(prometheus.Labels{
+		"host": "test-host", "model": "MFC-L2750DW", "serial": "U64123A9N123456", "adf": "true", "duplex": "true",
+	})))
+	assert.Equal(t, 1.0, state(m.ScannerState, "processing"))
+	assert.Equal(t, 0.0, state(m.ScannerState, "idle"))
+	assert.Equal(t, 1.0, state(m.ScannerAdfState, "loaded"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ScannerActiveJobs.With(prometheus.Labels{"host": "test-host"})))
+	// Job 2 finished before the first poll, so it isn't counted
+	assert.Equal(t, 0, testutil.CollectAndCount(m.ScannerJobsCompleted))
+
+	require.NoError(t, bc.collectScanner(t.Context()))
+
+	assert.Equal(t, 1.0, state(m.ScannerState, "idle"))
+	assert.Equal(t, 1.0, state(m.ScannerAdfState, "jammed"))
+	assert.Equal(t, 0.0, state(m.ScannerAdfState, "loaded"))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.ScannerActiveJobs.With(prometheus.Labels{"host": "test-host"})))
+	assert.Equal(t, 1.0, jobs("completed"))
+	assert.Equal(t, 1.0, jobs("canceled"))
+
+	// Jobs still listed on later polls aren't counted again
+	require.NoError(t, bc.collectScanner(t.Context()))
+	assert.Equal(t, 1.0, jobs("completed"))
+}
+
+func TestScannerSubsystemIsOptIn(t *testing.T) {
+	enabled := true
+	cfg := &config.Config{}
+
+	assert.False(t, cfg.SubsystemEnabled(config.SubsystemScanner))
+	assert.True(t, cfg.SubsystemEnabled(config.SubsystemStatus))
+
+	cfg.Collectors = map[string]config.SubsystemConfig{config.SubsystemScanner: {Enabled: &enabled}}
+	assert.True(t, cfg.SubsystemEnabled(config.SubsystemScanner))
+}
+
+func TestESCLAdfStates(t *testing.T) {
+	assert.Equal(t, "door_open", esclAdfStates["ScannerAdfHatchOpen"])
+	assert.Equal(t, "jammed", esclAdfStates["ScannerAdfJam"])
+	assert.Equal(t, "jammed", esclAdfStates["ScannerAdfMispick"])
+	assert.Equal(t, "jammed", esclAdfStates["ScannerAdfMultipickDetected"])
+
+	for _, state := range esclAdfStates {
+		assert.Contains(t, scannerAdfStates, state)
+	}
+}
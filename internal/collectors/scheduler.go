@@ -0,0 +1,89 @@
+package collectors
+
+import (
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+)
+
+// dueTolerance is how early a subsystem may run, so ticker jitter doesn't
+// push it back a whole tick
+const dueTolerance = 500 * time.Millisecond
+
+// minTick bounds the scheduler period when the intervals share no longer
+// common divisor
+const minTick = time.Second
+
+// schedule tracks the interval and next run of each enabled collection
+// subsystem so run can call each one on its own cadence
+type schedule struct {
+	mu        sync.Mutex
+	order     []string
+	intervals map[string]time.Duration
+	next      map[string]time.Time
+}
+
+// newSchedule builds the schedule for every enabled subsystem
+func newSchedule(cfg *config.Config) *schedule {
+	s := &schedule{
+		intervals: make(map[string]time.Duration),
+		next:      make(map[string]time.Time),
+	}
+
+	for _, name := range config.Subsystems {
+		if !cfg.SubsystemEnabled(name) {
+			continue
+		}
+
+		s.order = append(s.order, name)
+		s.intervals[name] = cfg.SubsystemInterval(name)
+	}
+
+	return s
+}
+
+// tick returns the scheduler period, the greatest common divisor of the
+// enabled intervals, so every subsystem comes due on a tick
+func (s *schedule) tick() time.Duration {
+	var period time.Duration
+
+	for _, interval := range s.intervals {
+		period = gcd(period, interval)
+	}
+
+	if period == 0 {
+		return time.Minute
+	}
+
+	return max(period, minTick)
+}
+
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// due returns the subsystems due at now, in run order, and schedules their
+// next run one interval later
+func (s *schedule) due(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tolerance := min(dueTolerance, s.tick()/2)
+
+	var due []string
+
+	for _, name := range s.order {
+		next := s.next[name]
+		if next.IsZero() || !now.Before(next.Add(-tolerance)) {
+			due = append(due, name)
+			s.next[name] = now.Add(s.intervals[name])
+		}
+	}
+
+	return due
+}
@@ -0,0 +1,83 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_PerSubsystemIntervals(t *testing.T) {
+	disabled := false
+
+	cfg := &config.Config{
+		Collectors: map[string]config.SubsystemConfig{
+			config.SubsystemInfo:   {Interval: config.Duration{Duration: time.Hour}},
+			config.SubsystemStatus: {Interval: config.Duration{Duration: 10 * time.Second}},
+			config.SubsystemUptime: {Enabled: &disabled},
+		},
+	}
+	cfg.Metrics.Collection.DefaultInterval = config.Duration{Duration: 30 * time.Second}
+
+	s := newSchedule(cfg)
+	assert.Equal(t, 10*time.Second, s.tick())
+
+	start := time.Now()
+
+	// Everything enabled runs on the first pass, in cycle order
+	assert.Equal(t, []string{
+		config.SubsystemInfo,
+		config.SubsystemStatus,
+		config.SubsystemBrother,
+		config.SubsystemNextCare,
+		config.SubsystemPaperTray,
+		config.SubsystemPageCounters,
+	}, s.due(start))
+
+	// Only status is due after one tick
+	assert.Equal(t, []string{config.SubsystemStatus}, s.due(start.Add(10*time.Second)))
+
+	// Default interval subsystems come due with a little ticker jitter
+	assert.Contains(t, s.due(start.Add(29*time.Second+800*time.Millisecond)), config.SubsystemBrother)
+
+	// Info waits for its own interval
+	assert.NotContains(t, s.due(start.Add(40*time.Minute)), config.SubsystemInfo)
+	assert.Contains(t, s.due(start.Add(time.Hour)), config.SubsystemInfo)
+}
+
+func TestSchedule_NonMultipleIntervals(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		status, fallback time.Duration
+		tick             time.Duration
+	}{
+		{"10s and 15s", 10 * time.Second, 15 * time.Second, 5 * time.Second},
+		{"20s and 30s", 20 * time.Second, 30 * time.Second, 10 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Collectors: map[string]config.SubsystemConfig{
+					config.SubsystemStatus: {Interval: config.Duration{Duration: tc.status}},
+				},
+			}
+			cfg.Metrics.Collection.DefaultInterval = config.Duration{Duration: tc.fallback}
+
+			s := newSchedule(cfg)
+			assert.Equal(t, tc.tick, s.tick())
+
+			start := time.Now()
+			runs := map[string]int{}
+
+			// Two minutes of ticks, each a little late
+			for at := time.Duration(0); at < 2*time.Minute; at += s.tick() {
+				for _, name := range s.due(start.Add(at + 50*time.Millisecond)) {
+					runs[name]++
+				}
+			}
+
+			assert.Equal(t, int(2*time.Minute/tc.status), runs[config.SubsystemStatus])
+			assert.Equal(t, int(2*time.Minute/tc.fallback), runs[config.SubsystemBrother])
+		})
+	}
+}
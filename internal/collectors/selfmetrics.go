@@ -0,0 +1,133 @@
+package collectors
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectStep runs a single collection step, recording its duration and,
+// on success, its last success timestamp. Errors go through
+// handleCollectionError under the given operation name and are returned.
+func (bc *BrotherCollector) collectStep(step, operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+
+	bc.metrics.CollectionDuration.With(bc.labels(prometheus.Labels{
+		"host": bc.config.Printer.Host,
+		"step": step,
+	})).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		bc.markSuccess(step)
+	}
+
+	bc.handleCollectionError(err, operation)
+
+	return err
+}
+
+// markSuccess records that step has just refreshed its data
+func (bc *BrotherCollector) markSuccess(step string) {
+	now := time.Now()
+
+	bc.stateMu.Lock()
+	bc.lastSuccess[step] = now
+	bc.stateMu.Unlock()
+
+	bc.metrics.LastSuccessTimestamp.With(bc.labels(prometheus.Labels{
+		"host": bc.config.Printer.Host,
+		"step": step,
+	})).Set(float64(now.Unix()))
+}
+
+// snmpGet wraps client.Get, counting requests by result and the varbinds
+// that came back, so OIDs silently disappearing after a firmware update show
+// up as missing varbinds
+func (bc *BrotherCollector) snmpGet(oids []string) (*gosnmp.SnmpPacket, error) {
+	result, err := bc.client.Get(oids)
+
+	if bc.probe != nil {
+		bc.probe.record(oids, result, err)
+	}
+
+	bc.countSNMPRequest(err)
+
+	if err != nil || result == nil {
+		return result, err
+	}
+
+	bc.countSNMPVarbinds(result.Variables)
+
+	return result, nil
+}
+
+// snmpWalk wraps client.BulkWalkAll with the same accounting as snmpGet,
+// returning every row under root
+func (bc *BrotherCollector) snmpWalk(root string) ([]gosnmp.SnmpPDU, error) {
+	rows, err := bc.client.BulkWalkAll(root)
+
+	if bc.probe != nil {
+		bc.probe.recordWalk(root, rows, err)
+	}
+
+	bc.countSNMPRequest(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bc.countSNMPVarbinds(rows)
+
+	return rows, nil
+}
+
+// countSNMPRequest counts a request by result and whether it was answered
+// in this cycle
+func (bc *BrotherCollector) countSNMPRequest(err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		if strings.Contains(strings.ToLower(err.Error()), "timeout") {
+			outcome = "timeout"
+		}
+	}
+
+	bc.metrics.SNMPRequests.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"result": outcome,
+	})).Inc()
+
+	bc.stateMu.Lock()
+	bc.cycleRequests++
+	if err == nil {
+		bc.cycleAnswered++
+	}
+	bc.stateMu.Unlock()
+}
+
+// countSNMPVarbinds counts the varbinds that came back with a value and
+// those the printer doesn't know
+func (bc *BrotherCollector) countSNMPVarbinds(variables []gosnmp.SnmpPDU) {
+	var present, missing int
+
+	for _, variable := range variables {
+		switch variable.Type {
+		case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+			missing++
+		default:
+			present++
+		}
+	}
+
+	bc.metrics.SNMPVarbinds.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"result": "value",
+	})).Add(float64(present))
+	bc.metrics.SNMPVarbinds.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"result": "missing",
+	})).Add(float64(missing))
+}
@@ -0,0 +1,35 @@
+package collectors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStep_RecordsDurationAndLastSuccess(t *testing.T) {
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host"}}
+	brotherMetrics := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+	bc := NewBrotherCollector(cfg, brotherMetrics, nil)
+
+	assert.NoError(t, bc.collectStep("status", "printer status", func() error { return nil }))
+	assert.Error(t, bc.collectStep("uptime", "printer uptime", func() error { return errors.New("boom") }))
+
+	// Both steps are timed, only the successful one has a last success
+	assert.Equal(t, 2, testutil.CollectAndCount(brotherMetrics.CollectionDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(brotherMetrics.LastSuccessTimestamp))
+	assert.Positive(t, testutil.ToFloat64(brotherMetrics.LastSuccessTimestamp.With(prometheus.Labels{
+		"host": "test-host",
+		"step": "status",
+	})))
+
+	assert.InDelta(t, 1, testutil.ToFloat64(brotherMetrics.PrinterConnectionErrors.With(prometheus.Labels{
+		"host":       "test-host",
+		"error_type": "printer uptime",
+	})), 0)
+}
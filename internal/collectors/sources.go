@@ -0,0 +1,247 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/promexporter/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sectionSteps maps each snapshot section to the subsystem that collects it,
+// which is also its collection step, and the operation its errors count under
+var sectionSteps = map[string]struct{ step, operation string }{
+	printer.SectionIdentity:    {config.SubsystemInfo, "printer info"},
+	printer.SectionStatus:      {config.SubsystemStatus, "printer status"},
+	printer.SectionUptime:      {config.SubsystemUptime, "printer uptime"},
+	printer.SectionSupplies:    {config.SubsystemBrother, "supplies"},
+	printer.SectionMaintenance: {config.SubsystemNextCare, "nextcare_metrics"},
+	printer.SectionTrays:       {config.SubsystemPaperTray, "paper_tray"},
+	printer.SectionCounters:    {config.SubsystemPageCounters, "page_counters"},
+}
+
+// configuredSource is a data source and the sections it may provide
+type configuredSource struct {
+	source   printer.Source
+	sections []string
+}
+
+// newSources creates the configured sources in priority order
+func (bc *BrotherCollector) newSources() []configuredSource {
+	sourceConfigs := bc.config.Printer.Sources
+	if len(sourceConfigs) == 0 {
+		sourceConfigs = []config.SourceConfig{{Name: bc.config.Printer.Source}}
+	}
+
+	sources := make([]configuredSource, 0, len(sourceConfigs))
+
+	for _, sourceConfig := range sourceConfigs {
+		var source printer.Source
+
+		switch sourceConfig.Name {
+		case config.SourceIPP:
+			source = &ippSource{bc: bc}
+		case config.SourceWeb:
+			source = &webSource{bc: bc}
+		case config.SourcePJL:
+			source = &pjlSource{bc: bc}
+		default:
+			source = &snmpSource{bc: bc}
+		}
+
+		sources = append(sources, configuredSource{source: source, sections: sourceConfig.AllowedSections()})
+	}
+
+	return sources
+}
+
+// hasSource reports whether name is one of the configured sources
+func (bc *BrotherCollector) hasSource(name string) bool {
+	return slices.ContainsFunc(bc.sources, func(configured configuredSource) bool {
+		return configured.source.Name() == name
+	})
+}
+
+// collectFromSources queries the sources in order, records the merged
+// snapshot in the store and renders it as metrics. A source is only asked
+// for the sections the sources before it left empty or incomplete, and only
+// adds what they lack, so e.g. a web source after SNMP fills a drum level
+// SNMP could not read. A source that couldn't fill a partial section isn't
+// asked for it again until the gap changes. It returns false when no source
+// could reach the printer.
+func (bc *BrotherCollector) collectFromSources(ctx context.Context, collectorSpan *tracing.CollectorSpan, due func(string) bool) bool {
+	var wanted []string
+
+	for _, section := range printer.Sections {
+		if due(sectionSteps[section].step) {
+			wanted = append(wanted, section)
+		}
+	}
+
+	if len(wanted) == 0 {
+		return true
+	}
+
+	snap := &printer.Snapshot{CollectedAt: time.Now()}
+	reached := false
+
+	var errs []error
+
+	for _, configured := range bc.sources {
+		var allowed []string
+
+		for _, section := range wanted {
+			if slices.Contains(configured.sections, section) {
+				allowed = append(allowed, section)
+			}
+		}
+
+		name := configured.source.Name()
+
+		missing := bc.worthAsking(name, snap, allowed)
+		if len(missing) == 0 {
+			continue
+		}
+
+		gaps := make(map[string]string, len(missing))
+		for _, section := range missing {
+			gaps[section] = snap.Gap(section)
+		}
+
+		start := time.Now()
+
+		fetched, err := configured.source.Fetch(ctx, missing)
+
+		bc.updateSourceHealth(name, time.Since(start), err)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+
+			slog.Error("Failed to fetch from source", "host", bc.config.Printer.Host, "source", name, "error", err)
+
+			if collectorSpan != nil {
+				collectorSpan.RecordError(err, attribute.String("printer.host", bc.config.Printer.Host), attribute.String("source", name))
+			}
+
+			continue
+		}
+
+		reached = true
+
+		snap.Merge(fetched, missing)
+		bc.rememberUnfilled(name, snap, gaps)
+	}
+
+	if !reached {
+		bc.store.Update(bc.config.Printer.Host, bc.config.Printer.Host, nil, snap.CollectedAt, errs)
+
+		bc.metrics.PrinterConnectionStatus.With(bc.labels(prometheus.Labels{
+			"host": bc.config.Printer.Host,
+		})).Set(0)
+
+		bc.handleUnreachable()
+
+		return false
+	}
+
+	bc.metrics.PrinterConnectionStatus.With(bc.labels(prometheus.Labels{
+		"host": bc.config.Printer.Host,
+	})).Set(1)
+
+	// A section filled by a fallback source still counts as refreshed
+	for _, section := range wanted {
+		if snap.Has(section) {
+			bc.markSuccess(sectionSteps[section].step)
+		} else {
+			errs = append(errs, fmt.Errorf("no source provided %s", section))
+		}
+	}
+
+	// Render first, it sets the supply states the store keeps
+	bc.render(snap)
+	bc.store.Update(bc.config.Printer.Host, bc.config.Printer.Host, snap, snap.CollectedAt, errs)
+
+	bc.updateDataAge()
+
+	return true
+}
+
+// worthAsking returns the sections of allowed a source should be asked for:
+// those the snapshot lacks, except partial ones whose gap the source
+// already failed to fill. Sections with no data at all are always asked
+// for.
+func (bc *BrotherCollector) worthAsking(source string, snap *printer.Snapshot, allowed []string) []string {
+	var sections []string
+
+	for _, section := range allowed {
+		gap := snap.Gap(section)
+
+		switch {
+		case gap == "":
+			delete(bc.unfilled[source], section)
+		case gap != "all" && bc.unfilled[source][section] == gap:
+			slog.Debug("Not asking source for a gap it didn't fill", "source", source, "section", section, "gap", gap)
+		default:
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
+
+// rememberUnfilled records the partial sections a source answered without
+// filling anything of their gap, and forgets those it improved
+func (bc *BrotherCollector) rememberUnfilled(source string, snap *printer.Snapshot, gaps map[string]string) {
+	for section, before := range gaps {
+		if before == "all" || snap.Gap(section) != before {
+			delete(bc.unfilled[source], section)
+			continue
+		}
+
+		if bc.unfilled[source] == nil {
+			bc.unfilled[source] = make(map[string]string)
+		}
+
+		bc.unfilled[source][section] = before
+	}
+}
+
+// fillSections runs the parser of each requested section as its
+// collection step
+func (bc *BrotherCollector) fillSections(sections []string, parsers map[string]func() error) {
+	for _, section := range printer.Sections {
+		parse, ok := parsers[section]
+		if !ok || !slices.Contains(sections, section) {
+			continue
+		}
+
+		step := sectionSteps[section]
+		_ = bc.collectStep(step.step, step.operation, parse)
+	}
+}
+
+// updateSourceHealth publishes the outcome of a fetch from a source
+func (bc *BrotherCollector) updateSourceHealth(source string, duration time.Duration, err error) {
+	labels := bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"source": source,
+	})
+
+	bc.metrics.SourceDuration.With(labels).Set(duration.Seconds())
+
+	if err != nil {
+		bc.metrics.SourceUp.With(labels).Set(0)
+		bc.metrics.SourceErrors.With(labels).Inc()
+
+		return
+	}
+
+	bc.metrics.SourceUp.With(labels).Set(1)
+	bc.metrics.SourceLastSuccessTimestamp.With(labels).Set(float64(time.Now().Unix()))
+}
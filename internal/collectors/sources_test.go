@@ -0,0 +1,217 @@
+package collectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/ipp"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/webui"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebUIServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/general/", http.StripPrefix("/general", http.FileServer(http.Dir("../webui/testdata/modern"))))
+
+	return httptest.NewServer(mux)
+}
+
+func newSourcesCollector(t *testing.T, sources []config.SourceConfig, ippURL, webURL string) (*BrotherCollector, *metrics.BrotherRegistry) {
+	t.Helper()
+
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host", Type: "laser", Sources: sources}}
+	m := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, m, nil)
+	bc.ippClient = ipp.NewClient(ippURL, 5*time.Second, false)
+	bc.webClient = webui.NewClient(webURL, "", 5*time.Second, false)
+
+	return bc, m
+}
+
+func TestCollectFromSources_SectionFallback(t *testing.T) {
+	ippServer := newIPPResponder(t, loadIPPFixture(t, "hl-l3270cdw.bin"))
+	defer ippServer.Close()
+
+	webServer := newWebUIServer()
+	defer webServer.Close()
+
+	// IPP has no MAC and no yellow toner percentage; the web UI may only
+	// fill identity and supplies
+	bc, m := newSourcesCollector(t, []config.SourceConfig{
+		{Name: config.SourceIPP},
+		{Name: config.SourceWeb, Sections: []string{printer.SectionIdentity, printer.SectionSupplies}},
+	}, ippServer.URL+"/ipp/print", webServer.URL)
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+
+	host := prometheus.Labels{"host": "test-host"}
+	toner := func(color string) float64 {
+		return testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": color}))
+	}
+
+	// IPP's values win, the web UI adds what IPP lacks
+	assert.InDelta(t, 60, toner("black"), 0)
+	assert.InDelta(t, 25, toner("yellow"), 0)
+	assert.InDelta(t, 5432, testutil.ToFloat64(m.PageCountTotal.With(host)), 0)
+
+	state, _ := bc.Store().Get("test-host")
+	assert.Equal(t, "00:80:92:7A:FB:CE", state.Snapshot.Identity.MAC)
+	assert.Equal(t, "E78096A9N123456", state.Snapshot.Identity.Serial)
+
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceUp.With(prometheus.Labels{"host": "test-host", "source": "ipp"})), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceUp.With(prometheus.Labels{"host": "test-host", "source": "web"})), 0)
+}
+
+func TestCollectFromSources_CompleteSections(t *testing.T) {
+	ippServer := newIPPResponder(t, loadIPPFixture(t, "hl-l3270cdw.bin"))
+	defer ippServer.Close()
+
+	webServer := newWebUIServer()
+	defer webServer.Close()
+
+	// IPP answers the status and trays in full, so the web UI isn't asked
+	bc, m := newSourcesCollector(t, []config.SourceConfig{
+		{Name: config.SourceIPP},
+		{Name: config.SourceWeb, Sections: []string{printer.SectionStatus, printer.SectionTrays}},
+	}, ippServer.URL+"/ipp/print", webServer.URL)
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceUp.With(prometheus.Labels{"host": "test-host", "source": "ipp"})), 0)
+	assert.Equal(t, 1, testutil.CollectAndCount(m.SourceUp))
+}
+
+// fakeSource answers every fetch with a copy of snap and counts the
+// sections it was asked for
+type fakeSource struct {
+	name  string
+	snap  printer.Snapshot
+	asked map[string]int
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Fetch(_ context.Context, sections []string) (*printer.Snapshot, error) {
+	for _, section := range sections {
+		f.asked[section]++
+	}
+
+	snap := f.snap
+
+	return &snap, nil
+}
+
+func TestCollectFromSources_UnfilledGap(t *testing.T) {
+	primary := &fakeSource{name: config.SourceIPP, asked: map[string]int{}, snap: printer.Snapshot{
+		Identity: &printer.Identity{Model: "HL-L3270CDW series", Serial: "E78096A9N123456", Firmware: "1.45"},
+	}}
+	// The fallback knows no MAC either
+	fallback := &fakeSource{name: config.SourcePJL, asked: map[string]int{}, snap: printer.Snapshot{
+		Identity: &printer.Identity{Model: "HL-L3270CDW"},
+	}}
+
+	bc, _ := newSourcesCollector(t, nil, "", "")
+	bc.sources = []configuredSource{
+		{source: primary, sections: []string{printer.SectionIdentity}},
+		{source: fallback, sections: []string{printer.SectionIdentity}},
+	}
+
+	identityDue := func(name string) bool { return name == config.SubsystemInfo }
+
+	for range 3 {
+		assert.True(t, bc.collectFromSources(t.Context(), nil, identityDue))
+	}
+
+	// Asked once for the missing MAC, not again while the gap is the same
+	assert.Equal(t, 3, primary.asked[printer.SectionIdentity])
+	assert.Equal(t, 1, fallback.asked[printer.SectionIdentity])
+
+	// A different gap from the primary is worth asking about again
+	primary.snap.Identity = &printer.Identity{Model: "HL-L3270CDW series"}
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, identityDue))
+	assert.Equal(t, 2, fallback.asked[printer.SectionIdentity])
+
+	// So is the whole section when the primary has nothing
+	primary.snap.Identity = nil
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, identityDue))
+	assert.True(t, bc.collectFromSources(t.Context(), nil, identityDue))
+	assert.Equal(t, 4, fallback.asked[printer.SectionIdentity])
+}
+
+func TestCollectFromSources_SourceDown(t *testing.T) {
+	ippServer := httptest.NewServer(http.NotFoundHandler())
+	defer ippServer.Close()
+
+	webServer := newWebUIServer()
+	defer webServer.Close()
+
+	bc, m := newSourcesCollector(t, []config.SourceConfig{
+		{Name: config.SourceIPP},
+		{Name: config.SourceWeb},
+	}, ippServer.URL+"/ipp/print", webServer.URL)
+
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+
+	host := prometheus.Labels{"host": "test-host"}
+	ippLabels := prometheus.Labels{"host": "test-host", "source": "ipp"}
+	webLabels := prometheus.Labels{"host": "test-host", "source": "web"}
+
+	// The web UI filled in for the failed IPP source
+	assert.InDelta(t, 1, testutil.ToFloat64(m.PrinterConnectionStatus.With(host)), 0)
+	assert.InDelta(t, 50, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "black"})), 0)
+	assert.InDelta(t, 95, testutil.ToFloat64(m.BeltUnitRemainingPercent.With(host)), 0)
+
+	assert.InDelta(t, 0, testutil.ToFloat64(m.SourceUp.With(ippLabels)), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceErrors.With(ippLabels)), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceUp.With(webLabels)), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(m.SourceErrors.With(webLabels)), 0)
+	assert.Positive(t, testutil.ToFloat64(m.SourceLastSuccessTimestamp.With(webLabels)))
+}
+
+func TestCollectFromSources_AllDown(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	bc, m := newSourcesCollector(t, []config.SourceConfig{
+		{Name: config.SourceIPP},
+		{Name: config.SourceWeb},
+	}, server.URL+"/ipp/print", server.URL)
+
+	assert.False(t, bc.collectFromSources(t.Context(), nil, func(string) bool { return true }))
+	assert.InDelta(t, 0, testutil.ToFloat64(m.PrinterConnectionStatus.With(prometheus.Labels{"host": "test-host"})), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(m.SourceErrors.With(prometheus.Labels{"host": "test-host", "source": "web"})), 0)
+}
+
+func TestCollectFromSources_NothingDue(t *testing.T) {
+	bc, m := newSourcesCollector(t, []config.SourceConfig{{Name: config.SourceIPP}}, "http://127.0.0.1:1/ipp/print", "")
+
+	// Only the scanner is due, no source is queried
+	assert.True(t, bc.collectFromSources(t.Context(), nil, func(name string) bool { return name == config.SubsystemScanner }))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.SourceUp))
+}
+
+func TestCollectOnce(t *testing.T) {
+	ippServer := newIPPResponder(t, loadIPPFixture(t, "hl-l3270cdw.bin"))
+	defer ippServer.Close()
+
+	bc, _ := newSourcesCollector(t, []config.SourceConfig{{Name: config.SourceIPP}}, ippServer.URL+"/ipp/print", "")
+
+	state := bc.CollectOnce(t.Context())
+
+	assert.True(t, state.Reachable)
+	assert.Equal(t, "test-host", state.Host)
+	assert.InDelta(t, 5432, state.Snapshot.Counters[printer.CounterTotal], 0)
+	assert.NotEmpty(t, state.Snapshot.Supplies)
+}
@@ -0,0 +1,39 @@
+package collectors
+
+import (
+	"log/slog"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// handleUnreachable applies the configured on_unreachable policy to the
+// values collected before the printer went away
+func (bc *BrotherCollector) handleUnreachable() {
+	switch bc.config.Printer.OnUnreachable {
+	case config.OnUnreachableDrop:
+		slog.Info("Dropping printer metrics while unreachable", "host", bc.config.Printer.Host)
+		bc.metrics.ResetPrinterData()
+	case config.OnUnreachableMarkStale:
+		bc.updateDataAge()
+	}
+}
+
+// updateDataAge publishes when the data of every step that has ever
+// succeeded was refreshed, when the mark_stale policy is configured. The
+// age itself is computed at scrape time.
+func (bc *BrotherCollector) updateDataAge() {
+	if bc.config.Printer.OnUnreachable != config.OnUnreachableMarkStale {
+		return
+	}
+
+	bc.stateMu.Lock()
+	defer bc.stateMu.Unlock()
+
+	for step, last := range bc.lastSuccess {
+		bc.metrics.DataAge.Set(bc.labels(prometheus.Labels{
+			"host": bc.config.Printer.Host,
+			"step": step,
+		}), last)
+	}
+}
@@ -0,0 +1,54 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPolicyCollector(policy string) (*BrotherCollector, *metrics.BrotherRegistry) {
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host", OnUnreachable: policy}}
+	brotherMetrics := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, brotherMetrics, nil)
+	brotherMetrics.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "black"}).Set(42)
+	brotherMetrics.PrinterConnectionStatus.With(prometheus.Labels{"host": "test-host"}).Set(0)
+
+	return bc, brotherMetrics
+}
+
+func TestHandleUnreachable_Keep(t *testing.T) {
+	bc, brotherMetrics := newPolicyCollector(config.OnUnreachableKeep)
+
+	bc.handleUnreachable()
+
+	assert.Equal(t, 1, testutil.CollectAndCount(brotherMetrics.TonerLevel))
+	assert.Equal(t, 0, testutil.CollectAndCount(brotherMetrics.DataAge))
+}
+
+func TestHandleUnreachable_Drop(t *testing.T) {
+	bc, brotherMetrics := newPolicyCollector(config.OnUnreachableDrop)
+
+	bc.handleUnreachable()
+
+	assert.Equal(t, 0, testutil.CollectAndCount(brotherMetrics.TonerLevel))
+	// Connection status is what tells you the printer is gone, so it stays
+	assert.Equal(t, 1, testutil.CollectAndCount(brotherMetrics.PrinterConnectionStatus))
+}
+
+func TestHandleUnreachable_MarkStale(t *testing.T) {
+	bc, brotherMetrics := newPolicyCollector(config.OnUnreachableMarkStale)
+	bc.lastSuccess[config.SubsystemBrother] = time.Now().Add(-5 * time.Minute)
+
+	bc.handleUnreachable()
+
+	assert.Equal(t, 1, testutil.CollectAndCount(brotherMetrics.TonerLevel))
+
+	assert.InDelta(t, 300, testutil.ToFloat64(brotherMetrics.DataAge), 5)
+}
@@ -0,0 +1,131 @@
+package collectors
+
+import (
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SupplyState is the derived alert state of a consumable
+type SupplyState int
+
+const (
+	SupplyStateOK SupplyState = iota
+	SupplyStateWarning
+	SupplyStateCritical
+	SupplyStateEmpty
+)
+
+// supplyStates lists every state, used to clear stale status series
+var supplyStates = []SupplyState{SupplyStateOK, SupplyStateWarning, SupplyStateCritical, SupplyStateEmpty}
+
+// String returns the status label value. Warning is reported as "low" to
+// keep the label values the status metrics have always used.
+func (s SupplyState) String() string {
+	switch s {
+	case SupplyStateWarning:
+		return "low"
+	case SupplyStateCritical:
+		return "critical"
+	case SupplyStateEmpty:
+		return "empty"
+	default:
+		return "ok"
+	}
+}
+
+// Level returns the three-level value published in brother_printer_supply_state
+// (0=ok, 1=warning, 2=critical). An empty supply is critical.
+func (s SupplyState) Level() float64 {
+	switch s {
+	case SupplyStateWarning:
+		return 1
+	case SupplyStateCritical, SupplyStateEmpty:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// classifyLevel maps a percentage level to a state without hysteresis
+func classifyLevel(level float64, t config.Thresholds) SupplyState {
+	switch {
+	case level <= t.Empty:
+		return SupplyStateEmpty
+	case level < t.Critical:
+		return SupplyStateCritical
+	case level < t.Warning:
+		return SupplyStateWarning
+	default:
+		return SupplyStateOK
+	}
+}
+
+// evaluateSupplyState maps a percentage level to a state, applying
+// hysteresis against the previous state: a supply only recovers to a less
+// severe state once its level clears that state's threshold by the
+// hysteresis margin, so a level hovering on a threshold doesn't flap.
+func evaluateSupplyState(prev SupplyState, hasPrev bool, level float64, t config.Thresholds) SupplyState {
+	state := classifyLevel(level, t)
+	if !hasPrev || state >= prev {
+		return state
+	}
+
+	// Recovering: only move as far as the level minus the margin allows
+	damped := classifyLevel(level-t.Hysteresis, t)
+	if damped > prev {
+		damped = prev
+	}
+
+	if damped > state {
+		return damped
+	}
+
+	return state
+}
+
+// updateSupplyState evaluates a consumable level against its configured
+// thresholds, publishes brother_printer_supply_state and, when statusMetric
+// is set, the per-colour status metric. It returns the status label and
+// value (1=ok, 0 otherwise).
+func (bc *BrotherCollector) updateSupplyState(supply, color string, level float64, statusMetric *prometheus.GaugeVec) (status string, statusValue float64) {
+	thresholds := bc.config.Printer.ThresholdsFor(supply, color)
+	key := supply + "/" + color
+
+	bc.stateMu.Lock()
+	prev, hasPrev := bc.supplyStates[key]
+	state := evaluateSupplyState(prev, hasPrev, level, thresholds)
+	bc.supplyStates[key] = state
+	bc.stateMu.Unlock()
+
+	bc.metrics.SupplyState.With(bc.labels(prometheus.Labels{
+		"host":   bc.config.Printer.Host,
+		"supply": supply,
+		"color":  color,
+	})).Set(state.Level())
+
+	status = state.String()
+	if state == SupplyStateOK {
+		statusValue = 1.0
+	}
+
+	if statusMetric != nil {
+		// Drop the series for other states so a supply never reports two
+		for _, other := range supplyStates {
+			if other != state {
+				statusMetric.Delete(bc.labels(prometheus.Labels{
+					"host":   bc.config.Printer.Host,
+					"color":  color,
+					"status": other.String(),
+				}))
+			}
+		}
+
+		statusMetric.With(bc.labels(prometheus.Labels{
+			"host":   bc.config.Printer.Host,
+			"color":  color,
+			"status": status,
+		})).Set(statusValue)
+	}
+
+	return status, statusValue
+}
@@ -0,0 +1,77 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyLevel(t *testing.T) {
+	thresholds := config.Thresholds{Warning: 20, Critical: 5, Empty: 0}
+
+	assert.Equal(t, SupplyStateOK, classifyLevel(50, thresholds))
+	assert.Equal(t, SupplyStateOK, classifyLevel(20, thresholds))
+	assert.Equal(t, SupplyStateWarning, classifyLevel(19, thresholds))
+	assert.Equal(t, SupplyStateCritical, classifyLevel(4, thresholds))
+	// The empty state used to be unreachable behind the "low" check
+	assert.Equal(t, SupplyStateEmpty, classifyLevel(0, thresholds))
+}
+
+func TestEvaluateSupplyState_Hysteresis(t *testing.T) {
+	thresholds := config.Thresholds{Warning: 20, Critical: 5, Empty: 0, Hysteresis: 3}
+
+	// First reading has nothing to be sticky against
+	assert.Equal(t, SupplyStateWarning, evaluateSupplyState(SupplyStateOK, false, 19, thresholds))
+
+	// Hovering just above the threshold stays in warning
+	assert.Equal(t, SupplyStateWarning, evaluateSupplyState(SupplyStateWarning, true, 21, thresholds))
+
+	// Clearing the threshold by the margin recovers
+	assert.Equal(t, SupplyStateOK, evaluateSupplyState(SupplyStateWarning, true, 23, thresholds))
+
+	// Getting worse is never delayed
+	assert.Equal(t, SupplyStateCritical, evaluateSupplyState(SupplyStateWarning, true, 4, thresholds))
+
+	// A replaced cartridge recovers straight to ok
+	assert.Equal(t, SupplyStateOK, evaluateSupplyState(SupplyStateEmpty, true, 100, thresholds))
+
+	// Partial recovery from critical only goes as far as the margin allows
+	assert.Equal(t, SupplyStateCritical, evaluateSupplyState(SupplyStateCritical, true, 6, thresholds))
+	assert.Equal(t, SupplyStateWarning, evaluateSupplyState(SupplyStateCritical, true, 9, thresholds))
+}
+
+func TestSupplyState_Level(t *testing.T) {
+	assert.InDelta(t, 0, SupplyStateOK.Level(), 0)
+	assert.InDelta(t, 1, SupplyStateWarning.Level(), 0)
+	assert.InDelta(t, 2, SupplyStateCritical.Level(), 0)
+	assert.InDelta(t, 2, SupplyStateEmpty.Level(), 0)
+}
+
+func TestThresholdsFor(t *testing.T) {
+	warning := 20.0
+	drumWarning := 5.0
+	cyanCritical := 10.0
+
+	printer := config.PrinterConfig{
+		Thresholds: map[string]config.ThresholdConfig{
+			"toner":      {Warning: &warning},
+			"drum":       {Warning: &drumWarning},
+			"toner_cyan": {Critical: &cyanCritical},
+		},
+	}
+
+	toner := printer.ThresholdsFor("toner", "black")
+	assert.InDelta(t, 20, toner.Warning, 0)
+	assert.InDelta(t, config.DefaultCriticalThreshold, toner.Critical, 0)
+
+	cyan := printer.ThresholdsFor("toner", "cyan")
+	assert.InDelta(t, 20, cyan.Warning, 0)
+	assert.InDelta(t, 10, cyan.Critical, 0)
+
+	drum := printer.ThresholdsFor("drum", "black")
+	assert.InDelta(t, 5, drum.Warning, 0)
+
+	fuser := printer.ThresholdsFor("fuser_unit", "")
+	assert.InDelta(t, config.DefaultWarningThreshold, fuser.Warning, 0)
+}
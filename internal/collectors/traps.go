@@ -0,0 +1,480 @@
+package collectors
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/gosnmp/gosnmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Trap OIDs
+const (
+	// OIDSnmpTrapOID is the varbind holding the trap OID of a v2c/v3 trap
+	OIDSnmpTrapOID = "1.3.6.1.6.3.1.1.4.1.0"
+	// OIDPrinterV2Alert is the Printer-MIB alert trap, printerV1Alert
+	// specific trap 1 in v1
+	OIDPrinterV2Alert = "1.3.6.1.2.1.43.18.2.0.1"
+	// OIDPrtAlertEntry is the alert table entry the alert trap varbinds
+	// are columns of
+	OIDPrtAlertEntry = "1.3.6.1.2.1.43.18.1.1"
+
+	oidSnmpTraps = "1.3.6.1.6.3.1.1.5"
+)
+
+// prtAlertEntry columns carried by alert traps
+const (
+	prtAlertSeverityLevel = 2
+	prtAlertCode          = 7
+	prtAlertDescription   = 8
+)
+
+// prtAlertSeverityLevel values
+const (
+	alertSeverityCritical           = 3
+	alertSeverityWarning            = 4
+	alertSeverityWarningBinaryEvent = 5
+)
+
+// trapAlertCodes maps PrtAlertCodeTC values to alert codes shared with the
+// other sources
+var trapAlertCodes = map[int]string{
+	3:    "door_open", // coverOpen
+	5:    "door_open", // interlockOpen
+	501:  "door_open",
+	8:    "jammed",
+	22:   "offline", // subunitOffline
+	801:  "input_tray_missing",
+	807:  "low_paper",
+	808:  "no_paper",
+	901:  "output_tray_missing",
+	902:  "output_near_full",
+	903:  "output_full",
+	1101: "no_toner",
+	1102: "no_toner", // markerInkEmpty
+	1104: "low_toner",
+	1105: "low_toner", // markerInkAlmostEmpty
+}
+
+// trapClearedCodes maps the PrtAlertCodeTC values reporting the end of a
+// condition to the alert they clear
+var trapClearedCodes = map[int]string{
+	4:   "door_open", // coverClosed
+	6:   "door_open", // interlockClosed
+	502: "door_open", // doorClosed
+}
+
+// alertReadyToPrint is printerReadyToPrint, which clears every alert
+const alertReadyToPrint = 507
+
+// trapSubsystems are collected after a trap, as far as they are enabled
+var trapSubsystems = []string{config.SubsystemStatus, config.SubsystemBrother, config.SubsystemPaperTray}
+
+// snmpAuthProtocols and snmpPrivProtocols map the configured SNMPv3
+// protocols to gosnmp's
+var (
+	snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+		"MD5":    gosnmp.MD5,
+		"SHA":    gosnmp.SHA,
+		"SHA224": gosnmp.SHA224,
+		"SHA256": gosnmp.SHA256,
+		"SHA384": gosnmp.SHA384,
+		"SHA512": gosnmp.SHA512,
+	}
+	snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+		"DES":     gosnmp.DES,
+		"AES":     gosnmp.AES,
+		"AES192":  gosnmp.AES192,
+		"AES256":  gosnmp.AES256,
+		"AES192C": gosnmp.AES192C,
+		"AES256C": gosnmp.AES256C,
+	}
+)
+
+// resolveInterval limits how often an unknown trap sender triggers a new
+// lookup of the printer addresses
+const resolveInterval = time.Minute
+
+// printerTrap is a trap accepted from the printer. alert is nil for Brother
+// enterprise traps, which only trigger a collection.
+type printerTrap struct {
+	received time.Time
+	alert    *trapAlert
+}
+
+// trapAlert holds the prtAlertEntry columns of a Printer-MIB alert trap
+type trapAlert struct {
+	severity    int
+	code        int
+	description string
+}
+
+// trapReceiver listens for SNMP traps and hands the printer's to the run
+// loop
+type trapReceiver struct {
+	bc       *BrotherCollector
+	listener *gosnmp.TrapListener
+
+	mu       sync.Mutex
+	addrs    []netip.Addr
+	resolved time.Time
+}
+
+// startTraps starts the trap listener when enabled. Errors are logged, the
+// exporter keeps polling without traps.
+func (bc *BrotherCollector) startTraps(ctx context.Context) {
+	if !bc.config.Traps.Enabled {
+		return
+	}
+
+	params, err := trapParams(bc.config.Traps)
+	if err != nil {
+		slog.Error("Invalid SNMP trap settings", "error", err)
+		return
+	}
+
+	receiver := &trapReceiver{bc: bc, listener: gosnmp.NewTrapListener()}
+	receiver.listener.Params = params
+	receiver.listener.OnNewTrap = func(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+		receiver.handle(ctx, packet, addr)
+	}
+
+	errs := make(chan error, 1)
+
+	go func() { errs <- receiver.listener.Listen(bc.config.Traps.Listen) }()
+
+	// Wait for the socket so Stop can't race Listen
+	select {
+	case <-receiver.listener.Listening():
+	case err := <-errs:
+		slog.Error("Failed to start SNMP trap listener", "listen", bc.config.Traps.Listen, "error", err)
+		return
+	}
+
+	receiver.refresh(ctx)
+
+	bc.trapReceiver = receiver
+
+	slog.Info("Listening for SNMP traps", "listen", bc.config.Traps.Listen)
+}
+
+// stopTraps closes the trap listener
+func (bc *BrotherCollector) stopTraps() {
+	if bc.trapReceiver != nil {
+		bc.trapReceiver.listener.Close()
+	}
+}
+
+// trapParams builds the listener parameters: v3 traps are authenticated
+// against the configured user, v1 and v2c traps need none
+func trapParams(cfg config.TrapsConfig) (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: cfg.Community}
+
+	v3 := cfg.V3
+	if v3.Username == "" {
+		return params, nil
+	}
+
+	engineID, err := hex.DecodeString(v3.EngineID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid engine ID: %w", err)
+	}
+
+	flags, usm := usmParameters(v3.SNMPv3Config)
+	usm.AuthoritativeEngineID = string(engineID)
+
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.MsgFlags = flags
+	params.SecurityParameters = usm
+
+	return params, nil
+}
+
+// usmParameters returns the message flags and security parameters of an
+// SNMPv3 user
+func usmParameters(v3 config.SNMPv3Config) (gosnmp.SnmpV3MsgFlags, *gosnmp.UsmSecurityParameters) {
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:               v3.Username,
+		AuthenticationProtocol: gosnmp.NoAuth,
+		PrivacyProtocol:        gosnmp.NoPriv,
+	}
+	flags := gosnmp.NoAuthNoPriv
+
+	if v3.AuthProtocol != "" {
+		usm.AuthenticationProtocol = snmpAuthProtocols[v3.AuthProtocol]
+		usm.AuthenticationPassphrase = v3.AuthPassphrase
+		flags = gosnmp.AuthNoPriv
+	}
+
+	if v3.PrivProtocol != "" {
+		usm.PrivacyProtocol = snmpPrivProtocols[v3.PrivProtocol]
+		usm.PrivacyPassphrase = v3.PrivPassphrase
+		flags = gosnmp.AuthPriv
+	}
+
+	return flags, usm
+}
+
+// handle checks a trap's sender and community, decodes it and queues it
+// for the run loop
+func (r *trapReceiver) handle(ctx context.Context, packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	result := r.accept(ctx, packet, addr)
+
+	r.bc.metrics.TrapsReceived.With(r.bc.labels(prometheus.Labels{
+		"host":   r.bc.config.Printer.Host,
+		"result": result,
+	})).Inc()
+}
+
+func (r *trapReceiver) accept(ctx context.Context, packet *gosnmp.SnmpPacket, addr *net.UDPAddr) string {
+	if !r.fromPrinter(ctx, addr) {
+		slog.Debug("Ignoring SNMP trap from unknown source", "source", addr.IP)
+		return "unknown_source"
+	}
+
+	community := r.bc.config.Traps.Community
+	if packet.Version != gosnmp.Version3 && community != "" && packet.Community != community {
+		slog.Warn("Ignoring SNMP trap with wrong community", "source", addr.IP)
+		return "bad_community"
+	}
+
+	trap, ok := decodeTrap(packet)
+	if !ok {
+		slog.Debug("Ignoring SNMP trap", "source", addr.IP, "trap", trapOID(packet))
+		return "ignored"
+	}
+
+	trap.received = time.Now()
+
+	select {
+	case r.bc.traps <- trap:
+	default:
+		// A collection is already on its way
+		slog.Debug("Dropping SNMP trap, queue full", "source", addr.IP)
+	}
+
+	return "accepted"
+}
+
+// fromPrinter reports whether addr is the printer or a configured source,
+// looking the addresses up again on a miss at most once per
+// resolveInterval
+func (r *trapReceiver) fromPrinter(ctx context.Context, addr *net.UDPAddr) bool {
+	ip, ok := netip.AddrFromSlice(addr.IP)
+	if !ok {
+		return false
+	}
+
+	ip = ip.Unmap()
+
+	r.mu.Lock()
+	known := slices.Contains(r.addrs, ip)
+	stale := time.Since(r.resolved) >= resolveInterval
+	r.mu.Unlock()
+
+	if known || !stale {
+		return known
+	}
+
+	return slices.Contains(r.refresh(ctx), ip)
+}
+
+// refresh resolves the printer host and the configured sources
+func (r *trapReceiver) refresh(ctx context.Context) []netip.Addr {
+	hosts := append([]string{r.bc.config.Printer.Host}, r.bc.config.Traps.Sources...)
+
+	var addrs []netip.Addr
+
+	for _, host := range hosts {
+		if ip, err := netip.ParseAddr(host); err == nil {
+			addrs = append(addrs, ip.Unmap())
+			continue
+		}
+
+		resolved, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			slog.Warn("Failed to resolve SNMP trap source", "host", host, "error", err)
+			continue
+		}
+
+		for _, ip := range resolved {
+			addrs = append(addrs, ip.Unmap())
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addrs = addrs
+	r.resolved = time.Now()
+
+	return addrs
+}
+
+// trapOID returns the trap OID of a packet without a leading dot. v1 traps
+// are translated as in RFC 3584: enterprise.0.specific for enterprise
+// specific traps, snmpTraps.(generic+1) for the generic ones.
+func trapOID(packet *gosnmp.SnmpPacket) string {
+	if packet.Version == gosnmp.Version1 {
+		if packet.GenericTrap == 6 {
+			return strings.TrimPrefix(packet.Enterprise, ".") + ".0." + strconv.Itoa(packet.SpecificTrap)
+		}
+
+		return oidSnmpTraps + "." + strconv.Itoa(packet.GenericTrap+1)
+	}
+
+	for _, variable := range packet.Variables {
+		if strings.TrimPrefix(variable.Name, ".") == OIDSnmpTrapOID {
+			if oid, ok := variable.Value.(string); ok {
+				return strings.TrimPrefix(oid, ".")
+			}
+		}
+	}
+
+	return ""
+}
+
+// decodeTrap decodes a Printer-MIB alert or Brother enterprise trap, or
+// returns false for any other
+func decodeTrap(packet *gosnmp.SnmpPacket) (printerTrap, bool) {
+	oid := trapOID(packet)
+
+	switch {
+	case oid == OIDPrinterV2Alert:
+		return printerTrap{alert: decodeTrapAlert(packet.Variables)}, true
+	case strings.HasPrefix(oid, OIDBrotherBase+"."):
+		return printerTrap{}, true
+	default:
+		return printerTrap{}, false
+	}
+}
+
+// decodeTrapAlert reads the prtAlertEntry columns from the varbinds of an
+// alert trap
+func decodeTrapAlert(variables []gosnmp.SnmpPDU) *trapAlert {
+	alert := &trapAlert{}
+
+	for _, variable := range variables {
+		column, ok := strings.CutPrefix(strings.TrimPrefix(variable.Name, "."), OIDPrtAlertEntry+".")
+		if !ok {
+			continue
+		}
+
+		column, _, _ = strings.Cut(column, ".")
+
+		switch column {
+		case strconv.Itoa(prtAlertSeverityLevel):
+			alert.severity = int(gosnmp.ToBigInt(variable.Value).Int64())
+		case strconv.Itoa(prtAlertCode):
+			alert.code = int(gosnmp.ToBigInt(variable.Value).Int64())
+		case strconv.Itoa(prtAlertDescription):
+			if description, ok := variable.Value.([]byte); ok {
+				alert.description = strings.TrimSpace(string(description))
+			}
+		}
+	}
+
+	return alert
+}
+
+// applyTrap updates the status and alerts from an alert trap, ahead of the
+// collection it triggers
+func (bc *BrotherCollector) applyTrap(trap printerTrap) {
+	if trap.alert == nil {
+		return
+	}
+
+	host := bc.config.Printer.Host
+	status := printer.Status{State: "unknown"}
+
+	var alerts []printer.Alert
+
+	if state, ok := bc.store.Get(host); ok && state.Snapshot.Status != nil {
+		status = *state.Snapshot.Status
+		alerts = state.Snapshot.Alerts
+	}
+
+	status, alerts, changed := trapStatus(status, alerts, trap.alert)
+	if !changed {
+		return
+	}
+
+	snap := &printer.Snapshot{CollectedAt: trap.received, Status: &status, Alerts: alerts}
+
+	bc.render(snap)
+	bc.store.Patch(host, host, snap)
+}
+
+// trapStatus applies an alert trap to the current status and alerts, and
+// reports whether anything changed
+func trapStatus(status printer.Status, alerts []printer.Alert, alert *trapAlert) (printer.Status, []printer.Alert, bool) {
+	if alert.code == alertReadyToPrint {
+		return printer.Status{State: "ready", Message: alert.description}, nil, true
+	}
+
+	if code, ok := trapClearedCodes[alert.code]; ok {
+		remaining := slices.DeleteFunc(slices.Clone(alerts), func(a printer.Alert) bool { return a.Code == code })
+
+		return status, remaining, len(remaining) != len(alerts)
+	}
+
+	var severity string
+
+	switch alert.severity {
+	case alertSeverityCritical:
+		severity = printer.SeverityCritical
+	case alertSeverityWarning:
+		severity = printer.SeverityWarning
+	case alertSeverityWarningBinaryEvent:
+		// Events such as power up don't persist, the collection catches
+		// whatever they changed
+		return status, alerts, false
+	default:
+		return status, alerts, false
+	}
+
+	code, ok := trapAlertCodes[alert.code]
+	if !ok {
+		code = "other"
+	}
+
+	if slices.ContainsFunc(alerts, func(a printer.Alert) bool { return a.Code == code && a.Severity == severity }) {
+		return status, alerts, false
+	}
+
+	// A trap for a known condition replaces it, e.g. low toner turning
+	// into no toner keeps its own code but a code can change severity
+	alerts = slices.DeleteFunc(slices.Clone(alerts), func(a printer.Alert) bool { return a.Code == code })
+	alerts = append(alerts, printer.Alert{Code: code, Severity: severity, Message: alert.description})
+
+	if severity == printer.SeverityCritical {
+		status = printer.Status{State: "stopped", Message: alert.description}
+	}
+
+	return status, alerts, true
+}
+
+// trapCollection returns the enabled subsystems a trap triggers
+func (bc *BrotherCollector) trapCollection() []string {
+	var subsystems []string
+
+	for _, name := range trapSubsystems {
+		if bc.config.SubsystemEnabled(name) {
+			subsystems = append(subsystems, name)
+		}
+	}
+
+	return subsystems
+}
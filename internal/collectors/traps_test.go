@@ -0,0 +1,248 @@
+package collectors
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/gosnmp/gosnmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alertTrapVariables are the varbinds of a Printer-MIB alert trap
+func alertTrapVariables(severity, code int, description string) []gosnmp.SnmpPDU {
+	return []gosnmp.SnmpPDU{
+		{Name: OIDPrtAlertEntry + ".2.1.12", Type: gosnmp.Integer, Value: severity},
+		{Name: OIDPrtAlertEntry + ".7.1.12", Type: gosnmp.Integer, Value: code},
+		{Name: OIDPrtAlertEntry + ".8.1.12", Type: gosnmp.OctetString, Value: description},
+	}
+}
+
+func newTrapCollector(t *testing.T, traps config.TrapsConfig) (*BrotherCollector, *metrics.BrotherRegistry, int) {
+	t.Helper()
+
+	// Find a free port for the listener
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, conn.Close())
+
+	traps.Enabled = true
+	traps.Listen = net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "127.0.0.1", Type: "laser"}, Traps: traps}
+	m := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, m, nil)
+	bc.startTraps(t.Context())
+	require.NotNil(t, bc.trapReceiver)
+	t.Cleanup(bc.stopTraps)
+
+	return bc, m, port
+}
+
+func sendTrap(t *testing.T, sender *gosnmp.GoSNMP, trap gosnmp.SnmpTrap) {
+	t.Helper()
+
+	sender.Timeout = time.Second
+
+	require.NoError(t, sender.Connect())
+	defer func() { _ = sender.Conn.Close() }()
+
+	_, err := sender.SendTrap(trap)
+	require.NoError(t, err)
+}
+
+func receiveTrap(t *testing.T, bc *BrotherCollector) printerTrap {
+	t.Helper()
+
+	select {
+	case trap := <-bc.traps:
+		return trap
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trap received")
+		return printerTrap{}
+	}
+}
+
+func trapCount(m *metrics.BrotherRegistry, result string) float64 {
+	return testutil.ToFloat64(m.TrapsReceived.With(prometheus.Labels{"host": "127.0.0.1", "result": result}))
+}
+
+func TestTrapListenerV2c(t *testing.T) {
+	bc, m, port := newTrapCollector(t, config.TrapsConfig{Community: "secret"})
+
+	bc.store.Update("127.0.0.1", "127.0.0.1", &printer.Snapshot{
+		CollectedAt: time.Now(),
+		Status:      &printer.Status{State: "ready"},
+	}, time.Now(), nil)
+
+	sender := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: uint16(port), Version: gosnmp.Version2c, Community: "secret"}
+
+	// A jam, then a trap from another MIB and one with the wrong community
+	sendTrap(t, sender, gosnmp.SnmpTrap{Variables: append([]gosnmp.SnmpPDU{
+		{Name: OIDSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: "." + OIDPrinterV2Alert},
+	}, alertTrapVariables(alertSeverityCritical, 8, "Paper Jam Tray 1")...)})
+
+	trap := receiveTrap(t, bc)
+	require.NotNil(t, trap.alert)
+	assert.Equal(t, trapAlert{severity: alertSeverityCritical, code: 8, description: "Paper Jam Tray 1"}, *trap.alert)
+
+	sendTrap(t, sender, gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{
+		{Name: OIDSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+	}})
+
+	wrong := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: uint16(port), Version: gosnmp.Version2c, Community: "public"}
+	sendTrap(t, wrong, gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{
+		{Name: OIDSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: "." + OIDPrinterV2Alert},
+	}})
+
+	assert.Eventually(t, func() bool {
+		return trapCount(m, "ignored") == 1 && trapCount(m, "bad_community") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1.0, trapCount(m, "accepted"))
+
+	// Applying the trap updates the status and alert metrics and the store
+	bc.applyTrap(trap)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Alert.With(prometheus.Labels{"host": "127.0.0.1", "code": "jammed", "severity": "critical"})))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.PrinterStatus.With(prometheus.Labels{"host": "127.0.0.1", "status": "stopped"})))
+
+	state, ok := bc.store.Get("127.0.0.1")
+	require.True(t, ok)
+	assert.Equal(t, printer.Status{State: "stopped", Message: "Paper Jam Tray 1"}, *state.Snapshot.Status)
+	assert.Equal(t, []printer.Alert{{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam Tray 1"}}, state.Snapshot.Alerts)
+}
+
+func TestTrapListenerV1(t *testing.T) {
+	bc, m, port := newTrapCollector(t, config.TrapsConfig{})
+
+	sender := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: uint16(port), Version: gosnmp.Version1, Community: "public"}
+
+	// printerV1Alert specific trap 1, then a Brother enterprise trap
+	sendTrap(t, sender, gosnmp.SnmpTrap{
+		Enterprise:   ".1.3.6.1.2.1.43.18.2",
+		AgentAddress: "127.0.0.1",
+		GenericTrap:  6,
+		SpecificTrap: 1,
+		Variables:    alertTrapVariables(alertSeverityWarning, 1104, "Toner Low"),
+	})
+
+	trap := receiveTrap(t, bc)
+	require.NotNil(t, trap.alert)
+	assert.Equal(t, 1104, trap.alert.code)
+
+	sendTrap(t, sender, gosnmp.SnmpTrap{
+		Enterprise:   ".1.3.6.1.4.1.2435.2.3.9.1",
+		AgentAddress: "127.0.0.1",
+		GenericTrap:  6,
+		SpecificTrap: 3,
+		Variables:    []gosnmp.SnmpPDU{{Name: ".1.3.6.1.4.1.2435.2.3.9.1.1.1.0", Type: gosnmp.Integer, Value: 1}},
+	})
+
+	trap = receiveTrap(t, bc)
+	assert.Nil(t, trap.alert)
+	assert.Equal(t, 2.0, trapCount(m, "accepted"))
+}
+
+func TestTrapListenerV3(t *testing.T) {
+	engineID := "8000000001020304"
+
+	bc, m, port := newTrapCollector(t, config.TrapsConfig{V3: config.TrapV3Config{
+		SNMPv3Config: config.SNMPv3Config{
+			Username:       "exporter",
+			AuthProtocol:   "SHA",
+			AuthPassphrase: "authpassword",
+			PrivProtocol:   "AES",
+			PrivPassphrase: "privpassword",
+		},
+		EngineID: engineID,
+	}})
+
+	sender := &gosnmp.GoSNMP{
+		Target:        "127.0.0.1",
+		Port:          uint16(port),
+		Version:       gosnmp.Version3,
+		SecurityModel: gosnmp.UserSecurityModel,
+		MsgFlags:      gosnmp.AuthPriv,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			UserName:                 "exporter",
+			AuthoritativeEngineID:    "\x80\x00\x00\x00\x01\x02\x03\x04",
+			AuthenticationProtocol:   gosnmp.SHA,
+			AuthenticationPassphrase: "authpassword",
+			PrivacyProtocol:          gosnmp.AES,
+			PrivacyPassphrase:        "privpassword",
+		},
+	}
+
+	sendTrap(t, sender, gosnmp.SnmpTrap{Variables: append([]gosnmp.SnmpPDU{
+		{Name: OIDSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: "." + OIDPrinterV2Alert},
+	}, alertTrapVariables(alertSeverityCritical, 808, "No Paper")...)})
+
+	trap := receiveTrap(t, bc)
+	require.NotNil(t, trap.alert)
+	assert.Equal(t, 808, trap.alert.code)
+	assert.Equal(t, 1.0, trapCount(m, "accepted"))
+}
+
+func TestTrapFromPrinter(t *testing.T) {
+	cfg := &config.Config{
+		Printer: config.PrinterConfig{Host: "10.0.0.5"},
+		Traps:   config.TrapsConfig{Sources: []string{"10.0.1.5"}},
+	}
+
+	receiver := &trapReceiver{bc: NewBrotherCollector(cfg, nil, nil)}
+	receiver.refresh(t.Context())
+
+	assert.True(t, receiver.fromPrinter(t.Context(), &net.UDPAddr{IP: net.ParseIP("10.0.0.5")}))
+	assert.True(t, receiver.fromPrinter(t.Context(), &net.UDPAddr{IP: net.ParseIP("::ffff:10.0.1.5")}))
+	assert.False(t, receiver.fromPrinter(t.Context(), &net.UDPAddr{IP: net.ParseIP("10.0.0.6")}))
+}
+
+func TestTrapStatus(t *testing.T) {
+	ready := printer.Status{State: "ready"}
+	lowToner := printer.Alert{Code: "low_toner", Severity: printer.SeverityWarning}
+
+	// A warning adds an alert but keeps the state
+	status, alerts, changed := trapStatus(ready, nil, &trapAlert{severity: alertSeverityWarning, code: 1104})
+	assert.True(t, changed)
+	assert.Equal(t, ready, status)
+	assert.Equal(t, []printer.Alert{lowToner}, alerts)
+
+	// The same alert again changes nothing
+	_, _, changed = trapStatus(ready, alerts, &trapAlert{severity: alertSeverityWarning, code: 1104})
+	assert.False(t, changed)
+
+	// An open door stops the printer, closing it clears the alert
+	status, alerts, changed = trapStatus(ready, alerts, &trapAlert{severity: alertSeverityCritical, code: 501, description: "Cover is Open"})
+	assert.True(t, changed)
+	assert.Equal(t, printer.Status{State: "stopped", Message: "Cover is Open"}, status)
+	assert.Len(t, alerts, 2)
+
+	status, alerts, changed = trapStatus(status, alerts, &trapAlert{severity: alertSeverityWarningBinaryEvent, code: 502})
+	assert.True(t, changed)
+	assert.Equal(t, "stopped", status.State)
+	assert.Equal(t, []printer.Alert{lowToner}, alerts)
+
+	// Unknown codes are kept as other, binary events are skipped
+	_, alerts, _ = trapStatus(ready, nil, &trapAlert{severity: alertSeverityWarning, code: 1501})
+	assert.Equal(t, []printer.Alert{{Code: "other", Severity: printer.SeverityWarning}}, alerts)
+
+	_, _, changed = trapStatus(ready, nil, &trapAlert{severity: alertSeverityWarningBinaryEvent, code: 503})
+	assert.False(t, changed)
+
+	// Ready to print clears everything
+	status, alerts, changed = trapStatus(status, []printer.Alert{lowToner}, &trapAlert{severity: alertSeverityWarningBinaryEvent, code: alertReadyToPrint})
+	assert.True(t, changed)
+	assert.Equal(t, "ready", status.State)
+	assert.Empty(t, alerts)
+}
@@ -0,0 +1,163 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/webui"
+)
+
+// webSource scrapes the printer's embedded web UI. Uptime, paper trays and
+// maintenance part page counts aren't shown there.
+type webSource struct {
+	bc *BrotherCollector
+}
+
+func (s *webSource) Name() string { return config.SourceWeb }
+
+// getWebClient returns the web UI client, creating it on first use
+func (bc *BrotherCollector) getWebClient() *webui.Client {
+	bc.clientsMu.Lock()
+	defer bc.clientsMu.Unlock()
+
+	if bc.webClient == nil {
+		bc.webClient = webui.NewClient(bc.config.Printer.WebURL(), bc.config.Printer.Web.Password,
+			bc.config.Printer.Web.Timeout.Duration, bc.config.Printer.Web.InsecureSkipVerify)
+	}
+
+	return bc.webClient
+}
+
+func (s *webSource) Fetch(ctx context.Context, sections []string) (*printer.Snapshot, error) {
+	bc := s.bc
+	client := bc.getWebClient()
+
+	var data *webui.Data
+
+	err := bc.collectStep("web_request", "web", func() error {
+		var err error
+
+		data, err = client.Fetch(ctx)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("Scraped web UI", "host", bc.config.Printer.Host, "generation", data.Generation)
+
+	snap := &printer.Snapshot{}
+
+	bc.fillSections(sections, map[string]func() error{
+		printer.SectionIdentity: func() error { return applyWebInfo(data, snap) },
+		printer.SectionStatus:   func() error { return bc.applyWebStatus(data, snap) },
+		printer.SectionSupplies: func() error { return applyWebSupplies(data, snap) },
+		printer.SectionCounters: func() error { return applyWebCounters(data, snap) },
+	})
+
+	return snap, nil
+}
+
+// applyWebInfo reads the identity from the information page
+func applyWebInfo(data *webui.Data, snap *printer.Snapshot) error {
+	if data.Model == "" {
+		return fmt.Errorf("no model name on the web UI information page")
+	}
+
+	snap.Identity = &printer.Identity{
+		Model:    data.Model,
+		Serial:   data.Serial,
+		Firmware: data.Firmware,
+		MAC:      data.MAC,
+		Name:     data.NodeName,
+		Location: data.Location,
+		Contact:  data.Contact,
+	}
+
+	return nil
+}
+
+// applyWebStatus maps the device status message to the printer status
+func (bc *BrotherCollector) applyWebStatus(data *webui.Data, snap *printer.Snapshot) error {
+	if data.Status == "" {
+		return fmt.Errorf("no device status on the web UI status page")
+	}
+
+	snap.Status = &printer.Status{State: webStatus(data.Status), Message: data.Status}
+
+	if alert, ok := webAlert(data.Status); ok {
+		snap.Alerts = []printer.Alert{alert}
+	}
+
+	slog.Debug("Web UI device status", "host", bc.config.Printer.Host, "message", data.Status)
+
+	return nil
+}
+
+// applyWebSupplies reads the toner, drum and maintenance part levels
+func applyWebSupplies(data *webui.Data, snap *printer.Snapshot) error {
+	if len(data.Toner) == 0 && len(data.Drum) == 0 && len(data.Parts) == 0 {
+		return fmt.Errorf("no consumable levels on the web UI")
+	}
+
+	for _, color := range slices.Sorted(maps.Keys(data.Toner)) {
+		snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "toner", Color: color, Percent: data.Toner[color]})
+	}
+
+	for _, color := range slices.Sorted(maps.Keys(data.Drum)) {
+		snap.Supplies = append(snap.Supplies, printer.Supply{Kind: "drum", Color: color, Percent: data.Drum[color]})
+	}
+
+	for _, part := range slices.Sorted(maps.Keys(data.Parts)) {
+		snap.Supplies = append(snap.Supplies, printer.Supply{Kind: part, Percent: data.Parts[part]})
+	}
+
+	return nil
+}
+
+// applyWebCounters reads the page counters from the information page
+func applyWebCounters(data *webui.Data, snap *printer.Snapshot) error {
+	total, ok := data.Counters["total"]
+	if !ok {
+		return fmt.Errorf("no page counter on the web UI information page")
+	}
+
+	snap.SetCounter(printer.CounterTotal, total)
+
+	if black, ok := data.Counters["black"]; ok {
+		snap.SetCounter(printer.CounterBlack, black)
+	}
+
+	if color, ok := data.Counters["color"]; ok {
+		snap.SetCounter(printer.CounterColor, color)
+	}
+
+	return nil
+}
+
+// webStatus maps a web UI status message to a printer status label
+func webStatus(message string) string {
+	message = strings.ToLower(message)
+
+	switch {
+	case message == "ready", message == "sleep", message == "deep sleep",
+		strings.HasPrefix(message, "toner low"), strings.HasPrefix(message, "replace toner soon"):
+		return "ready"
+	case strings.Contains(message, "printing"):
+		return "printing"
+	case strings.Contains(message, "warming up"), strings.Contains(message, "please wait"), strings.Contains(message, "cooling down"):
+		return "warmup"
+	case strings.Contains(message, "jam"), strings.Contains(message, "open"), strings.Contains(message, "error"),
+		strings.Contains(message, "no paper"), strings.Contains(message, "replace"), strings.Contains(message, "no toner"):
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
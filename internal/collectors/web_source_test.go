@@ -0,0 +1,66 @@
+package collectors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/webui"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectFromWeb(t *testing.T) {
+	// Serve the saved web UI pages at /general/
+	mux := http.NewServeMux()
+	mux.Handle("/general/", http.StripPrefix("/general", http.FileServer(http.Dir("../webui/testdata/modern"))))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := &config.Config{Printer: config.PrinterConfig{Host: "test-host", Type: "laser", Source: config.SourceWeb}}
+	m := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"))
+
+	bc := NewBrotherCollector(cfg, m, nil)
+	bc.webClient = webui.NewClient(server.URL, "", 5*time.Second, false)
+
+	ok := bc.collectFromSources(t.Context(), nil, func(string) bool { return true })
+	assert.True(t, ok)
+
+	host := prometheus.Labels{"host": "test-host"}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PrinterConnectionStatus.With(host)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PrinterStatus.With(prometheus.Labels{"host": "test-host", "status": "ready"})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.PrinterInfo.With(prometheus.Labels{
+		"host": "test-host", "model": "HL-L3270CDW", "serial": "E78096A9N123456",
+		"firmware": "ZC", "type": "laser", "mac": "00:80:92:7A:FB:CE",
+	})))
+	assert.Equal(t, 50.0, testutil.ToFloat64(m.TonerLevel.With(prometheus.Labels{"host": "test-host", "color": "black"})))
+	assert.Equal(t, 91.0, testutil.ToFloat64(m.DrumLevel.With(prometheus.Labels{"host": "test-host", "color": "black"})))
+	assert.Equal(t, 95.0, testutil.ToFloat64(m.BeltUnitRemainingPercent.With(host)))
+	assert.Equal(t, 99.0, testutil.ToFloat64(m.PaperFeedingKitRemainingPercent.With(host)))
+	assert.Equal(t, 5432.0, testutil.ToFloat64(m.PageCountTotal.With(host)))
+}
+
+func TestWebStatus(t *testing.T) {
+	tests := map[string]string{
+		"Ready":               "ready",
+		"Sleep":               "ready",
+		"Toner Low":           "ready",
+		"Printing":            "printing",
+		"Warming Up":          "warmup",
+		"Jam Tray 1":          "stopped",
+		"Front Cover is Open": "stopped",
+		"Replace Toner":       "stopped",
+		"Something new":       "unknown",
+	}
+
+	for message, expected := range tests {
+		assert.Equal(t, expected, webStatus(message), message)
+	}
+}
@@ -1,10 +1,23 @@
 package config
 
 import (
+	"cmp"
+	"encoding/hex"
 	"fmt"
+	"maps"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/d0ugal/brother-exporter/internal/printer"
 	promexporter_config "github.com/d0ugal/promexporter/config"
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +29,80 @@ type Config struct {
 	promexporter_config.BaseConfig
 
 	Printer PrinterConfig `yaml:"printer"`
+
+	// API configures the JSON API server, which listens on its own port next
+	// to the metrics server
+	API APIConfig `yaml:"api"`
+
+	// History configures the embedded store of periodic counter snapshots
+	History HistoryConfig `yaml:"history"`
+
+	// Report prices the pages of the usage report
+	Report ReportConfig `yaml:"report"`
+
+	// Events configures the log of printer changes such as status changes,
+	// alerts and consumable replacements
+	Events EventsConfig `yaml:"events"`
+
+	// MQTT configures publishing printer state to an MQTT broker, with Home
+	// Assistant discovery
+	MQTT MQTTConfig `yaml:"mqtt"`
+
+	// Notifications configures notifications of consumable, tray, alert and
+	// reachability changes
+	Notifications NotificationsConfig `yaml:"notifications"`
+
+	// Traps configures the SNMP trap listener
+	Traps TrapsConfig `yaml:"traps"`
+
+	// OTLP configures pushing the metrics to an OpenTelemetry collector
+	OTLP OTLPConfig `yaml:"otlp"`
+
+	// Push configures pushing the metrics after each collection cycle, for
+	// printers Prometheus can't scrape
+	Push PushConfig `yaml:"push"`
+
+	// Influx configures the InfluxDB line protocol output
+	Influx InfluxConfig `yaml:"influx"`
+
+	// Collectors enables, disables and sets the interval of each collection
+	// subsystem, keyed by subsystem name (see Subsystems)
+	Collectors map[string]SubsystemConfig `yaml:"collectors"`
+}
+
+// SubsystemConfig configures a single collection subsystem
+type SubsystemConfig struct {
+	Enabled  *bool    `yaml:"enabled,omitempty"` // Enable the subsystem (default: true, false for scanner)
+	Interval Duration `yaml:"interval"`          // Collection interval (default: metrics.collection.default_interval)
+}
+
+// Collection subsystem names
+const (
+	SubsystemInfo         = "info"
+	SubsystemStatus       = "status"
+	SubsystemUptime       = "uptime"
+	SubsystemBrother      = "brother"
+	SubsystemNextCare     = "nextcare"
+	SubsystemPaperTray    = "paper_tray"
+	SubsystemPageCounters = "page_counters"
+	SubsystemScanner      = "scanner"
+)
+
+// Subsystems lists every collection subsystem in the order a cycle runs them
+var Subsystems = []string{
+	SubsystemInfo,
+	SubsystemStatus,
+	SubsystemUptime,
+	SubsystemBrother,
+	SubsystemNextCare,
+	SubsystemPaperTray,
+	SubsystemPageCounters,
+	SubsystemScanner,
+}
+
+// optInSubsystems are disabled unless enabled in the collectors config
+var optInSubsystems = map[string]bool{
+	SubsystemScanner: true,
 }
 
 type PrinterConfig struct {
@@ -23,12 +110,528 @@ type PrinterConfig struct {
 	Community  string   `yaml:"community"`
 	Type       string   `yaml:"type"`
 	Interfaces []string `yaml:"interfaces"`
+
+	// Thresholds maps a consumable ("toner", "drum", "ink", "belt_unit",
+	// "fuser_unit", "laser_unit", "paper_feeding_kit"), optionally suffixed
+	// with a colour ("toner_cyan"), to its warning thresholds. The "default"
+	// key applies to anything without a more specific entry.
+	Thresholds map[string]ThresholdConfig `yaml:"thresholds"`
+
+	// Labels are extra labels added to every metric. Values containing
+	// "{{" are templates rendered against collected printer data, e.g.
+	// "{{.SysLocation}}" or "{{.Serial}}".
+	Labels map[string]string `yaml:"labels"`
+
+	// OnUnreachable controls what happens to previously collected values
+	// when the printer can't be reached: "keep" serves them unchanged,
+	// "drop" removes them and "mark_stale" keeps them and publishes
+	// brother_printer_data_age_seconds
+	OnUnreachable string `yaml:"on_unreachable"`
+
+	// Source selects how data is read from the printer: "snmp" (default),
+	// "ipp" for sites that block SNMP but allow IPP, "web" to scrape the
+	// embedded web server when neither is enabled, or "pjl" for status and
+	// page counts over the raw printing port
+	Source string `yaml:"source"`
+
+	// Sources lists the data sources to query in priority order, each
+	// optionally limited to some sections of the printer data. A source is
+	// only queried for sections the sources before it left empty. Defaults
+	// to Source alone.
+	Sources []SourceConfig `yaml:"sources"`
+
+	// IPP configures the IPP source
+	IPP IPPConfig `yaml:"ipp"`
+
+	// Web configures the web UI scraping source
+	Web WebConfig `yaml:"web"`
+
+	// PJL configures the PJL source
+	PJL PJLConfig `yaml:"pjl"`
+
+	// ESCL configures the scanner status endpoints polled by the scanner
+	// collector, which runs alongside whichever source is selected
+	ESCL ESCLConfig `yaml:"escl"`
+}
+
+// APIConfig configures the JSON API server. It serves the printer data
+// without authentication, so it only listens when enabled.
+type APIConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+}
+
+// URL returns the base URL of the API server, on localhost when it listens
+// on every interface
+func (a APIConfig) URL() string {
+	host := a.Host
+	if isWildcardHost(host) {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(a.Port))
+}
+
+func isWildcardHost(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::"
+}
+
+// HistoryConfig configures the snapshots of each printer's counters and
+// consumable levels kept on disk, served by the API
+type HistoryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Path is the database file (default: history.db)
+	Path string `yaml:"path"`
+	// Interval is how often a snapshot is taken (default: 24h). Snapshots
+	// are aligned to the interval in UTC, so daily ones are taken by the
+	// first collection after midnight.
+	Interval Duration `yaml:"interval"`
+	// RetentionDays is how long snapshots are kept (default: 730)
+	RetentionDays int `yaml:"retention_days"`
+}
+
+// ReportConfig configures the usage report built from the history
+type ReportConfig struct {
+	// Currency labels the cost columns, e.g. EUR
+	Currency    string    `yaml:"currency"`
+	CostPerPage PageCosts `yaml:"cost_per_page"`
+}
+
+// EventsConfig configures the event log, a JSON Lines file rotated by size
+// and served by the API
+type EventsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Path is the log file (default: events.jsonl). Rotated files get a
+	// numeric suffix, events.jsonl.1 being the most recent.
+	Path string `yaml:"path"`
+	// MaxSizeMB is the size a file is rotated at (default: 10)
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxFiles is how many rotated files are kept (default: 5)
+	MaxFiles int `yaml:"max_files"`
+}
+
+// PageCosts is the price of a mono and a colour page
+type PageCosts struct {
+	Mono  float64 `yaml:"mono"`
+	Color float64 `yaml:"color"`
+}
+
+// OTLP protocols
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+// OTLPConfig configures the OTLP metrics exporter
+type OTLPConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Protocol is grpc or http (default: grpc)
+	Protocol string `yaml:"protocol"`
+	// Endpoint is the collector's host:port (default: localhost:4317 for
+	// gRPC, localhost:4318 for HTTP)
+	Endpoint string `yaml:"endpoint"`
+	// URLPath is the HTTP path metrics are posted to (default: /v1/metrics)
+	URLPath string `yaml:"url_path"`
+	// Insecure disables TLS
+	Insecure bool `yaml:"insecure"`
+	// Headers are sent with every export, e.g. for authentication
+	Headers map[string]string `yaml:"headers"`
+
+	// Interval is how often the metrics are exported (default: the
+	// collection interval)
+	Interval Duration `yaml:"interval"`
+	Timeout  Duration `yaml:"timeout"`
+}
+
+// PushConfig configures the push modes, which can be used together and
+// with scraping
+type PushConfig struct {
+	RemoteWrite RemoteWriteConfig `yaml:"remote_write"`
+	Pushgateway PushgatewayConfig `yaml:"pushgateway"`
+}
+
+// PushAuth authenticates pushes with basic auth or a bearer token
+type PushAuth struct {
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	BearerToken string `yaml:"bearer_token"`
+}
+
+// RemoteWriteConfig configures sending the metrics with the Prometheus
+// remote-write protocol
+type RemoteWriteConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// URL is the remote-write endpoint, e.g.
+	// https://prometheus.example.com/api/v1/write
+	URL      string `yaml:"url"`
+	PushAuth `yaml:",inline"`
+	// Headers are sent with every request
+	Headers map[string]string `yaml:"headers"`
+	// ExternalLabels are added to every series, on top of job
+	ExternalLabels map[string]string `yaml:"external_labels"`
+	// Job is the job label of every series (default: brother-exporter)
+	Job string `yaml:"job"`
+
+	// QueueSize is how many cycles are kept in memory while the endpoint
+	// can't be reached, the oldest dropped first (default: 1000)
+	QueueSize int `yaml:"queue_size"`
+	// MinBackoff and MaxBackoff bound the delay between retries (default:
+	// 1s and 1m)
+	MinBackoff Duration `yaml:"min_backoff"`
+	MaxBackoff Duration `yaml:"max_backoff"`
+	Timeout    Duration `yaml:"timeout"`
+}
+
+// PushgatewayConfig configures pushing the metrics to a Pushgateway, in a
+// group per printer
+type PushgatewayConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// URL is the Pushgateway, e.g. http://pushgateway:9091
+	URL      string `yaml:"url"`
+	PushAuth `yaml:",inline"`
+	// Job is the job of the group (default: brother-exporter). The group's
+	// instance is the printer.
+	Job     string   `yaml:"job"`
+	Timeout Duration `yaml:"timeout"`
+}
+
+// Influx output modes
+const (
+	InfluxModeServe = "serve"
+	InfluxModeWrite = "write"
+)
+
+// InfluxConfig configures the printer state as InfluxDB line protocol,
+// served for Telegraf to poll or written to InfluxDB v2
+type InfluxConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Mode is serve, for GET /influx on the API port, or write (default:
+	// serve)
+	Mode string `yaml:"mode"`
+
+	// URL, Org, Bucket and Token locate and authorize writes to InfluxDB
+	// v2, e.g. http://influxdb:8086
+	URL    string `yaml:"url"`
+	Org    string `yaml:"org"`
+	Bucket string `yaml:"bucket"`
+	Token  string `yaml:"token"`
+
+	// Tags are added to every line
+	Tags map[string]string `yaml:"tags"`
+
+	Timeout Duration `yaml:"timeout"`
+}
+
+// MQTTConfig configures the MQTT publisher
+type MQTTConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Broker is the broker URL, e.g. tcp://192.168.1.10:1883 or
+	// ssl://broker:8883
+	Broker   string `yaml:"broker"`
+	ClientID string `yaml:"client_id"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// TopicPrefix prefixes the state topics, e.g. brother/<serial>/status
+	TopicPrefix string `yaml:"topic_prefix"`
+	// QoS is the quality of service of every publish (0, 1 or 2)
+	QoS byte `yaml:"qos"`
+	// Retain keeps the last state on the broker; discovery and
+	// availability messages are always retained
+	Retain bool `yaml:"retain"`
+
+	// Discovery publishes Home Assistant discovery config under
+	// DiscoveryPrefix (default: true)
+	Discovery       *bool  `yaml:"discovery,omitempty"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+
+	Timeout Duration `yaml:"timeout"`
+}
+
+// IsDiscoveryEnabled returns true if Home Assistant discovery is enabled
+// (defaults to true)
+func (m *MQTTConfig) IsDiscoveryEnabled() bool {
+	return m.Discovery == nil || *m.Discovery
+}
+
+// NotificationsConfig configures notifications and their targets
+type NotificationsConfig struct {
+	// ReminderInterval resends a notification while its condition lasts,
+	// 0 disables reminders
+	ReminderInterval Duration `yaml:"reminder_interval"`
+	// QuietHours holds notifications back until the window ends
+	QuietHours QuietHoursConfig `yaml:"quiet_hours"`
+
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+	Email    EmailConfig     `yaml:"email"`
+}
+
+// Enabled returns true if any notification target is configured
+func (n *NotificationsConfig) Enabled() bool {
+	return len(n.Webhooks) > 0 || n.Email.Enabled()
+}
+
+// EmailConfig configures email notifications over SMTP
+type EmailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// StartTLS upgrades the connection before authenticating (default:
+	// true); TLS connects over TLS instead, usually on port 465
+	StartTLS           *bool `yaml:"starttls,omitempty"`
+	TLS                bool  `yaml:"tls"`
+	InsecureSkipVerify bool  `yaml:"insecure_skip_verify"`
+
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+	// Recipients adds recipients for printers whose host, serial, name or
+	// location matches the key
+	Recipients map[string][]string `yaml:"recipients"`
+
+	// Kinds limits the notifications sent by email, e.g. [supply]
+	// (default: all)
+	Kinds []string `yaml:"kinds"`
+
+	// Subject and Body are Go templates, see README
+	Subject string `yaml:"subject"`
+	Body    string `yaml:"body"`
+
+	Timeout Duration `yaml:"timeout"`
+}
+
+// Enabled returns true if an SMTP server is configured
+func (e *EmailConfig) Enabled() bool {
+	return e.Host != ""
+}
+
+// IsStartTLSEnabled returns true if STARTTLS is used (defaults to true
+// unless TLS is set)
+func (e *EmailConfig) IsStartTLSEnabled() bool {
+	if e.StartTLS == nil {
+		return !e.TLS
+	}
+
+	return *e.StartTLS
+}
+
+// QuietHoursConfig is a daily window, e.g. 22:00 to 07:00
+type QuietHoursConfig struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+	// Timezone is an IANA name such as Europe/Dublin (default: local time)
+	Timezone string `yaml:"timezone"`
+}
+
+// Webhook payload formats
+const (
+	WebhookGeneric = "generic"
+	WebhookSlack   = "slack"
+	WebhookTeams   = "teams"
+	WebhookDiscord = "discord"
+)
+
+// WebhookConfig is a webhook notification target
+type WebhookConfig struct {
+	// Name identifies the target in logs (default: the URL's host)
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Format is the payload format: generic, slack, teams or discord
+	Format  string            `yaml:"format"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout Duration          `yaml:"timeout"`
+}
+
+// TrapsConfig configures the SNMP trap listener. Printer-MIB alert traps
+// update the alert and status metrics at once, and any accepted trap
+// triggers a collection.
+type TrapsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Listen is the UDP address to listen on (default: 0.0.0.0:162)
+	Listen string `yaml:"listen"`
+	// Community is the v1/v2c community traps must carry, empty accepts
+	// any
+	Community string `yaml:"community"`
+	// Sources are addresses, besides the printer host, that send traps
+	// for the printer, e.g. a second interface
+	Sources []string `yaml:"sources"`
+	// CollectDelay waits after a trap before collecting, so the printer
+	// has updated its state and a burst of traps triggers one collection
+	CollectDelay Duration `yaml:"collect_delay"`
+
+	V3 TrapV3Config `yaml:"v3"`
+}
+
+// SNMPv3Config is an SNMPv3 user and its authentication and privacy
+// settings
+type SNMPv3Config struct {
+	Username string `yaml:"username"`
+	// AuthProtocol is MD5, SHA, SHA224, SHA256, SHA384 or SHA512
+	AuthProtocol   string `yaml:"auth_protocol"`
+	AuthPassphrase string `yaml:"auth_passphrase"`
+	// PrivProtocol is DES, AES, AES192, AES256, AES192C or AES256C
+	PrivProtocol   string `yaml:"priv_protocol"`
+	PrivPassphrase string `yaml:"priv_passphrase"`
+}
+
+// TrapV3Config holds the SNMPv3 user traps are authenticated with
+type TrapV3Config struct {
+	SNMPv3Config `yaml:",inline"`
+
+	// EngineID is the printer's SNMP engine ID in hex, which v3 traps are
+	// authenticated against
+	EngineID string `yaml:"engine_id"`
+}
+
+// SNMPv3 protocols
+var (
+	SNMPv3AuthProtocols = []string{"MD5", "SHA", "SHA224", "SHA256", "SHA384", "SHA512"}
+	SNMPv3PrivProtocols = []string{"DES", "AES", "AES192", "AES256", "AES192C", "AES256C"}
+)
+
+// Printer data sources
+const (
+	SourceSNMP = "snmp"
+	SourceIPP  = "ipp"
+	SourceWeb  = "web"
+	SourcePJL  = "pjl"
+)
+
+// SourceConfig is an entry of the ordered source list
+type SourceConfig struct {
+	Name string `yaml:"name"`
+
+	// Sections limits the source to these sections: identity, status,
+	// uptime, supplies, maintenance, trays and counters. Empty means all.
+	Sections []string `yaml:"sections"`
+}
+
+// AllowedSections returns the sections the source may provide
+func (s SourceConfig) AllowedSections() []string {
+	if len(s.Sections) == 0 {
+		return printer.Sections
+	}
+
+	return s.Sections
+}
+
+// IPPConfig configures the IPP Get-Printer-Attributes source
+type IPPConfig struct {
+	Port               int      `yaml:"port"`                 // Default: 631
+	Path               string   `yaml:"path"`                 // Default: /ipp/print
+	TLS                bool     `yaml:"tls"`                  // Use IPPS (IPP over HTTPS)
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"` // Accept self-signed printer certificates
+	Timeout            Duration `yaml:"timeout"`              // Default: 10s
+}
+
+// IPPURL returns the http(s) URL of the printer's IPP endpoint
+func (p *PrinterConfig) IPPURL() string {
+	scheme := "http"
+	if p.IPP.TLS {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s:%d%s", scheme, p.Host, p.IPP.Port, p.IPP.Path)
+}
+
+// WebConfig configures the web UI scraping source
+type WebConfig struct {
+	Port               int      `yaml:"port"`                 // Default: 80, or 443 with TLS
+	TLS                bool     `yaml:"tls"`                  // Use https
+	Password           string   `yaml:"password"`             // Admin password, for printers that require a login
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"` // Accept self-signed printer certificates
+	Timeout            Duration `yaml:"timeout"`              // Default: 10s
+}
+
+// WebURL returns the base URL of the printer's embedded web server
+func (p *PrinterConfig) WebURL() string {
+	scheme := "http"
+	if p.Web.TLS {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s:%d", scheme, p.Host, p.Web.Port)
+}
+
+// PJLConfig configures the PJL source on the raw printing port
+type PJLConfig struct {
+	Port        int      `yaml:"port"`         // Default: 9100
+	Timeout     Duration `yaml:"timeout"`      // Default: 10s
+	BusyRetries int      `yaml:"busy_retries"` // Retries while the port is busy or refused. Default: 2
+	RetryDelay  Duration `yaml:"retry_delay"`  // Default: 2s
+}
+
+// PJLAddress returns the host:port of the printer's raw printing port
+func (p *PrinterConfig) PJLAddress() string {
+	return net.JoinHostPort(p.Host, strconv.Itoa(p.PJL.Port))
+}
+
+// ESCLConfig configures the eSCL (AirScan) scanner endpoints
+type ESCLConfig struct {
+	Port               int      `yaml:"port"`                 // Default: 80, or 443 with TLS
+	Path               string   `yaml:"path"`                 // Default: /eSCL
+	TLS                bool     `yaml:"tls"`                  // Use https
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"` // Accept self-signed printer certificates
+	Timeout            Duration `yaml:"timeout"`              // Default: 10s
+}
+
+// ESCLURL returns the URL of the printer's eSCL root
+func (p *PrinterConfig) ESCLURL() string {
+	scheme := "http"
+	if p.ESCL.TLS {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s:%d%s", scheme, p.Host, p.ESCL.Port, p.ESCL.Path)
+}
+
+// Unreachable printer policies
+const (
+	OnUnreachableKeep      = "keep"
+	OnUnreachableDrop      = "drop"
+	OnUnreachableMarkStale = "mark_stale"
+)
+
+// ThresholdConfig holds the percentage levels at which a consumable changes
+// state. Unset fields inherit from the next less specific entry.
+type ThresholdConfig struct {
+	Warning    *float64 `yaml:"warning"`
+	Critical   *float64 `yaml:"critical"`
+	Empty      *float64 `yaml:"empty"`
+	Hysteresis *float64 `yaml:"hysteresis"`
+}
+
+// Thresholds is a fully resolved ThresholdConfig
+type Thresholds struct {
+	Warning    float64
+	Critical   float64
+	Empty      float64
+	Hysteresis float64
 }
 
+// Default consumable thresholds (percentage)
+const (
+	DefaultWarningThreshold    = 10
+	DefaultCriticalThreshold   = 5
+	DefaultEmptyThreshold      = 0
+	DefaultHysteresisThreshold = 2
+)
+
 // LoadConfig loads configuration with priority: env vars > yaml file > defaults.
 // The yaml file is optional; if path is empty or the file does not exist it is
 // silently skipped. Environment variables are always applied on top.
 func LoadConfig(path string) (*Config, error) {
+	return LoadConfigWith(path, nil)
+}
+
+// LoadConfigWith loads configuration like LoadConfig, with override applied
+// on top of the environment variables, before defaults and validation. It
+// lets command line flags such as a subcommand's --host take priority.
+func LoadConfigWith(path string, override func(*Config)) (*Config, error) {
 	var cfg Config
 
 	if path != "" {
@@ -47,6 +650,11 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	applyEnvVars(&cfg)
+
+	if override != nil {
+		override(&cfg)
+	}
+
 	setDefaults(&cfg)
 
 	if err := cfg.Validate(); err != nil {
@@ -95,6 +703,144 @@ func applyEnvVars(cfg *Config) {
 	if printerType := os.Getenv("BROTHER_EXPORTER_PRINTER_TYPE"); printerType != "" {
 		cfg.Printer.Type = printerType
 	}
+
+	if source := os.Getenv("BROTHER_EXPORTER_PRINTER_SOURCE"); source != "" {
+		cfg.Printer.Source = source
+	}
+
+	if sources := os.Getenv("BROTHER_EXPORTER_PRINTER_SOURCES"); sources != "" {
+		cfg.Printer.Sources = nil
+
+		for name := range strings.SplitSeq(sources, ",") {
+			cfg.Printer.Sources = append(cfg.Printer.Sources, SourceConfig{Name: strings.TrimSpace(name)})
+		}
+	}
+
+	if password := os.Getenv("BROTHER_EXPORTER_PRINTER_WEB_PASSWORD"); password != "" {
+		cfg.Printer.Web.Password = password
+	}
+
+	if policy := os.Getenv("BROTHER_EXPORTER_PRINTER_ON_UNREACHABLE"); policy != "" {
+		cfg.Printer.OnUnreachable = policy
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_API_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.API.Enabled = enabled
+		}
+	}
+
+	if host := os.Getenv("BROTHER_EXPORTER_API_HOST"); host != "" {
+		cfg.API.Host = host
+	}
+
+	if portStr := os.Getenv("BROTHER_EXPORTER_API_PORT"); portStr != "" {
+		if port, err := parseInt(portStr); err == nil {
+			cfg.API.Port = port
+		}
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_HISTORY_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.History.Enabled = enabled
+		}
+	}
+
+	if path := os.Getenv("BROTHER_EXPORTER_HISTORY_PATH"); path != "" {
+		cfg.History.Path = path
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_EVENTS_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.Events.Enabled = enabled
+		}
+	}
+
+	if path := os.Getenv("BROTHER_EXPORTER_EVENTS_PATH"); path != "" {
+		cfg.Events.Path = path
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_OTLP_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.OTLP.Enabled = enabled
+		}
+	}
+
+	if protocol := os.Getenv("BROTHER_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
+		cfg.OTLP.Protocol = protocol
+	}
+
+	if endpoint := os.Getenv("BROTHER_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		cfg.OTLP.Endpoint = endpoint
+	}
+
+	if endpoint := os.Getenv("BROTHER_EXPORTER_REMOTE_WRITE_URL"); endpoint != "" {
+		cfg.Push.RemoteWrite.Enabled = true
+		cfg.Push.RemoteWrite.URL = endpoint
+	}
+
+	if token := os.Getenv("BROTHER_EXPORTER_REMOTE_WRITE_BEARER_TOKEN"); token != "" {
+		cfg.Push.RemoteWrite.BearerToken = token
+	}
+
+	if endpoint := os.Getenv("BROTHER_EXPORTER_PUSHGATEWAY_URL"); endpoint != "" {
+		cfg.Push.Pushgateway.Enabled = true
+		cfg.Push.Pushgateway.URL = endpoint
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_INFLUX_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.Influx.Enabled = enabled
+		}
+	}
+
+	if mode := os.Getenv("BROTHER_EXPORTER_INFLUX_MODE"); mode != "" {
+		cfg.Influx.Mode = mode
+	}
+
+	if endpoint := os.Getenv("BROTHER_EXPORTER_INFLUX_URL"); endpoint != "" {
+		cfg.Influx.URL = endpoint
+	}
+
+	if token := os.Getenv("BROTHER_EXPORTER_INFLUX_TOKEN"); token != "" {
+		cfg.Influx.Token = token
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_MQTT_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.MQTT.Enabled = enabled
+		}
+	}
+
+	if broker := os.Getenv("BROTHER_EXPORTER_MQTT_BROKER"); broker != "" {
+		cfg.MQTT.Broker = broker
+	}
+
+	if username := os.Getenv("BROTHER_EXPORTER_MQTT_USERNAME"); username != "" {
+		cfg.MQTT.Username = username
+	}
+
+	if password := os.Getenv("BROTHER_EXPORTER_MQTT_PASSWORD"); password != "" {
+		cfg.MQTT.Password = password
+	}
+
+	if password := os.Getenv("BROTHER_EXPORTER_SMTP_PASSWORD"); password != "" {
+		cfg.Notifications.Email.Password = password
+	}
+
+	if enabledStr := os.Getenv("BROTHER_EXPORTER_TRAPS_ENABLED"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			cfg.Traps.Enabled = enabled
+		}
+	}
+
+	if listen := os.Getenv("BROTHER_EXPORTER_TRAPS_LISTEN"); listen != "" {
+		cfg.Traps.Listen = listen
+	}
+
+	if community := os.Getenv("BROTHER_EXPORTER_TRAPS_COMMUNITY"); community != "" {
+		cfg.Traps.Community = community
+	}
 }
 
 // parseInt parses a string to int
@@ -146,50 +892,739 @@ func setDefaults(config *Config) {
 	if config.Printer.Type == "" {
 		config.Printer.Type = "laser"
 	}
-}
 
-// Validate performs comprehensive validation of the configuration
-func (c *Config) Validate() error {
-	// Validate server configuration
-	if err := c.validateServerConfig(); err != nil {
-		return fmt.Errorf("server config: %w", err)
+	if config.Printer.OnUnreachable == "" {
+		config.Printer.OnUnreachable = OnUnreachableKeep
 	}
 
-	// Validate logging configuration
-	if err := c.validateLoggingConfig(); err != nil {
-		return fmt.Errorf("logging config: %w", err)
+	if config.Printer.Source == "" {
+		config.Printer.Source = SourceSNMP
+		if len(config.Printer.Sources) > 0 {
+			config.Printer.Source = config.Printer.Sources[0].Name
+		}
 	}
 
-	// Validate metrics configuration
-	if err := c.validateMetricsConfig(); err != nil {
-		return fmt.Errorf("metrics config: %w", err)
+	if len(config.Printer.Sources) == 0 {
+		config.Printer.Sources = []SourceConfig{{Name: config.Printer.Source}}
 	}
 
-	// Validate printer configuration
-	if err := c.validatePrinterConfig(); err != nil {
-		return fmt.Errorf("printer config: %w", err)
+	if config.Printer.IPP.Port == 0 {
+		config.Printer.IPP.Port = 631
 	}
 
-	return nil
-}
-
-func (c *Config) validateServerConfig() error {
-	if c.Server.Port < 1 || c.Server.Port > 65535 {
-		return fmt.Errorf("port must be between 1 and 65535, got %d", c.Server.Port)
+	if config.Printer.IPP.Path == "" {
+		config.Printer.IPP.Path = "/ipp/print"
 	}
 
-	return nil
-}
+	if config.Printer.IPP.Timeout.Duration == 0 {
+		config.Printer.IPP.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
 
-func (c *Config) validateLoggingConfig() error {
-	validLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
+	if config.Printer.Web.Port == 0 {
+		config.Printer.Web.Port = 80
+		if config.Printer.Web.TLS {
+			config.Printer.Web.Port = 443
+		}
 	}
-	if !validLevels[c.Logging.Level] {
-		return fmt.Errorf("invalid logging level: %s", c.Logging.Level)
+
+	if config.Printer.Web.Timeout.Duration == 0 {
+		config.Printer.Web.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Printer.ESCL.Port == 0 {
+		config.Printer.ESCL.Port = 80
+		if config.Printer.ESCL.TLS {
+			config.Printer.ESCL.Port = 443
+		}
+	}
+
+	if config.Printer.ESCL.Path == "" {
+		config.Printer.ESCL.Path = "/eSCL"
+	}
+
+	if config.Printer.ESCL.Timeout.Duration == 0 {
+		config.Printer.ESCL.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Printer.PJL.Port == 0 {
+		config.Printer.PJL.Port = 9100
+	}
+
+	if config.Printer.PJL.Timeout.Duration == 0 {
+		config.Printer.PJL.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Printer.PJL.BusyRetries == 0 {
+		config.Printer.PJL.BusyRetries = 2
+	}
+
+	if config.Printer.PJL.RetryDelay.Duration == 0 {
+		config.Printer.PJL.RetryDelay = promexporter_config.Duration{Duration: 2 * time.Second}
+	}
+
+	if config.API.Host == "" {
+		config.API.Host = config.Server.Host
+	}
+
+	if config.API.Port == 0 {
+		config.API.Port = 8081
+	}
+
+	if config.History.Path == "" {
+		config.History.Path = "history.db"
+	}
+
+	if config.History.Interval.Duration == 0 {
+		config.History.Interval = promexporter_config.Duration{Duration: 24 * time.Hour}
+	}
+
+	if config.History.RetentionDays == 0 {
+		config.History.RetentionDays = 730
+	}
+
+	if config.Events.Path == "" {
+		config.Events.Path = "events.jsonl"
+	}
+
+	if config.Events.MaxSizeMB == 0 {
+		config.Events.MaxSizeMB = 10
+	}
+
+	if config.Events.MaxFiles == 0 {
+		config.Events.MaxFiles = 5
+	}
+
+	if config.OTLP.Protocol == "" {
+		config.OTLP.Protocol = OTLPProtocolGRPC
+	}
+
+	if config.OTLP.Endpoint == "" {
+		config.OTLP.Endpoint = "localhost:4317"
+		if config.OTLP.Protocol == OTLPProtocolHTTP {
+			config.OTLP.Endpoint = "localhost:4318"
+		}
+	}
+
+	if config.OTLP.URLPath == "" {
+		config.OTLP.URLPath = "/v1/metrics"
+	}
+
+	if config.OTLP.Interval.Duration == 0 {
+		config.OTLP.Interval = config.Metrics.Collection.DefaultInterval
+	}
+
+	if config.OTLP.Timeout.Duration == 0 {
+		config.OTLP.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Push.RemoteWrite.Job == "" {
+		config.Push.RemoteWrite.Job = "brother-exporter"
+	}
+
+	if config.Push.RemoteWrite.QueueSize == 0 {
+		config.Push.RemoteWrite.QueueSize = 1000
+	}
+
+	if config.Push.RemoteWrite.MinBackoff.Duration == 0 {
+		config.Push.RemoteWrite.MinBackoff = promexporter_config.Duration{Duration: time.Second}
+	}
+
+	if config.Push.RemoteWrite.MaxBackoff.Duration == 0 {
+		config.Push.RemoteWrite.MaxBackoff = promexporter_config.Duration{Duration: time.Minute}
+	}
+
+	if config.Push.RemoteWrite.Timeout.Duration == 0 {
+		config.Push.RemoteWrite.Timeout = promexporter_config.Duration{Duration: 30 * time.Second}
+	}
+
+	if config.Push.Pushgateway.Job == "" {
+		config.Push.Pushgateway.Job = "brother-exporter"
+	}
+
+	if config.Push.Pushgateway.Timeout.Duration == 0 {
+		config.Push.Pushgateway.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Influx.Mode == "" {
+		config.Influx.Mode = InfluxModeServe
+	}
+
+	if config.Influx.Timeout.Duration == 0 {
+		config.Influx.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.MQTT.ClientID == "" {
+		config.MQTT.ClientID = "brother-exporter"
+	}
+
+	if config.MQTT.TopicPrefix == "" {
+		config.MQTT.TopicPrefix = "brother"
+	}
+
+	if config.MQTT.DiscoveryPrefix == "" {
+		config.MQTT.DiscoveryPrefix = "homeassistant"
+	}
+
+	if config.MQTT.Timeout.Duration == 0 {
+		config.MQTT.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Notifications.Email.Port == 0 {
+		config.Notifications.Email.Port = 587
+		if config.Notifications.Email.TLS {
+			config.Notifications.Email.Port = 465
+		}
+	}
+
+	if config.Notifications.Email.Timeout.Duration == 0 {
+		config.Notifications.Email.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+	}
+
+	if config.Traps.Listen == "" {
+		config.Traps.Listen = "0.0.0.0:162"
+	}
+
+	if config.Traps.CollectDelay.Duration == 0 {
+		config.Traps.CollectDelay = promexporter_config.Duration{Duration: 2 * time.Second}
+	}
+
+	for i := range config.Notifications.Webhooks {
+		webhook := &config.Notifications.Webhooks[i]
+
+		if webhook.Format == "" {
+			webhook.Format = WebhookGeneric
+		}
+
+		if webhook.Name == "" {
+			if u, err := url.Parse(webhook.URL); err == nil {
+				webhook.Name = u.Host
+			}
+		}
+
+		if webhook.Timeout.Duration == 0 {
+			webhook.Timeout = promexporter_config.Duration{Duration: 10 * time.Second}
+		}
+	}
+}
+
+// ReservedLabelNames are the labels the Brother metrics declare, which
+// custom labels must not reuse. A test in internal/metrics checks it against
+// the registered metrics.
+var ReservedLabelNames = []string{
+	"adf", "code", "color", "duplex", "error_type", "firmware", "host", "mac", "model", "operation",
+	"result", "serial", "severity", "source", "state", "status", "step", "supply", "tray", "type",
+}
+
+// LabelNames returns the sorted names of the configured custom labels
+func (p *PrinterConfig) LabelNames() []string {
+	names := make([]string, 0, len(p.Labels))
+	for name := range p.Labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ThresholdsFor resolves the thresholds for a consumable and colour. Lookup
+// order is "<consumable>_<color>", "<consumable>", "default", then built-in
+// defaults, with each field resolved independently.
+func (p *PrinterConfig) ThresholdsFor(consumable, color string) Thresholds {
+	resolved := Thresholds{
+		Warning:    DefaultWarningThreshold,
+		Critical:   DefaultCriticalThreshold,
+		Empty:      DefaultEmptyThreshold,
+		Hysteresis: DefaultHysteresisThreshold,
+	}
+
+	keys := []string{"default", consumable}
+	if color != "" {
+		keys = append(keys, consumable+"_"+color)
+	}
+
+	for _, key := range keys {
+		t, ok := p.Thresholds[key]
+		if !ok {
+			continue
+		}
+
+		if t.Warning != nil {
+			resolved.Warning = *t.Warning
+		}
+
+		if t.Critical != nil {
+			resolved.Critical = *t.Critical
+		}
+
+		if t.Empty != nil {
+			resolved.Empty = *t.Empty
+		}
+
+		if t.Hysteresis != nil {
+			resolved.Hysteresis = *t.Hysteresis
+		}
+	}
+
+	return resolved
+}
+
+// OverrideThresholds sets warning and critical levels by threshold key, as
+// the check command's flags do. A level given alone moves the other levels
+// of its key as far as needed to keep empty <= critical <= warning, so
+// critical 20 raises the default warning of 10 to 20.
+func (p *PrinterConfig) OverrideThresholds(warning, critical map[string]float64) error {
+	for key, c := range critical {
+		if w, ok := warning[key]; ok && c > w {
+			return fmt.Errorf("critical level %s=%g is above its warning level %s=%g", key, c, key, w)
+		}
+	}
+
+	keys := slices.Collect(maps.Keys(warning))
+	for key := range critical {
+		if _, ok := warning[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if p.Thresholds == nil {
+		p.Thresholds = make(map[string]ThresholdConfig)
+	}
+
+	// Less specific keys first, as the more specific ones resolve on top
+	// of them
+	specificity := func(key string) int {
+		_, color, _ := splitThresholdKey(key)
+
+		switch {
+		case key == "default":
+			return 0
+		case color == "":
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(specificity(a), specificity(b)), strings.Compare(a, b))
+	})
+
+	for _, key := range keys {
+		consumable, color, ok := splitThresholdKey(key)
+		if !ok {
+			return fmt.Errorf("unknown consumable in thresholds: %s", key)
+		}
+
+		t := p.Thresholds[key]
+
+		w, hasWarning := warning[key]
+		if hasWarning {
+			t.Warning = &w
+		}
+
+		c, hasCritical := critical[key]
+		if hasCritical {
+			t.Critical = &c
+		}
+
+		p.Thresholds[key] = t
+		resolved := p.ThresholdsFor(consumable, color)
+
+		if !hasWarning && resolved.Warning < c {
+			t.Warning = &c
+		}
+
+		if !hasCritical && resolved.Critical > w {
+			t.Critical = &w
+			resolved.Critical = w
+		}
+
+		if resolved.Empty > resolved.Critical {
+			empty := resolved.Critical
+			t.Empty = &empty
+		}
+
+		p.Thresholds[key] = t
+	}
+
+	return nil
+}
+
+// Validate performs comprehensive validation of the configuration
+func (c *Config) Validate() error {
+	// Validate server configuration
+	if err := c.validateServerConfig(); err != nil {
+		return fmt.Errorf("server config: %w", err)
+	}
+
+	// Validate logging configuration
+	if err := c.validateLoggingConfig(); err != nil {
+		return fmt.Errorf("logging config: %w", err)
+	}
+
+	// Validate metrics configuration
+	if err := c.validateMetricsConfig(); err != nil {
+		return fmt.Errorf("metrics config: %w", err)
+	}
+
+	// Validate printer configuration
+	if err := c.validatePrinterConfig(); err != nil {
+		return fmt.Errorf("printer config: %w", err)
+	}
+
+	// Validate collectors configuration
+	if err := c.validateCollectorsConfig(); err != nil {
+		return fmt.Errorf("collectors config: %w", err)
+	}
+
+	// Validate API configuration
+	if err := c.validateAPIConfig(); err != nil {
+		return fmt.Errorf("api config: %w", err)
+	}
+
+	// Validate history configuration
+	if err := c.validateHistoryConfig(); err != nil {
+		return fmt.Errorf("history config: %w", err)
+	}
+
+	// Validate report configuration
+	if c.Report.CostPerPage.Mono < 0 || c.Report.CostPerPage.Color < 0 {
+		return fmt.Errorf("report config: cost_per_page must not be negative")
+	}
+
+	// Validate events configuration
+	if c.Events.MaxSizeMB < 0 || c.Events.MaxFiles < 0 {
+		return fmt.Errorf("events config: max_size_mb and max_files must not be negative")
+	}
+
+	// Validate MQTT configuration
+	if err := c.validateMQTTConfig(); err != nil {
+		return fmt.Errorf("mqtt config: %w", err)
+	}
+
+	// Validate OTLP configuration
+	if err := c.validateOTLPConfig(); err != nil {
+		return fmt.Errorf("otlp config: %w", err)
+	}
+
+	// Validate push configuration
+	if err := c.validatePushConfig(); err != nil {
+		return fmt.Errorf("push config: %w", err)
+	}
+
+	// Validate Influx configuration
+	if err := c.validateInfluxConfig(); err != nil {
+		return fmt.Errorf("influx config: %w", err)
+	}
+
+	// Validate traps configuration
+	if err := c.validateTrapsConfig(); err != nil {
+		return fmt.Errorf("traps config: %w", err)
+	}
+
+	// Validate notifications configuration
+	if err := c.validateNotificationsConfig(); err != nil {
+		return fmt.Errorf("notifications config: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) validateServerConfig() error {
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+
+	return nil
+}
+
+func (c *Config) validateAPIConfig() error {
+	if !c.API.Enabled {
+		return nil
+	}
+
+	if c.API.Port < 1 || c.API.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", c.API.Port)
+	}
+
+	if c.API.Port == c.Server.Port {
+		return fmt.Errorf("port %d is already used by the metrics server", c.API.Port)
+	}
+
+	return nil
+}
+
+func (c *Config) validateHistoryConfig() error {
+	if !c.History.Enabled {
+		return nil
+	}
+
+	if c.History.Interval.Duration < time.Minute {
+		return fmt.Errorf("interval must be at least 1m, got %s", c.History.Interval.Duration)
+	}
+
+	if c.History.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative, got %d", c.History.RetentionDays)
+	}
+
+	return nil
+}
+
+func (c *Config) validateOTLPConfig() error {
+	if !c.OTLP.Enabled {
+		return nil
+	}
+
+	if c.OTLP.Protocol != OTLPProtocolGRPC && c.OTLP.Protocol != OTLPProtocolHTTP {
+		return fmt.Errorf("protocol must be %s or %s, got %q", OTLPProtocolGRPC, OTLPProtocolHTTP, c.OTLP.Protocol)
+	}
+
+	if _, _, err := net.SplitHostPort(c.OTLP.Endpoint); err != nil {
+		return fmt.Errorf("endpoint must be host:port: %w", err)
+	}
+
+	if c.OTLP.Interval.Duration < time.Second {
+		return fmt.Errorf("interval must be at least 1s, got %s", c.OTLP.Interval.Duration)
+	}
+
+	return nil
+}
+
+func (c *Config) validatePushConfig() error {
+	if rw := c.Push.RemoteWrite; rw.Enabled {
+		if err := validatePushTarget("remote_write", rw.URL, rw.PushAuth); err != nil {
+			return err
+		}
+
+		if rw.QueueSize < 1 {
+			return fmt.Errorf("remote_write: queue_size must be positive, got %d", rw.QueueSize)
+		}
+
+		if rw.MinBackoff.Duration > rw.MaxBackoff.Duration {
+			return fmt.Errorf("remote_write: min_backoff %s is above max_backoff %s", rw.MinBackoff.Duration, rw.MaxBackoff.Duration)
+		}
+	}
+
+	if pg := c.Push.Pushgateway; pg.Enabled {
+		if err := validatePushTarget("pushgateway", pg.URL, pg.PushAuth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePushTarget(name, target string, auth PushAuth) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s: url must be an http or https URL, got %q", name, target)
+	}
+
+	if auth.BearerToken != "" && (auth.Username != "" || auth.Password != "") {
+		return fmt.Errorf("%s: use either basic auth or a bearer token, not both", name)
+	}
+
+	return nil
+}
+
+func (c *Config) validateInfluxConfig() error {
+	if !c.Influx.Enabled {
+		return nil
+	}
+
+	switch c.Influx.Mode {
+	case InfluxModeServe:
+		if !c.API.Enabled {
+			return fmt.Errorf("serve mode needs the API server, enable it with api.enabled")
+		}
+	case InfluxModeWrite:
+		parsed, err := url.Parse(c.Influx.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("url must be an http or https URL, got %q", c.Influx.URL)
+		}
+
+		if c.Influx.Org == "" || c.Influx.Bucket == "" {
+			return fmt.Errorf("org and bucket are required in write mode")
+		}
+	default:
+		return fmt.Errorf("mode must be %s or %s, got %q", InfluxModeServe, InfluxModeWrite, c.Influx.Mode)
+	}
+
+	return nil
+}
+
+func (c *Config) validateMQTTConfig() error {
+	if !c.MQTT.Enabled {
+		return nil
+	}
+
+	if c.MQTT.Broker == "" {
+		return fmt.Errorf("broker is required")
+	}
+
+	if c.MQTT.QoS > 2 {
+		return fmt.Errorf("qos must be 0, 1 or 2, got %d", c.MQTT.QoS)
+	}
+
+	if strings.ContainsAny(c.MQTT.TopicPrefix+c.MQTT.DiscoveryPrefix, "+#") {
+		return fmt.Errorf("topic prefixes must not contain wildcards")
+	}
+
+	return nil
+}
+
+func (c *Config) validateNotificationsConfig() error {
+	n := c.Notifications
+
+	if n.ReminderInterval.Duration < 0 {
+		return fmt.Errorf("reminder_interval must not be negative")
+	}
+
+	if (n.QuietHours.Start == "") != (n.QuietHours.End == "") {
+		return fmt.Errorf("quiet_hours needs both start and end")
+	}
+
+	for _, clock := range []string{n.QuietHours.Start, n.QuietHours.End} {
+		if _, err := time.Parse("15:04", clock); clock != "" && err != nil {
+			return fmt.Errorf("invalid quiet_hours time %q, expected HH:MM", clock)
+		}
+	}
+
+	if _, err := time.LoadLocation(n.QuietHours.Timezone); err != nil {
+		return fmt.Errorf("invalid quiet_hours timezone: %w", err)
+	}
+
+	if n.Email.Enabled() {
+		if err := validateEmailConfig(n.Email); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+
+	validFormats := []string{WebhookGeneric, WebhookSlack, WebhookTeams, WebhookDiscord}
+
+	for i, webhook := range n.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %d: url must be an http or https URL", i)
+		}
+
+		if !slices.Contains(validFormats, webhook.Format) {
+			return fmt.Errorf("webhook %d: invalid format %q, must be one of %v", i, webhook.Format, validFormats)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the protocols and passphrases of a user
+func (v SNMPv3Config) Validate() error {
+	if v.AuthProtocol != "" && !slices.Contains(SNMPv3AuthProtocols, v.AuthProtocol) {
+		return fmt.Errorf("invalid auth_protocol %q, must be one of %v", v.AuthProtocol, SNMPv3AuthProtocols)
+	}
+
+	if v.PrivProtocol != "" {
+		if !slices.Contains(SNMPv3PrivProtocols, v.PrivProtocol) {
+			return fmt.Errorf("invalid priv_protocol %q, must be one of %v", v.PrivProtocol, SNMPv3PrivProtocols)
+		}
+
+		if v.AuthProtocol == "" {
+			return fmt.Errorf("priv_protocol requires auth_protocol")
+		}
+	}
+
+	if v.AuthProtocol != "" && len(v.AuthPassphrase) < 8 {
+		return fmt.Errorf("auth_passphrase must be at least 8 characters")
+	}
+
+	if v.PrivProtocol != "" && len(v.PrivPassphrase) < 8 {
+		return fmt.Errorf("priv_passphrase must be at least 8 characters")
+	}
+
+	return nil
+}
+
+func (c *Config) validateTrapsConfig() error {
+	if !c.Traps.Enabled {
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(c.Traps.Listen); err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", c.Traps.Listen, err)
+	}
+
+	if c.Traps.CollectDelay.Duration < 0 {
+		return fmt.Errorf("collect_delay must not be negative")
+	}
+
+	v3 := c.Traps.V3
+	if v3.Username == "" {
+		return nil
+	}
+
+	if err := v3.Validate(); err != nil {
+		return fmt.Errorf("v3: %w", err)
+	}
+
+	if id, err := hex.DecodeString(v3.EngineID); err != nil || len(id) < 5 || len(id) > 32 {
+		return fmt.Errorf("v3: engine_id must be 5 to 32 bytes in hex")
+	}
+
+	return nil
+}
+
+func validateEmailConfig(e EmailConfig) error {
+	if e.Port < 1 || e.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", e.Port)
+	}
+
+	if e.TLS && e.StartTLS != nil && *e.StartTLS {
+		return fmt.Errorf("tls and starttls are mutually exclusive")
+	}
+
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		return fmt.Errorf("invalid from address %q: %w", e.From, err)
+	}
+
+	if len(e.To) == 0 && len(e.Recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required in to or recipients")
+	}
+
+	addresses := slices.Clone(e.To)
+	for _, recipients := range e.Recipients {
+		addresses = append(addresses, recipients...)
+	}
+
+	for _, address := range addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", address, err)
+		}
+	}
+
+	validKinds := []string{"supply", "tray", "alert", "unreachable"}
+
+	for _, kind := range e.Kinds {
+		if !slices.Contains(validKinds, kind) {
+			return fmt.Errorf("invalid kind %q, must be one of %v", kind, validKinds)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateLoggingConfig() error {
+	validLevels := map[string]bool{
+		"debug": true,
+		"info":  true,
+		"warn":  true,
+		"error": true,
+	}
+	if !validLevels[c.Logging.Level] {
+		return fmt.Errorf("invalid logging level: %s", c.Logging.Level)
 	}
 
 	validFormats := map[string]bool{
@@ -228,9 +1663,189 @@ func (c *Config) validatePrinterConfig() error {
 		return fmt.Errorf("printer type is required")
 	}
 
+	validSources := map[string]bool{
+		SourceSNMP: true,
+		SourceIPP:  true,
+		SourceWeb:  true,
+		SourcePJL:  true,
+	}
+
+	if !validSources[c.Printer.Source] {
+		return fmt.Errorf("invalid source: %s", c.Printer.Source)
+	}
+
+	seenSources := make(map[string]bool, len(c.Printer.Sources))
+
+	for _, source := range c.Printer.Sources {
+		if !validSources[source.Name] {
+			return fmt.Errorf("invalid source: %s", source.Name)
+		}
+
+		if seenSources[source.Name] {
+			return fmt.Errorf("source %s is listed more than once", source.Name)
+		}
+
+		seenSources[source.Name] = true
+
+		for _, section := range source.Sections {
+			if !slices.Contains(printer.Sections, section) {
+				return fmt.Errorf("invalid section %s for source %s", section, source.Name)
+			}
+		}
+	}
+
+	if c.Printer.IPP.Port < 1 || c.Printer.IPP.Port > 65535 {
+		return fmt.Errorf("ipp port must be between 1 and 65535, got %d", c.Printer.IPP.Port)
+	}
+
+	if !strings.HasPrefix(c.Printer.IPP.Path, "/") {
+		return fmt.Errorf("ipp path must start with /, got %s", c.Printer.IPP.Path)
+	}
+
+	if c.Printer.Web.Port < 1 || c.Printer.Web.Port > 65535 {
+		return fmt.Errorf("web port must be between 1 and 65535, got %d", c.Printer.Web.Port)
+	}
+
+	if c.Printer.PJL.Port < 1 || c.Printer.PJL.Port > 65535 {
+		return fmt.Errorf("pjl port must be between 1 and 65535, got %d", c.Printer.PJL.Port)
+	}
+
+	if c.Printer.ESCL.Port < 1 || c.Printer.ESCL.Port > 65535 {
+		return fmt.Errorf("escl port must be between 1 and 65535, got %d", c.Printer.ESCL.Port)
+	}
+
+	if !strings.HasPrefix(c.Printer.ESCL.Path, "/") {
+		return fmt.Errorf("escl path must start with /, got %s", c.Printer.ESCL.Path)
+	}
+
+	if c.Printer.PJL.BusyRetries < 0 {
+		return fmt.Errorf("pjl busy_retries must not be negative, got %d", c.Printer.PJL.BusyRetries)
+	}
+
+	validPolicies := map[string]bool{
+		OnUnreachableKeep:      true,
+		OnUnreachableDrop:      true,
+		OnUnreachableMarkStale: true,
+	}
+	if !validPolicies[c.Printer.OnUnreachable] {
+		return fmt.Errorf("invalid on_unreachable policy: %s", c.Printer.OnUnreachable)
+	}
+
+	for key := range c.Printer.Thresholds {
+		consumable, color, ok := splitThresholdKey(key)
+		if !ok {
+			return fmt.Errorf("unknown consumable in thresholds: %s", key)
+		}
+
+		t := c.Printer.ThresholdsFor(consumable, color)
+
+		if t.Warning < 0 || t.Warning > 100 || t.Critical < 0 || t.Critical > 100 || t.Empty < 0 || t.Empty > 100 {
+			return fmt.Errorf("thresholds for %s must be between 0 and 100", key)
+		}
+
+		if t.Empty > t.Critical || t.Critical > t.Warning {
+			return fmt.Errorf("thresholds for %s must satisfy empty <= critical <= warning", key)
+		}
+
+		if t.Hysteresis < 0 {
+			return fmt.Errorf("hysteresis for %s must not be negative", key)
+		}
+	}
+
+	for name := range c.Printer.Labels {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name: %s", name)
+		}
+
+		if slices.Contains(ReservedLabelNames, name) {
+			return fmt.Errorf("label name %s is already used by the exporter", name)
+		}
+
+		if value := c.Printer.Labels[name]; strings.Contains(value, "{{") {
+			if _, err := template.New(name).Parse(value); err != nil {
+				return fmt.Errorf("invalid template for label %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func (c *Config) validateCollectorsConfig() error {
+	enabled := 0
+
+	for _, name := range Subsystems {
+		if c.SubsystemEnabled(name) {
+			enabled++
+		}
+	}
+
+	if enabled == 0 {
+		return fmt.Errorf("at least one collector must be enabled")
+	}
+
+	for name, sub := range c.Collectors {
+		if !slices.Contains(Subsystems, name) {
+			return fmt.Errorf("unknown collector: %s", name)
+		}
+
+		if sub.Interval.Duration != 0 && sub.Interval.Duration < time.Second {
+			return fmt.Errorf("%s interval must be at least 1 second, got %s", name, sub.Interval.Duration)
+		}
+	}
+
 	return nil
 }
 
+// splitThresholdKey splits a thresholds key into consumable and colour
+func splitThresholdKey(key string) (consumable, color string, ok bool) {
+	if key == "default" || validConsumables[key] {
+		return key, "", true
+	}
+
+	idx := strings.LastIndex(key, "_")
+	if idx == -1 || !validConsumables[key[:idx]] {
+		return "", "", false
+	}
+
+	return key[:idx], key[idx+1:], true
+}
+
+// validConsumables lists the consumable names accepted as threshold keys
+var validConsumables = map[string]bool{
+	"toner":             true,
+	"drum":              true,
+	"ink":               true,
+	"belt_unit":         true,
+	"fuser_unit":        true,
+	"laser_unit":        true,
+	"paper_feeding_kit": true,
+}
+
+// SubsystemEnabled reports whether a collection subsystem is enabled
+// (defaults to true, except for opt-in subsystems such as scanner)
+func (c *Config) SubsystemEnabled(name string) bool {
+	sub, ok := c.Collectors[name]
+	if !ok || sub.Enabled == nil {
+		return !optInSubsystems[name]
+	}
+
+	return *sub.Enabled
+}
+
+// SubsystemInterval returns the collection interval of a subsystem, falling
+// back to the default interval
+func (c *Config) SubsystemInterval(name string) time.Duration {
+	if sub, ok := c.Collectors[name]; ok && sub.Interval.Duration > 0 {
+		return sub.Interval.Duration
+	}
+
+	return c.Metrics.Collection.DefaultInterval.Duration
+}
+
 // GetDefaultInterval returns the default collection interval
 func (c *Config) GetDefaultInterval() int {
 	return c.Metrics.Collection.DefaultInterval.Seconds()
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadWithLabels(labels map[string]string) (*Config, error) {
+	return LoadConfigWith("", func(cfg *Config) {
+		cfg.Printer.Host = "192.168.1.100"
+		cfg.Printer.Labels = labels
+	})
+}
+
+func TestValidate_ReservedLabelNames(t *testing.T) {
+	reserved := ReservedLabelNames
+	assert.Subset(t, reserved, []string{"host", "step", "result", "state", "adf", "duplex", "source", "code", "severity"})
+
+	for _, name := range reserved {
+		_, err := loadWithLabels(map[string]string{name: "x"})
+		assert.ErrorContains(t, err, "label name "+name+" is already used", name)
+	}
+}
+
+func TestValidate_CustomLabels(t *testing.T) {
+	cfg, err := loadWithLabels(map[string]string{"site": "dublin", "location": "{{.SysLocation}}"})
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"), cfg.Printer.LabelNames()...)
+	})
+}
+
+func TestAPIConfig_OptIn(t *testing.T) {
+	cfg, err := loadWithLabels(nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.API.Enabled)
+
+	t.Setenv("BROTHER_EXPORTER_API_ENABLED", "true")
+
+	cfg, err = loadWithLabels(nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.API.Enabled)
+}
+
+func TestRenderConfigHTML_DashboardLink(t *testing.T) {
+	cfg, err := loadWithLabels(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "disabled, set api.enabled to serve it", cfg.GetDisplayConfig()[dashboardDisplayKey])
+
+	_, ok := cfg.RenderConfigHTML(dashboardDisplayKey, nil)
+	assert.False(t, ok)
+
+	cfg.API.Enabled = true
+	cfg.API.Host = "192.168.1.5"
+	cfg.API.Port = 8081
+
+	link, ok := cfg.RenderConfigHTML(dashboardDisplayKey, nil)
+	require.True(t, ok)
+	assert.Equal(t, `<a href="http://192.168.1.5:8081/">http://192.168.1.5:8081/</a>`, link)
+
+	cfg.API.Host = "0.0.0.0"
+
+	link, ok = cfg.RenderConfigHTML(dashboardDisplayKey, nil)
+	require.True(t, ok)
+	assert.Contains(t, link, `location.hostname+":8081/"`)
+
+	_, ok = cfg.RenderConfigHTML("Server Port", nil)
+	assert.False(t, ok)
+}
+
+func loadWithThresholds(warning, critical map[string]float64) (*Config, error) {
+	var thresholdErr error
+
+	cfg, err := LoadConfigWith("", func(cfg *Config) {
+		cfg.Printer.Host = "192.168.1.100"
+		thresholdErr = cfg.Printer.OverrideThresholds(warning, critical)
+	})
+	if err == nil {
+		err = thresholdErr
+	}
+
+	return cfg, err
+}
+
+func TestOverrideThresholds_SingleLevel(t *testing.T) {
+	cfg, err := loadWithThresholds(nil, map[string]float64{"toner": 20})
+	require.NoError(t, err)
+
+	toner := cfg.Printer.ThresholdsFor("toner", "cyan")
+	assert.InDelta(t, 20, toner.Critical, 0)
+	assert.InDelta(t, 20, toner.Warning, 0)
+	assert.InDelta(t, 10, cfg.Printer.ThresholdsFor("drum", "").Warning, 0)
+
+	cfg, err = loadWithThresholds(map[string]float64{"toner_cyan": 3}, nil)
+	require.NoError(t, err)
+
+	cyan := cfg.Printer.ThresholdsFor("toner", "cyan")
+	assert.InDelta(t, 3, cyan.Warning, 0)
+	assert.InDelta(t, 3, cyan.Critical, 0)
+	assert.InDelta(t, 5, cfg.Printer.ThresholdsFor("toner", "black").Critical, 0)
+}
+
+func TestOverrideThresholds_InvertedPair(t *testing.T) {
+	_, err := loadWithThresholds(map[string]float64{"toner": 10}, map[string]float64{"toner": 20})
+	assert.ErrorContains(t, err, "critical level toner=20 is above its warning level toner=10")
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+)
+
+// dashboardDisplayKey is the entry of the promexporter status page that links
+// the printer dashboard, since that page itself can't be replaced
+const dashboardDisplayKey = "Printer Dashboard"
+
+// GetDisplayConfig adds the printer dashboard to the configuration shown on
+// the status page of the metrics port
+func (c *Config) GetDisplayConfig() map[string]interface{} {
+	display := c.BaseConfig.GetDisplayConfig()
+
+	if c.API.Enabled {
+		display[dashboardDisplayKey] = c.API.URL() + "/"
+	} else {
+		display[dashboardDisplayKey] = "disabled, set api.enabled to serve it"
+	}
+
+	return display
+}
+
+// RenderConfigHTML turns the dashboard entry of the status page into a link.
+// When the API listens on every interface the link follows the host the
+// page was loaded from.
+func (c *Config) RenderConfigHTML(key string, _ interface{}) (string, bool) {
+	if key != dashboardDisplayKey || !c.API.Enabled {
+		return "", false
+	}
+
+	if !isWildcardHost(c.API.Host) {
+		url := html.EscapeString(c.API.URL() + "/")
+
+		return fmt.Sprintf(`<a href="%s">%s</a>`, url, url), true
+	}
+
+	port := strconv.Itoa(c.API.Port)
+
+	return `<a id="printer-dashboard" href="/">port ` + port + `</a>` +
+		`<script>(function(a){a.href=location.protocol+"//"+location.hostname+":` + port + `/";a.textContent=a.href})` +
+		`(document.getElementById("printer-dashboard"))</script>`, true
+}
@@ -0,0 +1,246 @@
+// Package events turns consecutive collections of a printer into typed
+// events, such as a status change or a replaced toner, and logs them to a
+// rotating JSON Lines file for the API.
+package events
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Event types
+const (
+	TypeStatusChanged        = "status_changed"
+	TypeAlertRaised          = "alert_raised"
+	TypeAlertCleared         = "alert_cleared"
+	TypeConsumableReplaced   = "consumable_replaced"
+	TypeThresholdCrossed     = "threshold_crossed"
+	TypeTrayChanged          = "tray_changed"
+	TypePrinterRestarted     = "printer_restarted"
+	TypeFirmwareChanged      = "firmware_changed"
+	TypeConnectivityLost     = "connectivity_lost"
+	TypeConnectivityRestored = "connectivity_restored"
+)
+
+// Types lists every event type
+var Types = []string{
+	TypeStatusChanged,
+	TypeAlertRaised,
+	TypeAlertCleared,
+	TypeConsumableReplaced,
+	TypeThresholdCrossed,
+	TypeTrayChanged,
+	TypePrinterRestarted,
+	TypeFirmwareChanged,
+	TypeConnectivityLost,
+	TypeConnectivityRestored,
+}
+
+const (
+	// replacedRise is how many points a supply level must rise between two
+	// collections to count as replaced rather than re-estimated
+	replacedRise = 20
+	// bootTimeSlack absorbs the jitter of a boot time derived from the
+	// uptime, so only a real restart moves it further
+	bootTimeSlack = time.Minute
+)
+
+// Event is one change of one printer. Subject is what changed within the
+// printer, e.g. an alert code, a supply or a tray, and From and To its
+// values before and after.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Printer  string    `json:"printer"`
+	Host     string    `json:"host"`
+	Type     string    `json:"type"`
+	Subject  string    `json:"subject,omitempty"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// Diff returns the events between two consecutive states of a printer.
+// Sections are only compared when both states have them, so a section
+// that was not collected yet raises nothing.
+func Diff(prev, cur printer.State) []Event {
+	d := differ{state: cur, time: eventTime(cur)}
+
+	// A trap can patch the state before the first cycle ran
+	if !prev.LastCollection.IsZero() && prev.Reachable != cur.Reachable {
+		if cur.Reachable {
+			d.add(Event{Type: TypeConnectivityRestored, Message: "Printer is reachable again"})
+		} else {
+			d.add(Event{Type: TypeConnectivityLost, Message: "Printer is unreachable"})
+		}
+	}
+
+	old, snap := prev.Snapshot, cur.Snapshot
+	if old == nil || snap == nil {
+		return d.events
+	}
+
+	if old.Status != nil && snap.Status != nil {
+		d.status(old, snap)
+		d.alerts(old.Alerts, snap.Alerts)
+	}
+
+	d.supplies(old.Supplies, snap.Supplies)
+	d.trays(old.Trays, snap.Trays)
+
+	if !old.BootTime.IsZero() && snap.BootTime.Sub(old.BootTime) > bootTimeSlack {
+		d.add(Event{
+			Type:    TypePrinterRestarted,
+			From:    old.BootTime.UTC().Format(time.RFC3339),
+			To:      snap.BootTime.UTC().Format(time.RFC3339),
+			Message: "Printer restarted at " + snap.BootTime.UTC().Format(time.RFC3339),
+		})
+	}
+
+	if old.Identity != nil && snap.Identity != nil && old.Identity.Firmware != "" &&
+		snap.Identity.Firmware != "" && old.Identity.Firmware != snap.Identity.Firmware {
+		d.add(Event{
+			Type:    TypeFirmwareChanged,
+			From:    old.Identity.Firmware,
+			To:      snap.Identity.Firmware,
+			Message: fmt.Sprintf("Firmware changed from %s to %s", old.Identity.Firmware, snap.Identity.Firmware),
+		})
+	}
+
+	return d.events
+}
+
+// eventTime is when the newer of the last cycle and the last patch ran
+func eventTime(state printer.State) time.Time {
+	t := state.LastCollection
+	if state.Snapshot != nil && state.Snapshot.CollectedAt.After(t) {
+		t = state.Snapshot.CollectedAt
+	}
+
+	return t.UTC()
+}
+
+type differ struct {
+	state  printer.State
+	time   time.Time
+	events []Event
+}
+
+func (d *differ) add(event Event) {
+	event.Time = d.time
+	event.Printer = d.state.ID
+	event.Host = d.state.Host
+	d.events = append(d.events, event)
+}
+
+func (d *differ) status(old, snap *printer.Snapshot) {
+	if old.Status.State == snap.Status.State {
+		return
+	}
+
+	message := fmt.Sprintf("Status changed from %s to %s", old.Status.State, snap.Status.State)
+	if snap.Status.Message != "" {
+		message += ": " + snap.Status.Message
+	}
+
+	d.add(Event{Type: TypeStatusChanged, From: old.Status.State, To: snap.Status.State, Message: message})
+}
+
+func (d *differ) alerts(old, alerts []printer.Alert) {
+	has := func(alerts []printer.Alert, code string) bool {
+		return slices.ContainsFunc(alerts, func(alert printer.Alert) bool { return alert.Code == code })
+	}
+
+	for _, alert := range alerts {
+		if !has(old, alert.Code) {
+			d.add(Event{
+				Type:     TypeAlertRaised,
+				Subject:  alert.Code,
+				Severity: alert.Severity,
+				Message:  "Printer reports: " + alertText(alert),
+			})
+		}
+	}
+
+	for _, alert := range old {
+		if !has(alerts, alert.Code) {
+			d.add(Event{
+				Type:     TypeAlertCleared,
+				Subject:  alert.Code,
+				Severity: alert.Severity,
+				Message:  "Cleared: " + alertText(alert),
+			})
+		}
+	}
+}
+
+func alertText(alert printer.Alert) string {
+	if alert.Message != "" {
+		return alert.Message
+	}
+
+	return strings.ReplaceAll(alert.Code, "_", " ")
+}
+
+func (d *differ) supplies(old, supplies []printer.Supply) {
+	for _, supply := range supplies {
+		i := slices.IndexFunc(old, func(have printer.Supply) bool {
+			return have.Kind == supply.Kind && have.Color == supply.Color
+		})
+		if i < 0 {
+			continue
+		}
+
+		prev := old[i]
+		name := printer.SupplyName(supply)
+
+		subject := supply.Kind
+		if supply.Color != "" {
+			subject += "/" + supply.Color
+		}
+
+		switch {
+		case supply.Percent-prev.Percent >= replacedRise:
+			d.add(Event{
+				Type:    TypeConsumableReplaced,
+				Subject: subject,
+				From:    formatPercent(prev.Percent),
+				To:      formatPercent(supply.Percent),
+				Message: fmt.Sprintf("%s replaced (%s → %s)", name, formatPercent(prev.Percent), formatPercent(supply.Percent)),
+			})
+		case prev.State != "" && supply.State != "" && prev.State != supply.State:
+			d.add(Event{
+				Type:    TypeThresholdCrossed,
+				Subject: subject,
+				From:    prev.State,
+				To:      supply.State,
+				Message: fmt.Sprintf("%s is %s (%s)", name, supply.State, formatPercent(supply.Percent)),
+			})
+		}
+	}
+}
+
+func formatPercent(percent float64) string {
+	return fmt.Sprintf("%.0f%%", percent)
+}
+
+func (d *differ) trays(old, trays []printer.Tray) {
+	for _, tray := range trays {
+		i := slices.IndexFunc(old, func(have printer.Tray) bool { return have.Name == tray.Name })
+		if i < 0 || old[i].Status == tray.Status {
+			continue
+		}
+
+		d.add(Event{
+			Type:    TypeTrayChanged,
+			Subject: tray.Name,
+			From:    old[i].Status,
+			To:      tray.Status,
+			Message: fmt.Sprintf("Paper tray %s changed from %s to %s", tray.Name, old[i].Status, tray.Status),
+		})
+	}
+}
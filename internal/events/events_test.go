@@ -0,0 +1,102 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+func state(at time.Time, reachable bool, snap *printer.Snapshot) printer.State {
+	snap.CollectedAt = at
+
+	return printer.State{ID: "office", Host: "192.168.1.100", Reachable: reachable, LastCollection: at, Snapshot: snap}
+}
+
+func TestDiff(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	boot := t0.Add(-48 * time.Hour)
+
+	prev := state(t0, true, &printer.Snapshot{
+		Identity: &printer.Identity{Firmware: "1.20"},
+		Status:   &printer.Status{State: "ready"},
+		Alerts:   []printer.Alert{{Code: "low_toner", Severity: printer.SeverityWarning}},
+		BootTime: boot,
+		Supplies: []printer.Supply{
+			{Kind: "toner", Color: "black", Percent: 3, State: "critical"},
+			{Kind: "toner", Color: "cyan", Percent: 25, State: "ok"},
+			{Kind: "drum", Percent: 50, State: "ok"},
+		},
+		Trays: []printer.Tray{{Name: "tray1", Status: "ok"}, {Name: "tray2", Status: "ok"}},
+	})
+
+	cur := state(t1, true, &printer.Snapshot{
+		Identity: &printer.Identity{Firmware: "1.21"},
+		Status:   &printer.Status{State: "stopped", Message: "Paper Jam"},
+		Alerts:   []printer.Alert{{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam"}},
+		// Boot time jitter is not a restart
+		BootTime: boot.Add(time.Second),
+		Supplies: []printer.Supply{
+			{Kind: "toner", Color: "black", Percent: 100, State: "ok"},
+			{Kind: "toner", Color: "cyan", Percent: 18, State: "low"},
+			{Kind: "drum", Percent: 49, State: "ok"},
+			{Kind: "toner", Color: "yellow", Percent: 10, State: "low"},
+		},
+		Trays: []printer.Tray{{Name: "tray1", Status: "ok"}, {Name: "tray2", Status: "empty"}},
+	})
+
+	base := Event{Time: t1, Printer: "office", Host: "192.168.1.100"}
+	event := func(e Event) Event {
+		e.Time, e.Printer, e.Host = base.Time, base.Printer, base.Host
+		return e
+	}
+
+	assert.Equal(t, []Event{
+		event(Event{Type: TypeStatusChanged, From: "ready", To: "stopped", Message: "Status changed from ready to stopped: Paper Jam"}),
+		event(Event{Type: TypeAlertRaised, Subject: "jammed", Severity: printer.SeverityCritical, Message: "Printer reports: Paper Jam"}),
+		event(Event{Type: TypeAlertCleared, Subject: "low_toner", Severity: printer.SeverityWarning, Message: "Cleared: low toner"}),
+		event(Event{Type: TypeConsumableReplaced, Subject: "toner/black", From: "3%", To: "100%", Message: "Toner black replaced (3% → 100%)"}),
+		event(Event{Type: TypeThresholdCrossed, Subject: "toner/cyan", From: "ok", To: "low", Message: "Toner cyan is low (18%)"}),
+		event(Event{Type: TypeTrayChanged, Subject: "tray2", From: "ok", To: "empty", Message: "Paper tray tray2 changed from ok to empty"}),
+		event(Event{Type: TypeFirmwareChanged, From: "1.20", To: "1.21", Message: "Firmware changed from 1.20 to 1.21"}),
+	}, Diff(prev, cur))
+
+	assert.Empty(t, Diff(cur, cur))
+}
+
+func TestDiffRestartAndConnectivity(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	boot := t0.Add(-48 * time.Hour)
+
+	up := state(t0, true, &printer.Snapshot{BootTime: boot, Status: &printer.Status{State: "ready"}})
+
+	// Unreachable cycles keep the last snapshot
+	down := up
+	down.Reachable = false
+	down.LastCollection = t0.Add(time.Minute)
+
+	lost := Diff(up, down)
+	if assert.Len(t, lost, 1) {
+		assert.Equal(t, TypeConnectivityLost, lost[0].Type)
+		assert.Equal(t, t0.Add(time.Minute), lost[0].Time)
+	}
+
+	restarted := state(t0.Add(2*time.Minute), true, &printer.Snapshot{
+		BootTime: t0.Add(90 * time.Second),
+		Status:   &printer.Status{State: "ready"},
+	})
+
+	got := Diff(down, restarted)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, TypeConnectivityRestored, got[0].Type)
+		assert.Equal(t, TypePrinterRestarted, got[1].Type)
+		assert.Equal(t, "2026-03-01T12:01:30Z", got[1].To)
+	}
+
+	// A state patched by a trap before the first cycle has no reachability
+	// to compare against
+	patched := printer.State{ID: "office", Snapshot: &printer.Snapshot{}}
+	assert.Empty(t, Diff(patched, up))
+}
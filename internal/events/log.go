@@ -0,0 +1,351 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// subscriberBuffer is how many events a live subscriber may fall behind by
+// before events are dropped for it
+const subscriberBuffer = 64
+
+// Filter selects events. Empty fields match every event; From and To are
+// inclusive.
+type Filter struct {
+	Printer string
+	Types   []string
+	From    time.Time
+	To      time.Time
+}
+
+// Match reports whether event passes the filter
+func (f Filter) Match(event Event) bool {
+	switch {
+	case f.Printer != "" && event.Printer != f.Printer:
+		return false
+	case len(f.Types) > 0 && !slices.Contains(f.Types, event.Type):
+		return false
+	case !f.From.IsZero() && event.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && event.Time.After(f.To):
+		return false
+	default:
+		return true
+	}
+}
+
+// Log diffs each printer's state against the previous one and appends the
+// events to a JSON Lines file, rotated by size, and to live subscribers. It
+// implements the promexporter collector interface.
+type Log struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// closed is set by Stop, after which the collector may still finish a
+	// cycle
+	closed bool
+	// last is the previous state of each printer. Events are diffed from
+	// the exporter's start, a restart sees no changes.
+	last        map[string]printer.State
+	subscribers map[chan Event]struct{}
+}
+
+// Open opens the log at cfg.Path for appending, creating it and its
+// directory if needed
+func Open(cfg config.EventsConfig) (*Log, error) {
+	l := &Log{
+		path:        cfg.Path,
+		maxSize:     int64(cfg.MaxSizeMB) << 20,
+		maxFiles:    cfg.MaxFiles,
+		last:        make(map[string]printer.State),
+		subscribers: make(map[chan Event]struct{}),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the event log directory: %w", err)
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat event log: %w", err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+// Start does nothing, the log is written by Observe
+func (l *Log) Start(_ context.Context) {}
+
+// Stop closes the file and ends the live subscriptions
+func (l *Log) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			slog.Error("Failed to close event log", "error", err)
+		}
+
+		l.file = nil
+	}
+
+	for ch := range l.subscribers {
+		close(ch)
+		delete(l.subscribers, ch)
+	}
+}
+
+// Observe logs the changes since the printer's previous state. The first
+// state of a printer only becomes the baseline.
+func (l *Log) Observe(state printer.State) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	prev, ok := l.last[state.ID]
+	l.last[state.ID] = state
+
+	if !ok {
+		return
+	}
+
+	for _, event := range Diff(prev, state) {
+		slog.Info("Printer event", "printer", event.Printer, "type", event.Type, "subject", event.Subject, "message", event.Message)
+
+		if err := l.write(event); err != nil {
+			slog.Error("Failed to write event", "printer", event.Printer, "type", event.Type, "error", err)
+		}
+
+		l.broadcast(event)
+	}
+}
+
+// write appends an event to the file, rotating it first when full
+func (l *Log) write(event Event) error {
+	if l.file == nil {
+		// A failed rotation left no file, try again
+		if err := l.open(); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+// rotate shifts the rotated files up by one, dropping the oldest, and
+// starts a new file
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close event log: %w", err)
+	}
+
+	l.file = nil
+
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(l.rotated(i), l.rotated(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to rotate event log: %w", err)
+		}
+	}
+
+	if err := os.Rename(l.path, l.rotated(1)); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+
+	return l.open()
+}
+
+// rotated is the path of the i-th most recent rotated file
+func (l *Log) rotated(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+// Query returns the events matching filter from the log files, oldest
+// first. A positive limit keeps only the most recent events.
+func (l *Log) Query(filter Filter, limit int) ([]Event, error) {
+	files, size, err := l.openFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	var events []Event
+
+	for _, file := range files {
+		var r io.Reader = file
+
+		// The current file is read up to its size when opened, as Observe
+		// may be appending to it
+		if file.Name() == l.path {
+			r = io.LimitReader(file, size)
+		}
+
+		err := readEvents(r, file.Name(), func(event Event) {
+			if !filter.Match(event) {
+				return
+			}
+
+			events = append(events, event)
+
+			if limit > 0 && len(events) > 2*limit {
+				events = slices.Delete(events, 0, len(events)-limit)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	return events, nil
+}
+
+// openFiles opens the log files, oldest first, and returns them with the
+// size of the current one. The lock is only held while opening, so a query
+// doesn't hold up Observe; a rotation meanwhile renames the open files
+// without affecting what is read.
+func (l *Log) openFiles() ([]*os.File, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var files []*os.File
+
+	for i := l.maxFiles; i >= 0; i-- {
+		path := l.path
+		if i > 0 {
+			path = l.rotated(i)
+		}
+
+		file, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			for _, file := range files {
+				_ = file.Close()
+			}
+
+			return nil, 0, fmt.Errorf("failed to open event log: %w", err)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, l.size, nil
+}
+
+// readEvents calls fn for every event read from path. Lines that don't
+// decode, such as one cut short by a crash, are skipped.
+func readEvents(r io.Reader, path string, fn func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			slog.Debug("Skipping malformed event", "path", path, "error", err)
+			continue
+		}
+
+		fn(event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event log %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel receiving every new event and a function
+// ending the subscription. The channel is closed when that is called or
+// the log stops. Events are dropped for a subscriber that falls behind.
+func (l *Log) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	l.mu.Lock()
+	l.subscribers[ch] = struct{}{}
+	l.mu.Unlock()
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.subscribers[ch]; ok {
+			close(ch)
+			delete(l.subscribers, ch)
+		}
+	}
+}
+
+func (l *Log) broadcast(event Event) {
+	for ch := range l.subscribers {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("Dropping event for a slow subscriber", "type", event.Type)
+		}
+	}
+}
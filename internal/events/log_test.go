@@ -0,0 +1,170 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openLog(t *testing.T, cfg config.EventsConfig) *Log {
+	t.Helper()
+
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(t.TempDir(), "data", "events.jsonl")
+	}
+
+	cfg.MaxSizeMB = max(cfg.MaxSizeMB, 1)
+	cfg.MaxFiles = max(cfg.MaxFiles, 1)
+
+	l, err := Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(l.Stop)
+
+	return l
+}
+
+// observeStatus records a cycle in which the printer has status
+func observeStatus(l *Log, at time.Time, status string) {
+	l.Observe(state(at, true, &printer.Snapshot{Status: &printer.Status{State: status}}))
+}
+
+func TestLog(t *testing.T) {
+	l := openLog(t, config.EventsConfig{})
+
+	updates, cancel := l.Subscribe()
+	defer cancel()
+
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// The first state is the baseline
+	observeStatus(l, t0, "ready")
+	observeStatus(l, t0.Add(time.Minute), "printing")
+	observeStatus(l, t0.Add(2*time.Minute), "ready")
+	l.Observe(state(t0.Add(3*time.Minute), false, &printer.Snapshot{Status: &printer.Status{State: "ready"}}))
+
+	all, err := l.Query(Filter{}, 0)
+	require.NoError(t, err)
+
+	if assert.Len(t, all, 3) {
+		assert.Equal(t, "printing", all[0].To)
+		assert.Equal(t, t0.Add(time.Minute), all[0].Time)
+		assert.Equal(t, TypeConnectivityLost, all[2].Type)
+	}
+
+	assert.Equal(t, all[0], <-updates)
+
+	recent, err := l.Query(Filter{Types: []string{TypeStatusChanged}}, 1)
+	require.NoError(t, err)
+
+	if assert.Len(t, recent, 1) {
+		assert.Equal(t, "ready", recent[0].To)
+	}
+
+	none, err := l.Query(Filter{Printer: "reception"}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	ranged, err := l.Query(Filter{From: t0.Add(2 * time.Minute), To: t0.Add(2 * time.Minute)}, 0)
+	require.NoError(t, err)
+	assert.Len(t, ranged, 1)
+
+	// Stopping ends the subscriptions and further writes
+	l.Stop()
+
+	_, open := <-updates
+	for open {
+		_, open = <-updates
+	}
+
+	observeStatus(l, t0.Add(4*time.Minute), "printing")
+
+	all, err = l.Query(Filter{}, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l := openLog(t, config.EventsConfig{Path: path, MaxFiles: 2})
+	l.maxSize = 1024
+
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []string{"ready", "printing"}
+
+	for i := range 40 {
+		observeStatus(l, t0.Add(time.Duration(i)*time.Minute), statuses[i%2])
+	}
+
+	for _, name := range []string{"events.jsonl", "events.jsonl.1", "events.jsonl.2"} {
+		info, err := os.Stat(filepath.Join(filepath.Dir(path), name))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1024))
+	}
+
+	assert.NoFileExists(t, path+".3")
+
+	// The oldest events were dropped with the oldest file, the rest are in
+	// order
+	all, err := l.Query(Filter{}, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	assert.Less(t, len(all), 39)
+	assert.Equal(t, t0.Add(39*time.Minute), all[len(all)-1].Time)
+
+	for i := 1; i < len(all); i++ {
+		assert.Equal(t, time.Minute, all[i].Time.Sub(all[i-1].Time))
+	}
+
+	// A line cut short by a crash is skipped after the restart
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"type":"status_ch`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	l.Stop()
+	l = openLog(t, config.EventsConfig{Path: path, MaxFiles: 2})
+
+	again, err := l.Query(Filter{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, all, again)
+}
+
+func TestLogQueryWhileRotating(t *testing.T) {
+	l := openLog(t, config.EventsConfig{MaxFiles: 2})
+	l.maxSize = 512
+
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []string{"ready", "printing"}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := range 200 {
+			observeStatus(l, t0.Add(time.Duration(i)*time.Minute), statuses[i%2])
+		}
+	}()
+
+	// Queries see whole events in order, whatever rotation happens meanwhile
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		all, err := l.Query(Filter{}, 0)
+		require.NoError(t, err)
+
+		for i := 1; i < len(all); i++ {
+			assert.Positive(t, all[i].Time.Sub(all[i-1].Time))
+		}
+	}
+}
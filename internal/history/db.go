@@ -0,0 +1,284 @@
+// Package history keeps periodic snapshots of each printer's counters and
+// consumable levels in an embedded bbolt database, for queries beyond the
+// Prometheus retention such as billing by date.
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// printersBucket holds a bucket per printer ID, keyed by snapshot time
+var printersBucket = []byte("printers")
+
+// Snapshot is a printer's counters and consumable levels at a point in time
+type Snapshot struct {
+	Time time.Time `json:"time"`
+
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Location string `json:"location,omitempty"`
+
+	// Counters are the page counters by name (total, black, color, duplex,
+	// drum_black, ...)
+	Counters map[string]float64 `json:"counters,omitempty"`
+	Supplies []Supply           `json:"supplies,omitempty"`
+	// Maintenance holds the remaining pages of maintenance parts by kind
+	Maintenance map[string]float64 `json:"maintenance,omitempty"`
+}
+
+// Supply is the level of a consumable or maintenance part in percent
+type Supply struct {
+	Kind    string  `json:"kind"`
+	Color   string  `json:"color,omitempty"`
+	Percent float64 `json:"percent"`
+}
+
+// lockTimeout bounds how long opening waits for another process holding
+// the file, as bbolt locks it while open
+const lockTimeout = time.Second
+
+// ErrInUse is returned when another process, such as a running exporter,
+// holds the database
+var ErrInUse = errors.New("history database is in use by another process")
+
+// DB is the snapshot database
+type DB struct {
+	db *bolt.DB
+}
+
+// Open opens the database at path, creating it and its directory if needed
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// The timeout fails fast when another exporter holds the file
+	db, err := open(path, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(printersBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialise history database: %w", err)
+	}
+
+	return &DB{db: db}, nil
+}
+
+// OpenReadOnly opens an existing database for reading, failing with
+// ErrInUse while an exporter writes to it
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("history database: %w", err)
+	}
+
+	db, err := open(path, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(printersBucket) == nil {
+			return fmt.Errorf("%s is not a history database", path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &DB{db: db}, nil
+}
+
+func open(path string, opts *bolt.Options) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, opts)
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", ErrInUse, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Add stores a printer's snapshot, replacing one taken at the same time
+func (d *DB) Add(id string, snap Snapshot) error {
+	value, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(printersBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return fmt.Errorf("failed to create printer bucket: %w", err)
+		}
+
+		return bucket.Put(timeKey(snap.Time), value)
+	})
+}
+
+// Query returns a printer's snapshots taken from from to to, both
+// inclusive, oldest first. A zero from or to leaves that end open.
+func (d *DB) Query(id string, from, to time.Time) ([]Snapshot, error) {
+	var snaps []Snapshot
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(printersBucket).Bucket([]byte(id))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+
+		key, value := cursor.First()
+		if !from.IsZero() {
+			key, value = cursor.Seek(timeKey(from))
+		}
+
+		for ; key != nil; key, value = cursor.Next() {
+			if !to.IsZero() && keyTime(key).After(to) {
+				break
+			}
+
+			var snap Snapshot
+			if err := json.Unmarshal(value, &snap); err != nil {
+				return fmt.Errorf("failed to decode snapshot: %w", err)
+			}
+
+			snaps = append(snaps, snap)
+		}
+
+		return nil
+	})
+
+	return snaps, err
+}
+
+// Last returns a printer's latest snapshot, or false if it has none
+func (d *DB) Last(id string) (Snapshot, bool, error) {
+	var (
+		snap  Snapshot
+		found bool
+	)
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(printersBucket).Bucket([]byte(id))
+		if bucket == nil {
+			return nil
+		}
+
+		_, value := bucket.Cursor().Last()
+		if value == nil {
+			return nil
+		}
+
+		found = true
+
+		return json.Unmarshal(value, &snap)
+	})
+
+	return snap, found, err
+}
+
+// Next returns a printer's first snapshot taken at or after t, or false if
+// it has none
+func (d *DB) Next(id string, t time.Time) (Snapshot, bool, error) {
+	var (
+		snap  Snapshot
+		found bool
+	)
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(printersBucket).Bucket([]byte(id))
+		if bucket == nil {
+			return nil
+		}
+
+		_, value := bucket.Cursor().Seek(timeKey(t))
+		if value == nil {
+			return nil
+		}
+
+		found = true
+
+		return json.Unmarshal(value, &snap)
+	})
+
+	return snap, found, err
+}
+
+// Printers returns the IDs of the printers with snapshots, in order
+func (d *DB) Printers() ([]string, error) {
+	var ids []string
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(printersBucket).ForEachBucket(func(id []byte) error {
+			ids = append(ids, string(id))
+			return nil
+		})
+	})
+
+	return ids, err
+}
+
+// Prune deletes the snapshots taken before before and returns how many
+func (d *DB) Prune(before time.Time) (int, error) {
+	pruned := 0
+
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		printers := tx.Bucket(printersBucket)
+
+		return printers.ForEachBucket(func(id []byte) error {
+			cursor := printers.Bucket(id).Cursor()
+
+			// Keys sort by time, so the oldest are always first
+			for key, _ := cursor.First(); key != nil && keyTime(key).Before(before); key, _ = cursor.First() {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+
+				pruned++
+			}
+
+			return nil
+		})
+	})
+
+	return pruned, err
+}
+
+// timeKey encodes a time as a key that sorts chronologically
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano())) //nolint:gosec // snapshots are taken after 1970
+
+	return key
+}
+
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key))).UTC() //nolint:gosec // see timeKey
+}
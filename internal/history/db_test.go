@@ -0,0 +1,127 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDB(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "data", "history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func TestDB(t *testing.T) {
+	db := openDB(t)
+
+	day := time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)
+
+	for i := range 5 {
+		require.NoError(t, db.Add("printer", Snapshot{
+			Time:     day.AddDate(0, 0, i),
+			Serial:   "E123",
+			Counters: map[string]float64{"total": float64(1000 + 100*i)},
+		}))
+	}
+
+	require.NoError(t, db.Add("other", Snapshot{Time: day}))
+
+	snaps, err := db.Query("printer", day.AddDate(0, 0, 1), day.AddDate(0, 0, 3))
+	require.NoError(t, err)
+
+	if assert.Len(t, snaps, 3) {
+		assert.Equal(t, day.AddDate(0, 0, 1), snaps[0].Time)
+		assert.Equal(t, 1100.0, snaps[0].Counters["total"])
+		assert.Equal(t, "E123", snaps[0].Serial)
+		assert.Equal(t, day.AddDate(0, 0, 3), snaps[2].Time)
+	}
+
+	all, err := db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+
+	none, err := db.Query("missing", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	last, found, err := db.Last("printer")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1400.0, last.Counters["total"])
+
+	_, found, err = db.Last("missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	ids, err := db.Printers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other", "printer"}, ids)
+
+	// Pruning keeps the snapshots from the cutoff on
+	pruned, err := db.Prune(day.AddDate(0, 0, 3))
+	require.NoError(t, err)
+	assert.Equal(t, 4, pruned)
+
+	all, err = db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, day.AddDate(0, 0, 3), all[0].Time)
+	}
+}
+
+func TestDBReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, db.Add("printer", Snapshot{Time: time.Unix(1700000000, 0).UTC()}))
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	snaps, err := db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, snaps, 1)
+}
+
+func TestDBInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	_, err := OpenReadOnly(path)
+	require.Error(t, err)
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, db.Add("printer", Snapshot{Time: time.Unix(1700000000, 0).UTC()}))
+
+	// A second exporter or a reader is refused while the file is held
+	_, err = Open(path)
+	require.ErrorIs(t, err, ErrInUse)
+
+	_, err = OpenReadOnly(path)
+	require.ErrorIs(t, err, ErrInUse)
+
+	require.NoError(t, db.Close())
+
+	readOnly, err := OpenReadOnly(path)
+	require.NoError(t, err)
+
+	defer func() { _ = readOnly.Close() }()
+
+	snaps, err := readOnly.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, snaps, 1)
+	assert.Error(t, readOnly.Add("printer", Snapshot{Time: time.Unix(1700086400, 0).UTC()}))
+}
@@ -0,0 +1,159 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// pruneInterval is how often snapshots past the retention are deleted
+const pruneInterval = time.Hour
+
+// Recorder snapshots each printer once per interval from the store and
+// prunes old snapshots. It implements the promexporter collector interface
+// and owns the database, which Stop closes.
+type Recorder struct {
+	db        *DB
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	mu sync.Mutex
+	// last is when each printer was last snapshotted
+	last map[string]time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRecorder creates a recorder writing to db
+func NewRecorder(db *DB, cfg config.HistoryConfig) *Recorder {
+	return &Recorder{
+		db:        db,
+		interval:  cfg.Interval.Duration,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		now:       time.Now,
+		last:      make(map[string]time.Time),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start prunes the database now and every pruneInterval
+func (r *Recorder) Start(_ context.Context) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			r.prune()
+
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops pruning and closes the database
+func (r *Recorder) Stop() {
+	close(r.done)
+	r.wg.Wait()
+
+	if err := r.db.Close(); err != nil {
+		slog.Error("Failed to close history database", "error", err)
+	}
+}
+
+// Observe snapshots a printer on its first successful collection in each
+// interval. Intervals are aligned in UTC, a daily snapshot is the first
+// after midnight.
+func (r *Recorder) Observe(state printer.State) {
+	// LastSuccess only moves when a cycle reads the printer, so neither an
+	// unreachable printer nor a state patched by a trap is snapshotted
+	taken := state.LastSuccess
+	if taken.IsZero() || state.Snapshot == nil || (len(state.Snapshot.Counters) == 0 && len(state.Snapshot.Supplies) == 0) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last, ok := r.last[state.ID]
+	if !ok {
+		snap, found, err := r.db.Last(state.ID)
+		if err != nil {
+			slog.Error("Failed to read the last history snapshot", "printer", state.ID, "error", err)
+			return
+		}
+
+		if found {
+			last = snap.Time
+		}
+	}
+
+	if !last.IsZero() && !taken.Truncate(r.interval).After(last.Truncate(r.interval)) {
+		r.last[state.ID] = last
+		return
+	}
+
+	if err := r.db.Add(state.ID, newSnapshot(taken, state.Snapshot)); err != nil {
+		slog.Error("Failed to store history snapshot", "printer", state.ID, "error", err)
+		return
+	}
+
+	r.last[state.ID] = taken
+
+	slog.Debug("Stored history snapshot", "printer", state.ID, "time", taken)
+}
+
+func (r *Recorder) prune() {
+	pruned, err := r.db.Prune(r.now().Add(-r.retention))
+	if err != nil {
+		slog.Error("Failed to prune history", "error", err)
+		return
+	}
+
+	if pruned > 0 {
+		slog.Info("Pruned history snapshots", "count", pruned)
+	}
+}
+
+// newSnapshot copies the counters and levels of a printer snapshot
+func newSnapshot(taken time.Time, snap *printer.Snapshot) Snapshot {
+	history := Snapshot{
+		Time:     taken.UTC(),
+		Counters: maps.Clone(snap.Counters),
+	}
+
+	if identity := snap.Identity; identity != nil {
+		history.Model = identity.Model
+		history.Serial = identity.Serial
+		history.Name = identity.Name
+		history.Location = identity.Location
+	}
+
+	for _, supply := range snap.Supplies {
+		history.Supplies = append(history.Supplies, Supply{Kind: supply.Kind, Color: supply.Color, Percent: supply.Percent})
+	}
+
+	for _, part := range snap.Maintenance {
+		if history.Maintenance == nil {
+			history.Maintenance = make(map[string]float64)
+		}
+
+		history.Maintenance[part.Kind] = part.RemainingPages
+	}
+
+	return history
+}
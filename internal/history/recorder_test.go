@@ -0,0 +1,84 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	promexporter_config "github.com/d0ugal/promexporter/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newState(success time.Time, total float64) printer.State {
+	return printer.State{
+		ID:             "printer",
+		Host:           "printer",
+		Reachable:      true,
+		LastCollection: success,
+		LastSuccess:    success,
+		Snapshot: &printer.Snapshot{
+			Identity:    &printer.Identity{Model: "HL-L3270CDW", Serial: "E123", Location: "Office"},
+			Counters:    map[string]float64{printer.CounterTotal: total},
+			Supplies:    []printer.Supply{{Kind: "toner", Color: "black", Percent: 40, State: "ok"}},
+			Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: 45000}},
+		},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	db := openDB(t)
+	cfg := config.HistoryConfig{Interval: promexporter_config.Duration{Duration: 24 * time.Hour}, RetentionDays: 30}
+	recorder := NewRecorder(db, cfg)
+
+	midnight := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// One snapshot per UTC day, the first of the day
+	recorder.Observe(newState(midnight.Add(-time.Minute), 100))
+	recorder.Observe(newState(midnight.Add(time.Minute), 110))
+	recorder.Observe(newState(midnight.Add(12*time.Hour), 150))
+
+	// An unreachable cycle keeps the last success
+	unreachable := newState(midnight.Add(12*time.Hour), 150)
+	unreachable.Reachable = false
+	unreachable.LastCollection = midnight.Add(25 * time.Hour)
+	recorder.Observe(unreachable)
+
+	recorder.Observe(newState(midnight.Add(26*time.Hour), 200))
+
+	snaps, err := db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	if assert.Len(t, snaps, 3) {
+		assert.Equal(t, 100.0, snaps[0].Counters["total"])
+		assert.Equal(t, 110.0, snaps[1].Counters["total"])
+		assert.Equal(t, 200.0, snaps[2].Counters["total"])
+
+		assert.Equal(t, Snapshot{
+			Time:        midnight.Add(time.Minute),
+			Model:       "HL-L3270CDW",
+			Serial:      "E123",
+			Location:    "Office",
+			Counters:    map[string]float64{"total": 110},
+			Supplies:    []Supply{{Kind: "toner", Color: "black", Percent: 40}},
+			Maintenance: map[string]float64{"fuser_unit": 45000},
+		}, snaps[1])
+	}
+
+	// A new recorder picks up from the stored snapshots
+	restarted := NewRecorder(db, cfg)
+	restarted.Observe(newState(midnight.Add(27*time.Hour), 210))
+
+	snaps, err = db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, snaps, 3)
+
+	// Retention prunes what is older than the retention days
+	restarted.now = func() time.Time { return midnight.AddDate(0, 0, 30).Add(time.Hour) }
+	restarted.prune()
+
+	snaps, err = db.Query("printer", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, snaps, 1)
+}
@@ -0,0 +1,210 @@
+// Package influx renders the printer state as InfluxDB line protocol, for
+// Telegraf to poll or to write to InfluxDB v2.
+package influx
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Measurements
+const (
+	MeasurementPrinter     = "brother_printer"
+	MeasurementSupply      = "brother_supply"
+	MeasurementMaintenance = "brother_maintenance"
+	MeasurementTray        = "brother_tray"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// field is a field key and its value: a float64, int64, bool or string
+type field struct {
+	key   string
+	value any
+}
+
+// AppendState appends the lines of a printer's state to b: the printer
+// with its status and page counters, and a line per supply, maintenance
+// part and tray. The printer's identity becomes tags, next to tags. A
+// printer not collected yet has no lines; an unreachable one only its
+// reachability, at the failed cycle.
+func AppendState(b []byte, state printer.State, tags map[string]string) []byte {
+	if state.LastCollection.IsZero() {
+		return b
+	}
+
+	base := printerTags(state, tags)
+
+	if !state.Reachable || state.Snapshot == nil {
+		return appendLine(b, MeasurementPrinter, base, []field{{"reachable", false}}, state.LastCollection)
+	}
+
+	snap := state.Snapshot
+	at := state.LastSuccess
+
+	fields := []field{{"reachable", true}}
+
+	if snap.Status != nil {
+		fields = append(fields, field{"status", snap.Status.State})
+
+		if snap.Status.Message != "" {
+			fields = append(fields, field{"status_message", snap.Status.Message})
+		}
+
+		codes := make([]string, 0, len(snap.Alerts))
+		for _, alert := range snap.Alerts {
+			codes = append(codes, alert.Code)
+		}
+
+		fields = append(fields, field{"alert_count", int64(len(snap.Alerts))}, field{"alerts", strings.Join(codes, ",")})
+	}
+
+	if !snap.BootTime.IsZero() {
+		fields = append(fields, field{"boot_time", snap.BootTime.Unix()})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(snap.Counters)) {
+		fields = append(fields, field{"pages_" + name, int64(snap.Counters[name])})
+	}
+
+	b = appendLine(b, MeasurementPrinter, base, fields, at)
+
+	for _, supply := range snap.Supplies {
+		fields := []field{{"level_percent", supply.Percent}}
+
+		if supply.State != "" {
+			fields = append(fields, field{"state", supply.State})
+		}
+
+		if nearEnd, ok := snap.NearEnd[supply.Kind]; ok {
+			fields = append(fields, field{"near_end", nearEnd})
+		}
+
+		b = appendLine(b, MeasurementSupply, with(base, "kind", supply.Kind, "color", supply.Color), fields, at)
+	}
+
+	for _, part := range snap.Maintenance {
+		b = appendLine(b, MeasurementMaintenance, with(base, "part", part.Kind),
+			[]field{{"remaining_pages", int64(part.RemainingPages)}}, at)
+	}
+
+	for _, tray := range snap.Trays {
+		b = appendLine(b, MeasurementTray, with(base, "tray", tray.Name), []field{{"status", tray.Status}}, at)
+	}
+
+	return b
+}
+
+// printerTags are the extra tags and the printer's identity, which wins
+func printerTags(state printer.State, tags map[string]string) map[string]string {
+	base := make(map[string]string, len(tags)+7)
+	maps.Copy(base, tags)
+
+	base["host"] = state.Host
+
+	if state.Snapshot != nil && state.Snapshot.Identity != nil {
+		identity := state.Snapshot.Identity
+		base["model"] = identity.Model
+		base["serial"] = identity.Serial
+		base["firmware"] = identity.Firmware
+		base["mac"] = identity.MAC
+		base["name"] = identity.Name
+		base["location"] = identity.Location
+	}
+
+	return base
+}
+
+// with returns a copy of tags with the key value pairs added
+func with(tags map[string]string, pairs ...string) map[string]string {
+	copied := make(map[string]string, len(tags)+len(pairs)/2)
+	maps.Copy(copied, tags)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		copied[pairs[i]] = pairs[i+1]
+	}
+
+	return copied
+}
+
+// appendLine appends one line, with the tags sorted and empty ones left
+// out. Fields that can't be represented, such as NaN, are left out too.
+func appendLine(b []byte, measurement string, tags map[string]string, fields []field, at time.Time) []byte {
+	keys := make([]string, 0, len(tags))
+	for key, value := range tags {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	line := []byte(measurementEscaper.Replace(measurement))
+
+	for _, key := range keys {
+		line = append(line, ',')
+		line = append(line, keyEscaper.Replace(key)...)
+		line = append(line, '=')
+		line = append(line, keyEscaper.Replace(tags[key])...)
+	}
+
+	written := 0
+
+	for _, f := range fields {
+		value, ok := formatField(f.value)
+		if !ok {
+			continue
+		}
+
+		if written == 0 {
+			line = append(line, ' ')
+		} else {
+			line = append(line, ',')
+		}
+
+		line = append(line, keyEscaper.Replace(f.key)...)
+		line = append(line, '=')
+		line = append(line, value...)
+		written++
+	}
+
+	// A line needs a field
+	if written == 0 {
+		return b
+	}
+
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, at.UnixNano(), 10)
+	line = append(line, '\n')
+
+	return append(b, line...)
+}
+
+func formatField(value any) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(v, 10) + "i", true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return `"` + stringEscaper.Replace(v) + `"`, true
+	default:
+		return "", false
+	}
+}
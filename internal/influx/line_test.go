@@ -0,0 +1,61 @@
+package influx
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendState(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	state := printer.State{
+		ID:             "192.168.1.100",
+		Host:           "192.168.1.100",
+		Reachable:      true,
+		LastCollection: at,
+		LastSuccess:    at,
+		Snapshot: &printer.Snapshot{
+			Identity: &printer.Identity{Model: "HL-L3270CDW series", Serial: "E123", Firmware: "1.20", Location: "Office, 2nd floor"},
+			Status:   &printer.Status{State: "stopped", Message: `Paper "Jam"`},
+			Alerts:   []printer.Alert{{Code: "jammed"}, {Code: "low_toner"}},
+			BootTime: time.Unix(1700000000, 0),
+			Supplies: []printer.Supply{
+				{Kind: "toner", Color: "black", Percent: 60.5, State: "ok"},
+				{Kind: "belt_unit", Percent: math.NaN()},
+			},
+			NearEnd:     map[string]bool{"toner": false},
+			Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: 48000}},
+			Trays:       []printer.Tray{{Name: "tray1", Status: "ok"}},
+			Counters:    map[string]float64{printer.CounterTotal: 5432, printer.CounterColor: 1432},
+		},
+	}
+
+	identity := `firmware=1.20,host=192.168.1.100,location=Office\,\ 2nd\ floor,model=HL-L3270CDW\ series`
+	ns := " 1772366400000000000"
+
+	// The belt unit has no representable field and no line
+	assert.Equal(t, []string{
+		`brother_printer,` + identity + `,serial=E123,site=hq reachable=true,status="stopped",status_message="Paper \"Jam\"",` +
+			`alert_count=2i,alerts="jammed,low_toner",boot_time=1700000000i,pages_color=1432i,pages_total=5432i` + ns,
+		`brother_supply,color=black,firmware=1.20,host=192.168.1.100,kind=toner,location=Office\,\ 2nd\ floor,model=HL-L3270CDW\ series,` +
+			`serial=E123,site=hq level_percent=60.5,state="ok",near_end=false` + ns,
+		`brother_maintenance,` + identity + `,part=fuser_unit,serial=E123,site=hq remaining_pages=48000i` + ns,
+		`brother_tray,` + identity + `,serial=E123,site=hq,tray=tray1 status="ok"` + ns,
+		"",
+	}, strings.Split(string(AppendState(nil, state, map[string]string{"site": "hq", "host": "ignored"})), "\n"))
+}
+
+func TestAppendStateUnreachable(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Not collected yet
+	assert.Empty(t, AppendState(nil, printer.State{Host: "192.168.1.100", Snapshot: &printer.Snapshot{}}, nil))
+
+	state := printer.State{Host: "192.168.1.100", LastCollection: at, Snapshot: &printer.Snapshot{}}
+	assert.Equal(t, "brother_printer,host=192.168.1.100 reachable=false 1772366400000000000\n", string(AppendState(nil, state, nil)))
+}
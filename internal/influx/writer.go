@@ -0,0 +1,182 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// maxPending is how many cycles are kept while InfluxDB can't be reached,
+// the oldest dropped first
+const maxPending = 1000
+
+// Writer writes each collection result to InfluxDB v2. Cycles that fail
+// to write are retried with the next one. It implements the promexporter
+// collector interface.
+type Writer struct {
+	cfg      config.InfluxConfig
+	endpoint string
+	client   *http.Client
+
+	mu sync.Mutex
+	// pending holds the lines of each cycle not written yet, oldest first
+	pending [][]byte
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWriter creates a writer to the bucket in cfg
+func NewWriter(cfg config.InfluxConfig) *Writer {
+	query := url.Values{"org": {cfg.Org}, "bucket": {cfg.Bucket}, "precision": {"ns"}}
+
+	return &Writer{
+		cfg:      cfg,
+		endpoint: strings.TrimSuffix(cfg.URL, "/") + "/api/v2/write?" + query.Encode(),
+		client:   &http.Client{Timeout: cfg.Timeout.Duration},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Start writes in the background
+func (w *Writer) Start(ctx context.Context) {
+	slog.Info("Starting InfluxDB writer", "url", w.cfg.URL, "org", w.cfg.Org, "bucket", w.cfg.Bucket)
+
+	ctx, w.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.wake:
+			}
+
+			w.flush(ctx)
+		}
+	}()
+}
+
+// Stop stops writing, discarding what is still pending
+func (w *Writer) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+}
+
+// Observe queues the lines of a cycle, called after each cycle
+func (w *Writer) Observe(state printer.State) {
+	lines := AppendState(nil, state, w.cfg.Tags)
+	if len(lines) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+
+	if len(w.pending) >= maxPending {
+		w.pending = w.pending[1:]
+		slog.Warn("InfluxDB writes are failing, dropped the oldest cycle", "pending", maxPending)
+	}
+
+	w.pending = append(w.pending, lines)
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes every pending cycle in one request. They are kept for the
+// next cycle when InfluxDB may take them later.
+func (w *Writer) flush(ctx context.Context) {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	err := w.write(ctx, bytes.Join(pending, nil))
+	if err == nil {
+		slog.Debug("Wrote to InfluxDB", "cycles", len(pending))
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	var status *statusError
+	if errors.As(err, &status) && status.code != http.StatusTooManyRequests && status.code < 500 {
+		slog.Error("InfluxDB rejected the write, dropping it", "url", w.cfg.URL, "cycles", len(pending), "error", err)
+		return
+	}
+
+	slog.Warn("Failed to write to InfluxDB, retrying with the next cycle", "url", w.cfg.URL, "error", err)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(pending, w.pending...)
+	if len(w.pending) > maxPending {
+		w.pending = w.pending[len(w.pending)-maxPending:]
+	}
+}
+
+// statusError is a response other than 2xx
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.code, e.message)
+}
+
+func (w *Writer) write(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.cfg.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode/100 != 2 {
+		return &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+
+	return nil
+}
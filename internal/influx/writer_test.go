@@ -0,0 +1,119 @@
+package influx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses = []int{http.StatusServiceUnavailable, http.StatusNoContent}
+		bodies   []string
+	)
+
+	received := make(chan struct{}, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/write", r.URL.Path)
+		assert.Equal(t, "facilities", r.URL.Query().Get("org"))
+		assert.Equal(t, "printers", r.URL.Query().Get("bucket"))
+		assert.Equal(t, "ns", r.URL.Query().Get("precision"))
+		assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		status := http.StatusNoContent
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+
+		if status == http.StatusNoContent {
+			bodies = append(bodies, string(body))
+		}
+		mu.Unlock()
+
+		w.WriteHeader(status)
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	writer := NewWriter(config.InfluxConfig{
+		URL:     server.URL + "/",
+		Org:     "facilities",
+		Bucket:  "printers",
+		Token:   "secret",
+		Timeout: config.Duration{Duration: 5 * time.Second},
+	})
+
+	writer.Start(t.Context())
+	defer writer.Stop()
+
+	first := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := printer.State{Host: "192.168.1.100", LastCollection: first, Snapshot: &printer.Snapshot{}}
+
+	// Rejected with a 503 and kept
+	writer.Observe(state)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "write not received")
+	}
+
+	// Written together with the next cycle
+	state.LastCollection = first.Add(time.Minute)
+	writer.Observe(state)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "write not received")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, bodies, 1)
+	assert.Equal(t, []string{
+		"brother_printer,host=192.168.1.100 reachable=false 1772366400000000000",
+		"brother_printer,host=192.168.1.100 reachable=false 1772366460000000000",
+		"",
+	}, strings.Split(bodies[0], "\n"))
+}
+
+func TestWriterDropsRejected(t *testing.T) {
+	writer := NewWriter(config.InfluxConfig{URL: "http://127.0.0.1:1"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unknown bucket", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	writer.endpoint = server.URL
+
+	state := printer.State{Host: "192.168.1.100", LastCollection: time.Now(), Snapshot: &printer.Snapshot{}}
+	writer.Observe(state)
+	writer.flush(t.Context())
+
+	assert.Empty(t, writer.pending)
+
+	// Unreachable InfluxDB keeps the cycle
+	writer.endpoint = "http://127.0.0.1:1/api/v2/write"
+	writer.Observe(state)
+	writer.flush(t.Context())
+
+	assert.Len(t, writer.pending, 1)
+}
@@ -0,0 +1,121 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxResponseSize bounds how much of a response is read
+const maxResponseSize = 4 << 20
+
+// Client sends Get-Printer-Attributes requests to a single printer
+type Client struct {
+	// URL is the printer's http(s) endpoint, e.g. http://host:631/ipp/print
+	URL string
+
+	httpClient *http.Client
+	requestID  atomic.Uint32
+}
+
+// NewClient creates a client for the printer at url. insecureSkipVerify
+// disables certificate checks for IPPS, as printers usually ship with a
+// self-signed certificate.
+func NewClient(url string, timeout time.Duration, insecureSkipVerify bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // opt-in for self-signed printer certificates
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	return &Client{
+		URL:        url,
+		httpClient: &http.Client{Timeout: timeout, Transport: transport},
+	}
+}
+
+// PrinterURI returns the ipp:// or ipps:// form of the client URL, used as
+// the printer-uri operation attribute
+func (c *Client) PrinterURI() string {
+	switch {
+	case strings.HasPrefix(c.URL, "https://"):
+		return "ipps://" + strings.TrimPrefix(c.URL, "https://")
+	case strings.HasPrefix(c.URL, "http://"):
+		return "ipp://" + strings.TrimPrefix(c.URL, "http://")
+	default:
+		return c.URL
+	}
+}
+
+// GetPrinterAttributes requests the named printer attributes and returns the
+// printer attributes group of the response
+func (c *Client) GetPrinterAttributes(ctx context.Context, attributes []string) (*Group, error) {
+	requested := make([]Value, 0, len(attributes))
+	for _, name := range attributes {
+		requested = append(requested, Value{Tag: TagKeyword, Value: name})
+	}
+
+	request := &Message{
+		Code:      OperationGetPrinterAttributes,
+		RequestID: c.requestID.Add(1),
+		Groups: []Group{{
+			Tag: TagOperationAttributes,
+			Attributes: []Attribute{
+				{Name: "attributes-charset", Values: []Value{{Tag: TagCharset, Value: "utf-8"}}},
+				{Name: "attributes-natural-language", Values: []Value{{Tag: TagNaturalLanguage, Value: "en"}}},
+				{Name: "printer-uri", Values: []Value{{Tag: TagURI, Value: c.PrinterURI()}}},
+				{Name: "requested-attributes", Values: requested},
+			},
+		}},
+	}
+
+	body, err := request.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode IPP request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IPP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/ipp")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("IPP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("IPP request failed with HTTP status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IPP response: %w", err)
+	}
+
+	response, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode IPP response: %w", err)
+	}
+
+	// Status codes below 0x0100 are successful, possibly with ignored attributes
+	if response.Code >= 0x0100 {
+		return nil, fmt.Errorf("IPP request failed with status 0x%04x", response.Code)
+	}
+
+	printer := response.Group(TagPrinterAttributes)
+	if printer == nil {
+		return nil, fmt.Errorf("IPP response has no printer attributes")
+	}
+
+	return printer, nil
+}
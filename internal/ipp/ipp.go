@@ -0,0 +1,453 @@
+// Package ipp implements the small subset of the Internet Printing Protocol
+// (RFC 8010/8011) needed to read printer attributes: message encoding and
+// decoding, and a Get-Printer-Attributes client.
+package ipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Delimiter tags
+const (
+	TagOperationAttributes   byte = 0x01
+	TagJobAttributes         byte = 0x02
+	TagEndOfAttributes       byte = 0x03
+	TagPrinterAttributes     byte = 0x04
+	TagUnsupportedAttributes byte = 0x05
+)
+
+// Value tags
+const (
+	TagUnknown          byte = 0x12
+	TagNoValue          byte = 0x13
+	TagInteger          byte = 0x21
+	TagBoolean          byte = 0x22
+	TagEnum             byte = 0x23
+	TagOctetString      byte = 0x30
+	TagDateTime         byte = 0x31
+	TagResolution       byte = 0x32
+	TagRangeOfInteger   byte = 0x33
+	TagBeginCollection  byte = 0x34
+	TagEndCollection    byte = 0x37
+	TagTextWithoutLang  byte = 0x41
+	TagNameWithoutLang  byte = 0x42
+	TagKeyword          byte = 0x44
+	TagURI              byte = 0x45
+	TagURIScheme        byte = 0x46
+	TagCharset          byte = 0x47
+	TagNaturalLanguage  byte = 0x48
+	TagMimeMediaType    byte = 0x49
+	TagMemberAttrName   byte = 0x4a
+	TagTextWithLanguage byte = 0x35
+	TagNameWithLanguage byte = 0x36
+)
+
+// Operation and status codes
+const (
+	OperationGetPrinterAttributes uint16 = 0x000b
+	StatusOK                      uint16 = 0x0000
+)
+
+const (
+	maxDelimiterTag byte = 0x0f
+	versionMajor    byte = 2
+	versionMinor    byte = 0
+)
+
+// Message is an IPP request or response. Code is the operation id for a
+// request and the status code for a response.
+type Message struct {
+	Code      uint16
+	RequestID uint32
+	Groups    []Group
+}
+
+// Group is a set of attributes under one delimiter tag
+type Group struct {
+	Tag        byte
+	Attributes []Attribute
+}
+
+// Attribute is a named, possibly multi-valued, attribute
+type Attribute struct {
+	Name   string
+	Values []Value
+}
+
+// Value is a single attribute value. Value holds an int for integer and enum
+// values, a bool for booleans, []Attribute for collections, a string for
+// text-like and octetString values, a LocalizedString for textWithLanguage
+// and nameWithLanguage values and the raw bytes for anything else.
+type Value struct {
+	Tag   byte
+	Value any
+}
+
+// LocalizedString is a textWithLanguage or nameWithLanguage value: a text
+// and the natural language it is in
+type LocalizedString struct {
+	Language string
+	Text     string
+}
+
+// Int returns the value as an int
+func (v Value) Int() (int, bool) {
+	i, ok := v.Value.(int)
+	return i, ok
+}
+
+// String returns the value as a string
+func (v Value) String() string {
+	switch val := v.Value.(type) {
+	case string:
+		return val
+	case LocalizedString:
+		return val.Text
+	case int:
+		return fmt.Sprintf("%d", val)
+	case bool:
+		return fmt.Sprintf("%t", val)
+	default:
+		return ""
+	}
+}
+
+// Group returns the first group with the given tag, or nil
+func (m *Message) Group(tag byte) *Group {
+	for i := range m.Groups {
+		if m.Groups[i].Tag == tag {
+			return &m.Groups[i]
+		}
+	}
+
+	return nil
+}
+
+// Get returns the values of the named attribute, or nil
+func (g *Group) Get(name string) []Value {
+	if g == nil {
+		return nil
+	}
+
+	for _, attr := range g.Attributes {
+		if attr.Name == name {
+			return attr.Values
+		}
+	}
+
+	return nil
+}
+
+// Strings returns the values of the named attribute as strings
+func (g *Group) Strings(name string) []string {
+	values := g.Get(name)
+	out := make([]string, 0, len(values))
+
+	for _, v := range values {
+		out = append(out, v.String())
+	}
+
+	return out
+}
+
+// Ints returns the integer values of the named attribute
+func (g *Group) Ints(name string) []int {
+	values := g.Get(name)
+	out := make([]int, 0, len(values))
+
+	for _, v := range values {
+		if i, ok := v.Int(); ok {
+			out = append(out, i)
+		}
+	}
+
+	return out
+}
+
+// Encode serialises the message
+func (m *Message) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.Write([]byte{versionMajor, versionMinor})
+	_ = binary.Write(&buf, binary.BigEndian, m.Code)
+	_ = binary.Write(&buf, binary.BigEndian, m.RequestID)
+
+	for _, group := range m.Groups {
+		buf.WriteByte(group.Tag)
+
+		for _, attr := range group.Attributes {
+			for i, value := range attr.Values {
+				name := attr.Name
+				if i > 0 {
+					name = "" // additional values of a multi-valued attribute
+				}
+
+				if err := encodeValue(&buf, name, value); err != nil {
+					return nil, fmt.Errorf("attribute %s: %w", attr.Name, err)
+				}
+			}
+		}
+	}
+
+	buf.WriteByte(TagEndOfAttributes)
+
+	return buf.Bytes(), nil
+}
+
+func encodeValue(buf *bytes.Buffer, name string, value Value) error {
+	var data []byte
+
+	switch v := value.Value.(type) {
+	case []Attribute:
+		return encodeCollection(buf, name, v)
+	case int:
+		data = binary.BigEndian.AppendUint32(nil, uint32(int32(v))) //nolint:gosec // IPP integers are 32-bit
+	case bool:
+		data = []byte{0}
+		if v {
+			data[0] = 1
+		}
+	case string:
+		data = []byte(v)
+	case LocalizedString:
+		if len(v.Language) > 0xffff || len(v.Text) > 0xffff {
+			return errors.New("attribute too long")
+		}
+
+		data = binary.BigEndian.AppendUint16(nil, uint16(len(v.Language))) //nolint:gosec // length checked above
+		data = append(data, v.Language...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(v.Text))) //nolint:gosec // length checked above
+		data = append(data, v.Text...)
+	case []byte:
+		data = v
+	case nil:
+	default:
+		return fmt.Errorf("unsupported value type %T", value.Value)
+	}
+
+	if len(name) > 0xffff || len(data) > 0xffff {
+		return errors.New("attribute too long")
+	}
+
+	buf.WriteByte(value.Tag)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(name))) //nolint:gosec // length checked above
+	buf.WriteString(name)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(data))) //nolint:gosec // length checked above
+	buf.Write(data)
+
+	return nil
+}
+
+// encodeCollection writes a begCollection value, its members and the
+// matching endCollection
+func encodeCollection(buf *bytes.Buffer, name string, members []Attribute) error {
+	if err := encodeValue(buf, name, Value{Tag: TagBeginCollection}); err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := encodeValue(buf, "", Value{Tag: TagMemberAttrName, Value: member.Name}); err != nil {
+			return err
+		}
+
+		for _, value := range member.Values {
+			if err := encodeValue(buf, "", value); err != nil {
+				return fmt.Errorf("member %s: %w", member.Name, err)
+			}
+		}
+	}
+
+	return encodeValue(buf, "", Value{Tag: TagEndCollection})
+}
+
+// Decode parses an IPP message
+func Decode(data []byte) (*Message, error) {
+	r := bytes.NewReader(data)
+
+	var header struct {
+		Major, Minor byte
+		Code         uint16
+		RequestID    uint32
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read IPP header: %w", err)
+	}
+
+	if header.Major != 1 && header.Major != 2 {
+		return nil, fmt.Errorf("unsupported IPP version %d.%d", header.Major, header.Minor)
+	}
+
+	msg := &Message{Code: header.Code, RequestID: header.RequestID}
+
+	var group *Group
+
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("missing end-of-attributes tag: %w", err)
+		}
+
+		if tag == TagEndOfAttributes {
+			return msg, nil
+		}
+
+		if tag <= maxDelimiterTag {
+			msg.Groups = append(msg.Groups, Group{Tag: tag})
+			group = &msg.Groups[len(msg.Groups)-1]
+
+			continue
+		}
+
+		if group == nil {
+			return nil, errors.New("attribute outside of a group")
+		}
+
+		name, value, err := decodeValue(r, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name != "":
+			group.Attributes = append(group.Attributes, Attribute{Name: name, Values: []Value{value}})
+		case len(group.Attributes) > 0:
+			last := &group.Attributes[len(group.Attributes)-1]
+			last.Values = append(last.Values, value)
+		default:
+			return nil, errors.New("additional value without an attribute")
+		}
+	}
+}
+
+// decodeValue reads the rest of an attribute whose value tag has been read
+func decodeValue(r *bytes.Reader, tag byte) (string, Value, error) {
+	name, err := readField(r)
+	if err != nil {
+		return "", Value{}, fmt.Errorf("failed to read attribute name: %w", err)
+	}
+
+	data, err := readField(r)
+	if err != nil {
+		return "", Value{}, fmt.Errorf("failed to read value of %s: %w", name, err)
+	}
+
+	value := Value{Tag: tag}
+
+	switch tag {
+	case TagInteger, TagEnum:
+		if len(data) != 4 {
+			return "", Value{}, fmt.Errorf("invalid integer length %d for %s", len(data), name)
+		}
+
+		value.Value = int(int32(binary.BigEndian.Uint32(data))) //nolint:gosec // IPP integers are signed 32-bit
+	case TagBoolean:
+		value.Value = len(data) == 1 && data[0] != 0
+	case TagOctetString, TagTextWithoutLang, TagNameWithoutLang, TagKeyword, TagURI,
+		TagURIScheme, TagCharset, TagNaturalLanguage, TagMimeMediaType, TagMemberAttrName:
+		value.Value = string(data)
+	case TagTextWithLanguage, TagNameWithLanguage:
+		localized, err := decodeLocalizedString(data)
+		if err != nil {
+			return "", Value{}, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+
+		value.Value = localized
+	case TagBeginCollection:
+		members, err := decodeCollection(r)
+		if err != nil {
+			return "", Value{}, fmt.Errorf("collection %s: %w", name, err)
+		}
+
+		value.Value = members
+	default:
+		value.Value = data
+	}
+
+	return string(name), value, nil
+}
+
+// decodeCollection reads collection members up to the matching end tag
+func decodeCollection(r *bytes.Reader) ([]Attribute, error) {
+	var members []Attribute
+
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("unterminated collection: %w", err)
+		}
+
+		_, value, err := decodeValue(r, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		switch tag {
+		case TagEndCollection:
+			return members, nil
+		case TagMemberAttrName:
+			members = append(members, Attribute{Name: value.String()})
+		default:
+			if len(members) == 0 {
+				return nil, errors.New("collection value without a member name")
+			}
+
+			last := &members[len(members)-1]
+			last.Values = append(last.Values, value)
+		}
+	}
+}
+
+// decodeLocalizedString splits a textWithLanguage or nameWithLanguage value
+// into its language and text, each prefixed with its length
+func decodeLocalizedString(data []byte) (LocalizedString, error) {
+	r := bytes.NewReader(data)
+
+	language, err := readField(r)
+	if err != nil {
+		return LocalizedString{}, fmt.Errorf("failed to read language: %w", err)
+	}
+
+	text, err := readField(r)
+	if err != nil {
+		return LocalizedString{}, fmt.Errorf("failed to read text: %w", err)
+	}
+
+	return LocalizedString{Language: string(language), Text: string(text)}, nil
+}
+
+func readField(r *bytes.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ParseKeyValues parses the "key=value;key=value;" octetString format used by
+// printer-input-tray and printer-supply (PWG 5100.13)
+func ParseKeyValues(s string) map[string]string {
+	values := make(map[string]string)
+
+	for _, field := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return values
+}
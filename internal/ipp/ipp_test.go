@@ -0,0 +1,82 @@
+package ipp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	msg := &Message{
+		Code:      OperationGetPrinterAttributes,
+		RequestID: 7,
+		Groups: []Group{{
+			Tag: TagPrinterAttributes,
+			Attributes: []Attribute{
+				{Name: "printer-state", Values: []Value{{Tag: TagEnum, Value: 3}}},
+				{Name: "marker-levels", Values: []Value{{Tag: TagInteger, Value: 60}, {Tag: TagInteger, Value: -3}}},
+				{Name: "printer-is-accepting-jobs", Values: []Value{{Tag: TagBoolean, Value: true}}},
+				{Name: "printer-impressions-completed-col", Values: []Value{{Tag: TagBeginCollection, Value: []Attribute{
+					{Name: "monochrome", Values: []Value{{Tag: TagInteger, Value: 10}}},
+				}}}},
+			},
+		}},
+	}
+
+	data, err := msg.Encode()
+	require.NoError(t, err)
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, msg.Code, decoded.Code)
+	assert.Equal(t, msg.RequestID, decoded.RequestID)
+
+	printer := decoded.Group(TagPrinterAttributes)
+	require.NotNil(t, printer)
+	assert.Equal(t, []int{3}, printer.Ints("printer-state"))
+	assert.Equal(t, []int{60, -3}, printer.Ints("marker-levels"))
+	assert.Equal(t, []string{"true"}, printer.Strings("printer-is-accepting-jobs"))
+	assert.Equal(t, msg.Groups[0].Attributes[3].Values[0].Value, printer.Get("printer-impressions-completed-col")[0].Value)
+}
+
+func TestDecodeWithLanguage(t *testing.T) {
+	// printer-location as textWithLanguage: the value is a length-prefixed
+	// language then a length-prefixed text
+	attribute := func(value []byte) []byte {
+		data := []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, TagPrinterAttributes, TagTextWithLanguage, 0x00, 0x10}
+		data = append(data, "printer-location"...)
+		data = append(data, 0x00, byte(len(value)))
+		data = append(data, value...)
+
+		return append(data, TagEndOfAttributes)
+	}
+
+	data := attribute(append([]byte{0x00, 0x02, 'd', 'e', 0x00, 0x05}, "Büro"...))
+
+	msg, err := Decode(data)
+	require.NoError(t, err)
+
+	printer := msg.Group(TagPrinterAttributes)
+	assert.Equal(t, []string{"Büro"}, printer.Strings("printer-location"))
+	assert.Equal(t, LocalizedString{Language: "de", Text: "Büro"}, printer.Get("printer-location")[0].Value)
+
+	encoded, err := msg.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, data, encoded)
+
+	_, err = Decode(attribute([]byte{0x00, 0x09, 'd', 'e'}))
+	assert.Error(t, err)
+}
+
+func TestParseKeyValues(t *testing.T) {
+	values := ParseKeyValues("type=sheetFeedAutoRemovableTray;maxcapacity=250;level=-3;name=Tray1;")
+
+	assert.Equal(t, map[string]string{
+		"type":        "sheetFeedAutoRemovableTray",
+		"maxcapacity": "250",
+		"level":       "-3",
+		"name":        "Tray1",
+	}, values)
+}
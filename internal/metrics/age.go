@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AgeVec is a gauge vector of the seconds since a point in time. The age is
+// computed when the metrics are gathered, so it keeps growing between
+// collections instead of standing still at the value of the last one.
+type AgeVec struct {
+	desc       *prometheus.Desc
+	labelNames []string
+	now        func() time.Time
+
+	mu     sync.Mutex
+	series map[string]ageSeries
+}
+
+type ageSeries struct {
+	labelValues []string
+	since       time.Time
+}
+
+// NewAgeVec creates an age vector with the given label names
+func NewAgeVec(name, help string, labelNames []string) *AgeVec {
+	return &AgeVec{
+		desc:       prometheus.NewDesc(name, help, labelNames, nil),
+		labelNames: labelNames,
+		now:        time.Now,
+		series:     make(map[string]ageSeries),
+	}
+}
+
+// Set records the time the series with the given labels counts from
+func (v *AgeVec) Set(labels prometheus.Labels, since time.Time) {
+	values := make([]string, len(v.labelNames))
+	for i, name := range v.labelNames {
+		values[i] = labels[name]
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.series[strings.Join(values, "\xff")] = ageSeries{labelValues: values, since: since}
+}
+
+// Reset deletes every series
+func (v *AgeVec) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	clear(v.series)
+}
+
+// Describe implements prometheus.Collector
+func (v *AgeVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- v.desc
+}
+
+// Collect implements prometheus.Collector
+func (v *AgeVec) Collect(ch chan<- prometheus.Metric) {
+	now := v.now()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, series := range v.series {
+		ch <- prometheus.MustNewConstMetric(v.desc, prometheus.GaugeValue, now.Sub(series.since).Seconds(), series.labelValues...)
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeVec_GrowsBetweenUpdates(t *testing.T) {
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	ages := NewAgeVec("test_age_seconds", "Test age", []string{"host", "step"})
+	ages.now = func() time.Time { return now }
+
+	ages.Set(prometheus.Labels{"host": "printer", "step": "brother"}, start.Add(-5*time.Minute))
+	assert.InDelta(t, 300, testutil.ToFloat64(ages), 0)
+
+	// Nothing refreshed the data, the next scrape still sees it age
+	now = now.Add(time.Minute)
+	require.NoError(t, testutil.CollectAndCompare(ages, strings.NewReader(`
+# HELP test_age_seconds Test age
+# TYPE test_age_seconds gauge
+test_age_seconds{host="printer",step="brother"} 360
+`)))
+
+	ages.Reset()
+	assert.Equal(t, 0, testutil.CollectAndCount(ages))
+}
@@ -10,6 +10,9 @@ import (
 type BrotherRegistry struct {
 	*promexporter_metrics.Registry
 
+	// ExtraLabels are the custom label names added to every metric
+	ExtraLabels []string
+
 	// Printer connection metrics
 	PrinterConnectionStatus *prometheus.GaugeVec
 	PrinterConnectionErrors *prometheus.CounterVec
@@ -62,16 +65,70 @@ type BrotherRegistry struct {
 
 	// Maintenance counters
 	MaintenanceCount *prometheus.CounterVec
+
+	// Derived consumable state and printer-reported end of life flags
+	SupplyState   *prometheus.GaugeVec
+	SupplyNearEnd *prometheus.GaugeVec
+
+	// Conditions reported by the printer
+	Alert *prometheus.GaugeVec
+
+	// Exporter self-observability
+	CollectionDuration   *prometheus.HistogramVec
+	LastSuccessTimestamp *prometheus.GaugeVec
+	SNMPRequests         *prometheus.CounterVec
+	SNMPVarbinds         *prometheus.CounterVec
+
+	// Age of the data served for each collection step
+	DataAge *AgeVec
+
+	// Health of each configured data source
+	SourceUp                   *prometheus.GaugeVec
+	SourceErrors               *prometheus.CounterVec
+	SourceDuration             *prometheus.GaugeVec
+	SourceLastSuccessTimestamp *prometheus.GaugeVec
+
+	// SNMP traps received from the printer
+	TrapsReceived *prometheus.CounterVec
+
+	// Scanner metrics (eSCL)
+	ScannerInfo          *prometheus.GaugeVec
+	ScannerState         *prometheus.GaugeVec
+	ScannerAdfState      *prometheus.GaugeVec
+	ScannerActiveJobs    *prometheus.GaugeVec
+	ScannerJobsCompleted *prometheus.CounterVec
+
+	// vectors lists every metric vector, registered in own
+	vectors []resetter
+
+	// dataVectors lists the vectors holding values read from the printer
+	dataVectors []resetter
+
+	// own holds the Brother metrics only, for exporters other than the
+	// Prometheus endpoint
+	own *prometheus.Registry
 }
 
-// NewBrotherRegistry creates a new Brother metrics registry
-func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherRegistry {
+// resetter is implemented by every prometheus metric vector
+type resetter interface {
+	prometheus.Collector
+	Reset()
+}
+
+// NewBrotherRegistry creates a new Brother metrics registry. Any extraLabels
+// (the configured custom printer labels) are added to every metric.
+func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry, extraLabels ...string) *BrotherRegistry {
 	// Get the underlying Prometheus registry
 	promRegistry := baseRegistry.GetRegistry()
 	factory := promauto.With(promRegistry)
 
 	brother := &BrotherRegistry{
-		Registry: baseRegistry,
+		Registry:    baseRegistry,
+		ExtraLabels: extraLabels,
+	}
+
+	labelNames := func(names ...string) []string {
+		return append(names, extraLabels...)
 	}
 
 	// Printer connection metrics
@@ -80,20 +137,20 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_connection_status",
 			Help: "Brother host connection status (1=connected, 0=disconnected)",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_connection_status", "Brother host connection status (1=connected, 0=disconnected)", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_connection_status", "Brother host connection status (1=connected, 0=disconnected)", labelNames("host"))
 
 	brother.PrinterConnectionErrors = factory.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "brother_printer_connection_errors_total",
 			Help: "Total number of connection errors to Brother host",
 		},
-		[]string{"host", "error_type"},
+		labelNames("host", "error_type"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_connection_errors_total", "Total number of connection errors to Brother host", []string{"host", "error_type"})
+	baseRegistry.AddMetricInfo("brother_printer_connection_errors_total", "Total number of connection errors to Brother host", labelNames("host", "error_type"))
 
 	// Printer information
 	brother.PrinterInfo = factory.NewGaugeVec(
@@ -101,10 +158,10 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_info",
 			Help: "Information about the Brother host",
 		},
-		[]string{"host", "model", "serial", "firmware", "type", "mac"},
+		labelNames("host", "model", "serial", "firmware", "type", "mac"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_info", "Information about the Brother host", []string{"host", "model", "serial", "firmware", "type", "mac"})
+	baseRegistry.AddMetricInfo("brother_printer_info", "Information about the Brother host", labelNames("host", "model", "serial", "firmware", "type", "mac"))
 
 	// Printer uptime
 	brother.PrinterUptime = factory.NewGaugeVec(
@@ -112,10 +169,10 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_restart_timestamp",
 			Help: "Unix timestamp when Brother host was last restarted (use time() - brother_printer_restart_timestamp for uptime)",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_restart_timestamp", "Unix timestamp when Brother host was last restarted (use time() - brother_printer_restart_timestamp for uptime)", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_restart_timestamp", "Unix timestamp when Brother host was last restarted (use time() - brother_printer_restart_timestamp for uptime)", labelNames("host"))
 
 	// Printer status
 	brother.PrinterStatus = factory.NewGaugeVec(
@@ -123,10 +180,10 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_status",
 			Help: "Brother host status (1=ready, 0=not_ready)",
 		},
-		[]string{"host", "status"},
+		labelNames("host", "status"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_status", "Brother host status (1=ready, 0=not_ready)", []string{"host", "status"})
+	baseRegistry.AddMetricInfo("brother_printer_status", "Brother host status (1=ready, 0=not_ready)", labelNames("host", "status"))
 
 	// Toner/Cartridge levels (for laser hosts)
 	brother.TonerLevel = factory.NewGaugeVec(
@@ -134,20 +191,20 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_toner_level_percent",
 			Help: "Brother host toner level percentage",
 		},
-		[]string{"host", "color"},
+		labelNames("host", "color"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_toner_level_percent", "Brother host toner level percentage", []string{"host", "color"})
+	baseRegistry.AddMetricInfo("brother_printer_toner_level_percent", "Brother host toner level percentage", labelNames("host", "color"))
 
 	brother.TonerStatus = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_toner_status",
-			Help: "Brother host toner status (1=ok, 0=low/empty)",
+			Help: "Brother host toner status (1=ok, 0=low/critical/empty)",
 		},
-		[]string{"host", "color", "status"},
+		labelNames("host", "color", "status"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_toner_status", "Brother host toner status (1=ok, 0=low/empty)", []string{"host", "color", "status"})
+	baseRegistry.AddMetricInfo("brother_printer_toner_status", "Brother host toner status (1=ok, 0=low/critical/empty)", labelNames("host", "color", "status"))
 
 	// Ink levels (for inkjet hosts)
 	brother.InkLevel = factory.NewGaugeVec(
@@ -155,20 +212,20 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_ink_level_percent",
 			Help: "Brother host ink level percentage",
 		},
-		[]string{"host", "color"},
+		labelNames("host", "color"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_ink_level_percent", "Brother host ink level percentage", []string{"host", "color"})
+	baseRegistry.AddMetricInfo("brother_printer_ink_level_percent", "Brother host ink level percentage", labelNames("host", "color"))
 
 	brother.InkStatus = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_ink_status",
-			Help: "Brother host ink status (1=ok, 0=low/empty)",
+			Help: "Brother host ink status (1=ok, 0=low/critical/empty)",
 		},
-		[]string{"host", "color", "status"},
+		labelNames("host", "color", "status"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_ink_status", "Brother host ink status (1=ok, 0=low/empty)", []string{"host", "color", "status"})
+	baseRegistry.AddMetricInfo("brother_printer_ink_status", "Brother host ink status (1=ok, 0=low/critical/empty)", labelNames("host", "color", "status"))
 
 	// Drum levels (for laser hosts)
 	brother.DrumLevel = factory.NewGaugeVec(
@@ -176,20 +233,20 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_drum_level_percent",
 			Help: "Brother host drum level percentage",
 		},
-		[]string{"host", "color"},
+		labelNames("host", "color"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_drum_level_percent", "Brother host drum level percentage", []string{"host", "color"})
+	baseRegistry.AddMetricInfo("brother_printer_drum_level_percent", "Brother host drum level percentage", labelNames("host", "color"))
 
 	brother.DrumStatus = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_drum_status",
-			Help: "Brother host drum status (1=ok, 0=low/empty)",
+			Help: "Brother host drum status (1=ok, 0=low/critical/empty)",
 		},
-		[]string{"host", "color", "status"},
+		labelNames("host", "color", "status"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_drum_status", "Brother host drum status (1=ok, 0=low/empty)", []string{"host", "color", "status"})
+	baseRegistry.AddMetricInfo("brother_printer_drum_status", "Brother host drum status (1=ok, 0=low/critical/empty)", labelNames("host", "color", "status"))
 
 	// Paper tray status
 	brother.PaperTrayStatus = factory.NewGaugeVec(
@@ -197,10 +254,10 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_paper_tray_status",
 			Help: "Brother host paper tray status (1=ok, 0=empty/error)",
 		},
-		[]string{"host", "tray", "status"},
+		labelNames("host", "tray", "status"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_paper_tray_status", "Brother host paper tray status (1=ok, 0=empty/error)", []string{"host", "tray", "status"})
+	baseRegistry.AddMetricInfo("brother_printer_paper_tray_status", "Brother host paper tray status (1=ok, 0=empty/error)", labelNames("host", "tray", "status"))
 
 	// Page counters
 	brother.PageCountTotal = factory.NewGaugeVec(
@@ -208,40 +265,40 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_pages",
 			Help: "Total number of pages printed",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_pages", "Total number of pages printed", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_pages", "Total number of pages printed", labelNames("host"))
 
 	brother.PageCountBlack = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_black",
 			Help: "Number of black pages printed",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_black", "Number of black pages printed", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_black", "Number of black pages printed", labelNames("host"))
 
 	brother.PageCountColor = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_color",
 			Help: "Number of color pages printed",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_color", "Number of color pages printed", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_color", "Number of color pages printed", labelNames("host"))
 
 	brother.PageCountDuplex = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_duplex",
 			Help: "Number of duplex pages printed",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_duplex", "Number of duplex pages printed", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_duplex", "Number of duplex pages printed", labelNames("host"))
 
 	// Drum page counts
 	brother.PageCountDrumBlack = factory.NewGaugeVec(
@@ -249,40 +306,40 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_page_count_drum_black",
 			Help: "Number of pages printed with black drum",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_black", "Number of pages printed with black drum", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_black", "Number of pages printed with black drum", labelNames("host"))
 
 	brother.PageCountDrumCyan = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_drum_cyan",
 			Help: "Number of pages printed with cyan drum",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_cyan", "Number of pages printed with cyan drum", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_cyan", "Number of pages printed with cyan drum", labelNames("host"))
 
 	brother.PageCountDrumMagenta = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_drum_magenta",
 			Help: "Number of pages printed with magenta drum",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_magenta", "Number of pages printed with magenta drum", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_magenta", "Number of pages printed with magenta drum", labelNames("host"))
 
 	brother.PageCountDrumYellow = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_page_count_drum_yellow",
 			Help: "Number of pages printed with yellow drum",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_yellow", "Number of pages printed with yellow drum", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_page_count_drum_yellow", "Number of pages printed with yellow drum", labelNames("host"))
 
 	// Maintenance component life remaining (pages)
 	brother.BeltUnitRemainingPages = factory.NewGaugeVec(
@@ -290,40 +347,40 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_belt_unit_remaining_pages",
 			Help: "Belt unit remaining pages",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_belt_unit_remaining_pages", "Belt unit remaining pages", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_belt_unit_remaining_pages", "Belt unit remaining pages", labelNames("host"))
 
 	brother.FuserUnitRemainingPages = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_fuser_unit_remaining_pages",
 			Help: "Fuser unit remaining pages",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_fuser_unit_remaining_pages", "Fuser unit remaining pages", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_fuser_unit_remaining_pages", "Fuser unit remaining pages", labelNames("host"))
 
 	brother.LaserUnitRemainingPages = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_laser_unit_remaining_pages",
 			Help: "Laser unit remaining pages",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_laser_unit_remaining_pages", "Laser unit remaining pages", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_laser_unit_remaining_pages", "Laser unit remaining pages", labelNames("host"))
 
 	brother.PaperFeedingKitRemainingPages = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_paper_feeding_kit_remaining_pages",
 			Help: "Paper feeding kit remaining pages",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_paper_feeding_kit_remaining_pages", "Paper feeding kit remaining pages", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_paper_feeding_kit_remaining_pages", "Paper feeding kit remaining pages", labelNames("host"))
 
 	// Maintenance component life remaining (percentage)
 	brother.BeltUnitRemainingPercent = factory.NewGaugeVec(
@@ -331,40 +388,40 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_belt_unit_remaining_percent",
 			Help: "Belt unit remaining percentage",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_belt_unit_remaining_percent", "Belt unit remaining percentage", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_belt_unit_remaining_percent", "Belt unit remaining percentage", labelNames("host"))
 
 	brother.FuserUnitRemainingPercent = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_fuser_unit_remaining_percent",
 			Help: "Fuser unit remaining percentage",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_fuser_unit_remaining_percent", "Fuser unit remaining percentage", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_fuser_unit_remaining_percent", "Fuser unit remaining percentage", labelNames("host"))
 
 	brother.LaserUnitRemainingPercent = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_laser_unit_remaining_percent",
 			Help: "Laser unit remaining percentage",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_laser_unit_remaining_percent", "Laser unit remaining percentage", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_laser_unit_remaining_percent", "Laser unit remaining percentage", labelNames("host"))
 
 	brother.PaperFeedingKitRemainingPercent = factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "brother_printer_paper_feeding_kit_remaining_percent",
 			Help: "Paper feeding kit remaining percentage",
 		},
-		[]string{"host"},
+		labelNames("host"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_paper_feeding_kit_remaining_percent", "Paper feeding kit remaining percentage", []string{"host"})
+	baseRegistry.AddMetricInfo("brother_printer_paper_feeding_kit_remaining_percent", "Paper feeding kit remaining percentage", labelNames("host"))
 
 	// Maintenance counters
 	brother.MaintenanceCount = factory.NewCounterVec(
@@ -372,10 +429,297 @@ func NewBrotherRegistry(baseRegistry *promexporter_metrics.Registry) *BrotherReg
 			Name: "brother_printer_maintenance_count_total",
 			Help: "Total number of maintenance operations",
 		},
-		[]string{"host", "operation"},
+		labelNames("host", "operation"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_printer_maintenance_count_total", "Total number of maintenance operations", labelNames("host", "operation"))
+
+	// Derived consumable state
+	brother.SupplyState = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_printer_supply_state",
+			Help: "Brother host consumable state from configured thresholds (0=ok, 1=warning, 2=critical)",
+		},
+		labelNames("host", "supply", "color"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_printer_supply_state", "Brother host consumable state from configured thresholds (0=ok, 1=warning, 2=critical)", labelNames("host", "supply", "color"))
+
+	brother.SupplyNearEnd = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_printer_supply_near_end",
+			Help: "Brother host reports the consumable near end of life (1=near end, 0=ok)",
+		},
+		labelNames("host", "supply"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_printer_supply_near_end", "Brother host reports the consumable near end of life (1=near end, 0=ok)", labelNames("host", "supply"))
+
+	brother.Alert = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_printer_alert",
+			Help: "Conditions reported by the printer (1 while active), e.g. no_paper, jammed, low_toner",
+		},
+		labelNames("host", "code", "severity"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_printer_alert", "Conditions reported by the printer (1 while active), e.g. no_paper, jammed, low_toner", labelNames("host", "code", "severity"))
+
+	// Exporter self-observability
+	brother.CollectionDuration = factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "brother_exporter_collection_duration_seconds",
+			Help:    "Duration of each collection step",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		labelNames("host", "step"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_collection_duration_seconds", "Duration of each collection step", labelNames("host", "step"))
+
+	brother.LastSuccessTimestamp = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_exporter_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful run of each collection step",
+		},
+		labelNames("host", "step"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_last_success_timestamp_seconds", "Unix timestamp of the last successful run of each collection step", labelNames("host", "step"))
+
+	brother.SNMPRequests = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "brother_exporter_snmp_requests_total",
+			Help: "Total number of SNMP requests by result (success, timeout, error)",
+		},
+		labelNames("host", "result"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_snmp_requests_total", "Total number of SNMP requests by result (success, timeout, error)", labelNames("host", "result"))
+
+	brother.SNMPVarbinds = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "brother_exporter_snmp_varbinds_total",
+			Help: "Total number of SNMP varbinds received by result (value, missing)",
+		},
+		labelNames("host", "result"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_snmp_varbinds_total", "Total number of SNMP varbinds received by result (value, missing)", labelNames("host", "result"))
+
+	brother.DataAge = NewAgeVec(
+		"brother_printer_data_age_seconds",
+		"Seconds since the data served for each collection step was last refreshed",
+		labelNames("host", "step"),
+	)
+	promRegistry.MustRegister(brother.DataAge)
+
+	baseRegistry.AddMetricInfo("brother_printer_data_age_seconds", "Seconds since the data served for each collection step was last refreshed", labelNames("host", "step"))
+
+	brother.SourceUp = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_exporter_source_up",
+			Help: "Whether the last fetch from each data source succeeded (1=up, 0=down)",
+		},
+		labelNames("host", "source"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_source_up", "Whether the last fetch from each data source succeeded (1=up, 0=down)", labelNames("host", "source"))
+
+	brother.SourceErrors = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "brother_exporter_source_errors_total",
+			Help: "Total number of failed fetches from each data source",
+		},
+		labelNames("host", "source"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_source_errors_total", "Total number of failed fetches from each data source", labelNames("host", "source"))
+
+	brother.SourceDuration = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_exporter_source_duration_seconds",
+			Help: "Duration of the last fetch from each data source",
+		},
+		labelNames("host", "source"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_source_duration_seconds", "Duration of the last fetch from each data source", labelNames("host", "source"))
+
+	brother.SourceLastSuccessTimestamp = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_exporter_source_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful fetch from each data source",
+		},
+		labelNames("host", "source"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_source_last_success_timestamp_seconds", "Unix timestamp of the last successful fetch from each data source", labelNames("host", "source"))
+
+	brother.TrapsReceived = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "brother_exporter_traps_received_total",
+			Help: "Total number of SNMP traps received by result (accepted, ignored, unknown_source, bad_community)",
+		},
+		labelNames("host", "result"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_exporter_traps_received_total", "Total number of SNMP traps received by result (accepted, ignored, unknown_source, bad_community)", labelNames("host", "result"))
+
+	brother.ScannerInfo = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_scanner_info",
+			Help: "Information about the Brother scanner",
+		},
+		labelNames("host", "model", "serial", "adf", "duplex"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_scanner_info", "Information about the Brother scanner", labelNames("host", "model", "serial", "adf", "duplex"))
+
+	brother.ScannerState = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_scanner_state",
+			Help: "Scanner state (1 for the current state: idle, processing, testing, stopped, down)",
+		},
+		labelNames("host", "state"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_scanner_state", "Scanner state (1 for the current state: idle, processing, testing, stopped, down)", labelNames("host", "state"))
+
+	brother.ScannerAdfState = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_scanner_adf_state",
+			Help: "Document feeder state (1 for the current state: loaded, empty, jammed, door_open, processing, other)",
+		},
+		labelNames("host", "state"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_scanner_adf_state", "Document feeder state (1 for the current state: loaded, empty, jammed, door_open, processing, other)", labelNames("host", "state"))
+
+	brother.ScannerActiveJobs = factory.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "brother_scanner_active_jobs",
+			Help: "Number of pending or processing scan jobs",
+		},
+		labelNames("host"),
+	)
+
+	baseRegistry.AddMetricInfo("brother_scanner_active_jobs", "Number of pending or processing scan jobs", labelNames("host"))
+
+	brother.ScannerJobsCompleted = factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "brother_scanner_jobs_completed_total",
+			Help: "Total number of finished scan jobs by result (completed, canceled, aborted)",
+		},
+		labelNames("host", "result"),
 	)
 
-	baseRegistry.AddMetricInfo("brother_printer_maintenance_count_total", "Total number of maintenance operations", []string{"host", "operation"})
+	baseRegistry.AddMetricInfo("brother_scanner_jobs_completed_total", "Total number of finished scan jobs by result (completed, canceled, aborted)", labelNames("host", "result"))
+
+	brother.dataVectors = []resetter{
+		brother.PrinterInfo,
+		brother.PrinterUptime,
+		brother.PrinterStatus,
+		brother.TonerLevel,
+		brother.TonerStatus,
+		brother.InkLevel,
+		brother.InkStatus,
+		brother.DrumLevel,
+		brother.DrumStatus,
+		brother.PaperTrayStatus,
+		brother.PageCountTotal,
+		brother.PageCountBlack,
+		brother.PageCountColor,
+		brother.PageCountDuplex,
+		brother.PageCountDrumBlack,
+		brother.PageCountDrumCyan,
+		brother.PageCountDrumMagenta,
+		brother.PageCountDrumYellow,
+		brother.BeltUnitRemainingPages,
+		brother.FuserUnitRemainingPages,
+		brother.LaserUnitRemainingPages,
+		brother.PaperFeedingKitRemainingPages,
+		brother.BeltUnitRemainingPercent,
+		brother.FuserUnitRemainingPercent,
+		brother.LaserUnitRemainingPercent,
+		brother.PaperFeedingKitRemainingPercent,
+		brother.SupplyState,
+		brother.SupplyNearEnd,
+		brother.Alert,
+		brother.ScannerInfo,
+		brother.ScannerState,
+		brother.ScannerAdfState,
+		brother.ScannerActiveJobs,
+	}
+
+	brother.vectors = []resetter{
+		brother.PrinterConnectionStatus,
+		brother.PrinterConnectionErrors,
+		brother.PrinterInfo,
+		brother.PrinterUptime,
+		brother.PrinterStatus,
+		brother.TonerLevel,
+		brother.TonerStatus,
+		brother.InkLevel,
+		brother.InkStatus,
+		brother.DrumLevel,
+		brother.DrumStatus,
+		brother.PaperTrayStatus,
+		brother.PageCountTotal,
+		brother.PageCountBlack,
+		brother.PageCountColor,
+		brother.PageCountDuplex,
+		brother.PageCountDrumBlack,
+		brother.PageCountDrumCyan,
+		brother.PageCountDrumMagenta,
+		brother.PageCountDrumYellow,
+		brother.BeltUnitRemainingPages,
+		brother.FuserUnitRemainingPages,
+		brother.LaserUnitRemainingPages,
+		brother.PaperFeedingKitRemainingPages,
+		brother.BeltUnitRemainingPercent,
+		brother.FuserUnitRemainingPercent,
+		brother.LaserUnitRemainingPercent,
+		brother.PaperFeedingKitRemainingPercent,
+		brother.MaintenanceCount,
+		brother.SupplyState,
+		brother.SupplyNearEnd,
+		brother.Alert,
+		brother.CollectionDuration,
+		brother.LastSuccessTimestamp,
+		brother.SNMPRequests,
+		brother.SNMPVarbinds,
+		brother.DataAge,
+		brother.SourceUp,
+		brother.SourceErrors,
+		brother.SourceDuration,
+		brother.SourceLastSuccessTimestamp,
+		brother.TrapsReceived,
+		brother.ScannerInfo,
+		brother.ScannerState,
+		brother.ScannerAdfState,
+		brother.ScannerActiveJobs,
+		brother.ScannerJobsCompleted,
+	}
+
+	brother.own = prometheus.NewRegistry()
+	for _, vector := range brother.vectors {
+		brother.own.MustRegister(vector)
+	}
 
 	return brother
 }
+
+// Gatherer gathers the Brother metrics, without the Go runtime and process
+// metrics of the base registry
+func (r *BrotherRegistry) Gatherer() prometheus.Gatherer {
+	return r.own
+}
+
+// ResetPrinterData deletes every series holding values read from the
+// printer, leaving connection and exporter metrics in place
+func (r *BrotherRegistry) ResetPrinterData() {
+	for _, vector := range r.dataVectors {
+		vector.Reset()
+	}
+}
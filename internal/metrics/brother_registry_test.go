@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedLabelNames(t *testing.T) {
+	const infoName = "brother_exporter_info_test"
+
+	baseRegistry := promexporter_metrics.NewRegistry(infoName)
+	NewBrotherRegistry(baseRegistry)
+
+	names := make(map[string]bool)
+
+	for _, info := range baseRegistry.GetMetricsInfo() {
+		if info.Name == infoName {
+			continue
+		}
+
+		for _, label := range info.Labels {
+			names[label] = true
+		}
+	}
+
+	assert.Equal(t, slices.Sorted(maps.Keys(names)), config.ReservedLabelNames)
+}
@@ -0,0 +1,290 @@
+// Package mqtt publishes printer state to an MQTT broker after each
+// collection cycle, with Home Assistant discovery so the printers show up
+// as devices without further configuration.
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Availability payloads
+const (
+	online  = "online"
+	offline = "offline"
+)
+
+// queueSize bounds the states waiting to be sent
+const queueSize = 64
+
+// Publisher publishes printer state to the broker. It implements the
+// promexporter collector interface so it connects and disconnects with the
+// application, and Publish is subscribed to the printer store. Sending runs
+// on its own goroutine so a slow broker doesn't hold up collection.
+type Publisher struct {
+	config config.MQTTConfig
+	client paho.Client
+
+	mu sync.Mutex
+	// latest holds the last state of each printer, republished on
+	// (re)connect
+	latest  map[string]printer.State
+	stopped bool
+
+	queue chan printer.State
+	// connected is signalled on each (re)connect so the sending goroutine
+	// republishes everything
+	connected chan struct{}
+	wg        sync.WaitGroup
+
+	// discovered holds the discovery topics sent since the last connect.
+	// Only the sending goroutine uses it.
+	discovered map[string]bool
+}
+
+// NewPublisher creates a publisher for the configured broker. The exporter
+// availability topic is set as the last will, so Home Assistant marks the
+// sensors unavailable when the exporter goes away.
+func NewPublisher(cfg config.MQTTConfig) *Publisher {
+	p := &Publisher{
+		config:     cfg,
+		latest:     make(map[string]printer.State),
+		queue:      make(chan printer.State, queueSize),
+		connected:  make(chan struct{}, 1),
+		discovered: make(map[string]bool),
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectTimeout(cfg.Timeout.Duration).
+		SetWriteTimeout(cfg.Timeout.Duration).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(p.bridgeTopic(), offline, cfg.QoS, true).
+		SetOnConnectHandler(p.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			slog.Warn("Lost connection to MQTT broker", "broker", cfg.Broker, "error", err)
+		})
+
+	p.client = paho.NewClient(opts)
+
+	return p
+}
+
+// Start sends the queued states in the background and connects to the
+// broker, retrying until it succeeds
+func (p *Publisher) Start(_ context.Context) {
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		p.run()
+	}()
+
+	slog.Info("Connecting to MQTT broker", "broker", p.config.Broker)
+
+	p.client.Connect()
+}
+
+// Stop sends the queued states, marks the exporter offline and
+// disconnects. Later states are dropped.
+func (p *Publisher) Stop() {
+	p.mu.Lock()
+
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+
+	p.stopped = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+
+	if p.client.IsConnectionOpen() {
+		p.publish(p.bridgeTopic(), offline, true)
+	}
+
+	p.client.Disconnect(250)
+}
+
+// Publish queues a printer's state for sending. It is kept and sent on
+// connect when the broker is unavailable.
+func (p *Publisher) Publish(state printer.State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latest[state.ID] = state
+
+	if p.stopped {
+		return
+	}
+
+	select {
+	case p.queue <- state:
+	default:
+		slog.Warn("MQTT queue full, dropping state", "printer", state.ID)
+	}
+}
+
+// onConnect asks the sending goroutine to announce the exporter and resend
+// discovery and the latest state, which the broker may have lost
+func (p *Publisher) onConnect(_ paho.Client) {
+	slog.Info("Connected to MQTT broker", "broker", p.config.Broker)
+
+	select {
+	case p.connected <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued states and republishes on connect until the queue is
+// closed
+func (p *Publisher) run() {
+	for {
+		select {
+		case state, ok := <-p.queue:
+			if !ok {
+				return
+			}
+
+			if p.client.IsConnectionOpen() {
+				p.publishState(state)
+			}
+		case <-p.connected:
+			p.republish()
+		}
+	}
+}
+
+// republish announces the exporter and resends every printer's latest
+// state, with discovery
+func (p *Publisher) republish() {
+	p.publish(p.bridgeTopic(), online, true)
+
+	p.mu.Lock()
+	states := slices.Collect(maps.Values(p.latest))
+	p.mu.Unlock()
+
+	clear(p.discovered)
+
+	for _, state := range states {
+		p.publishState(state)
+	}
+}
+
+// publishState sends a printer's discovery config, availability and
+// sensor values. Printers whose identity hasn't been collected yet are
+// skipped, as their topics are keyed by serial number.
+func (p *Publisher) publishState(state printer.State) {
+	id, ok := deviceID(state)
+	if !ok {
+		slog.Debug("Not publishing printer without identity to MQTT", "printer", state.ID)
+		return
+	}
+
+	sensors := stateSensors(state)
+
+	if p.config.IsDiscoveryEnabled() {
+		for _, s := range sensors {
+			topic := p.discoveryTopic(id, s)
+			if p.discovered[topic] {
+				continue
+			}
+
+			payload, err := p.discoveryPayload(id, state, s)
+			if err != nil {
+				slog.Error("Failed to encode MQTT discovery config", "topic", topic, "error", err)
+				continue
+			}
+
+			if p.publish(topic, payload, true) {
+				p.discovered[topic] = true
+			}
+		}
+	}
+
+	availability := offline
+	if state.Reachable {
+		availability = online
+	}
+
+	p.publish(p.availabilityTopic(id), availability, true)
+
+	for _, s := range sensors {
+		p.publish(p.stateTopic(id, s.key), s.value, p.config.Retain)
+	}
+}
+
+// publish sends a message and waits for it to be sent, reporting success
+func (p *Publisher) publish(topic string, payload any, retained bool) bool {
+	token := p.client.Publish(topic, p.config.QoS, retained, payload)
+
+	if !token.WaitTimeout(p.config.Timeout.Duration) {
+		slog.Error("Timed out publishing to MQTT", "topic", topic)
+		return false
+	}
+
+	if err := token.Error(); err != nil {
+		slog.Error("Failed to publish to MQTT", "topic", topic, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// bridgeTopic is the exporter's own availability topic
+func (p *Publisher) bridgeTopic() string {
+	return p.config.TopicPrefix + "/availability"
+}
+
+// availabilityTopic is a printer's availability, driven by whether the
+// last cycle reached it
+func (p *Publisher) availabilityTopic(id string) string {
+	return fmt.Sprintf("%s/%s/availability", p.config.TopicPrefix, id)
+}
+
+func (p *Publisher) stateTopic(id, key string) string {
+	return fmt.Sprintf("%s/%s/%s", p.config.TopicPrefix, id, key)
+}
+
+// deviceID returns the topic segment of a printer: its serial number, or
+// its ID when the sources don't report one
+func deviceID(state printer.State) (string, bool) {
+	if state.Snapshot == nil || state.Snapshot.Identity == nil {
+		return "", false
+	}
+
+	id := state.Snapshot.Identity.Serial
+	if id == "" {
+		id = state.ID
+	}
+
+	return topicSegment(id), true
+}
+
+// topicSegment makes s safe to use as a single topic level
+func topicSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', ' ':
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
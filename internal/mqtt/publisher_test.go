@@ -0,0 +1,221 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBroker runs an embedded broker and returns its URL
+func startBroker(t *testing.T) string {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{Logger: slog.New(slog.DiscardHandler)})
+	require.NoError(t, server.AddHook(new(auth.AllowHook), nil))
+
+	listener := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	require.NoError(t, server.AddListener(listener))
+
+	go func() { _ = server.Serve() }()
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	return "tcp://" + listener.Address()
+}
+
+// recorder subscribes to every topic and keeps the last message of each
+type recorder struct {
+	mu       sync.Mutex
+	messages map[string]paho.Message
+}
+
+func subscribe(t *testing.T, broker, clientID string) *recorder {
+	t.Helper()
+
+	r := &recorder{messages: make(map[string]paho.Message)}
+
+	client := paho.NewClient(paho.NewClientOptions().AddBroker(broker).SetClientID(clientID))
+	require.True(t, client.Connect().WaitTimeout(5*time.Second))
+
+	token := client.Subscribe("#", 1, func(_ paho.Client, msg paho.Message) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.messages[msg.Topic()] = msg
+	})
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+
+	t.Cleanup(func() { client.Disconnect(0) })
+
+	return r
+}
+
+// wait returns the last message on topic once its payload is want
+func (r *recorder) wait(t *testing.T, topic, want string) paho.Message {
+	t.Helper()
+
+	var msg paho.Message
+
+	assert.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		msg = r.messages[topic]
+
+		return msg != nil && string(msg.Payload()) == want
+	}, 5*time.Second, 10*time.Millisecond, "waiting for %q on %s", want, topic)
+
+	return msg
+}
+
+func (r *recorder) payload(topic string) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if msg := r.messages[topic]; msg != nil {
+		return msg.Payload()
+	}
+
+	return nil
+}
+
+func newTestPublisher(broker string) *Publisher {
+	return NewPublisher(config.MQTTConfig{
+		Broker:          broker,
+		ClientID:        "brother-exporter",
+		TopicPrefix:     "brother",
+		DiscoveryPrefix: "homeassistant",
+		QoS:             1,
+		Timeout:         config.Duration{Duration: 5 * time.Second},
+	})
+}
+
+func TestPublisher(t *testing.T) {
+	broker := startBroker(t)
+
+	store := printer.NewStore()
+	publisher := newTestPublisher(broker)
+	store.Subscribe(publisher.Publish)
+
+	collectedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Published before the connection is up, sent on connect
+	store.Update("192.168.1.100", "192.168.1.100", &printer.Snapshot{
+		CollectedAt: collectedAt,
+		Identity:    &printer.Identity{Model: "HL-L3270CDW series", Serial: "E78096A9N123456", Firmware: "1.45"},
+		Status:      &printer.Status{State: "ready"},
+		Supplies: []printer.Supply{
+			{Kind: "toner", Color: "black", Percent: 60},
+			{Kind: "belt_unit", Percent: 90},
+		},
+		Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: 48000}},
+		Trays:       []printer.Tray{{Name: "tray1", Status: "ok"}},
+		Counters:    map[string]float64{printer.CounterTotal: 5432},
+	}, collectedAt, nil)
+
+	r := subscribe(t, broker, "recorder")
+
+	publisher.Start(t.Context())
+	defer publisher.Stop()
+
+	r.wait(t, "brother/availability", "online")
+	r.wait(t, "brother/E78096A9N123456/availability", "online")
+	r.wait(t, "brother/E78096A9N123456/status", "ready")
+	r.wait(t, "brother/E78096A9N123456/toner/black", "60")
+	r.wait(t, "brother/E78096A9N123456/belt_unit", "90")
+	r.wait(t, "brother/E78096A9N123456/maintenance/fuser_unit", "48000")
+	r.wait(t, "brother/E78096A9N123456/tray/tray1", "ok")
+	r.wait(t, "brother/E78096A9N123456/pages/total", "5432")
+	r.wait(t, "brother/E78096A9N123456/last_seen", "2026-03-01T12:00:00Z")
+
+	var discovery map[string]any
+
+	require.Eventually(t, func() bool {
+		return r.payload("homeassistant/sensor/brother_E78096A9N123456/toner_black/config") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, json.Unmarshal(r.payload("homeassistant/sensor/brother_E78096A9N123456/toner_black/config"), &discovery))
+
+	assert.Equal(t, "Toner black", discovery["name"])
+	assert.Equal(t, "brother_E78096A9N123456_toner_black", discovery["unique_id"])
+	assert.Equal(t, "brother/E78096A9N123456/toner/black", discovery["state_topic"])
+	assert.Equal(t, "%", discovery["unit_of_measurement"])
+	assert.Equal(t, "all", discovery["availability_mode"])
+	assert.Equal(t, []any{
+		map[string]any{"topic": "brother/availability"},
+		map[string]any{"topic": "brother/E78096A9N123456/availability"},
+	}, discovery["availability"])
+
+	device, _ := discovery["device"].(map[string]any)
+	assert.Equal(t, "Brother HL-L3270CDW series", device["name"])
+	assert.Equal(t, "1.45", device["sw_version"])
+
+	// Availability and discovery are retained for clients that subscribe
+	// later, the values only with retain enabled
+	late := subscribe(t, broker, "late")
+	assert.True(t, late.wait(t, "brother/availability", "online").Retained())
+	assert.True(t, late.wait(t, "brother/E78096A9N123456/availability", "online").Retained())
+	assert.NotNil(t, late.payload("homeassistant/sensor/brother_E78096A9N123456/toner_black/config"))
+	assert.Nil(t, late.payload("brother/E78096A9N123456/toner/black"))
+
+	// An unreachable printer is marked unavailable, its values kept
+	store.Update("192.168.1.100", "192.168.1.100", nil, collectedAt.Add(time.Minute), nil)
+	r.wait(t, "brother/E78096A9N123456/availability", "offline")
+	r.wait(t, "brother/E78096A9N123456/toner/black", "60")
+
+	// Stopping marks the exporter unavailable
+	publisher.Stop()
+	r.wait(t, "brother/availability", "offline")
+}
+
+func TestPublisherWithoutIdentity(t *testing.T) {
+	broker := startBroker(t)
+
+	r := subscribe(t, broker, "recorder")
+
+	publisher := newTestPublisher(broker)
+	publisher.Start(t.Context())
+
+	defer publisher.Stop()
+
+	r.wait(t, "brother/availability", "online")
+
+	// Printers are keyed by serial, so nothing is sent until it is known
+	publisher.Publish(printer.State{ID: "192.168.1.100", Snapshot: &printer.Snapshot{Status: &printer.Status{State: "ready"}}})
+
+	// Without a serial the printer ID is used
+	publisher.Publish(printer.State{ID: "192.168.1.101", Reachable: true, Snapshot: &printer.Snapshot{
+		Identity: &printer.Identity{Model: "HL-5250DN"},
+		Status:   &printer.Status{State: "printing"},
+	}})
+
+	r.wait(t, "brother/192.168.1.101/status", "printing")
+	assert.Nil(t, r.payload("brother/192.168.1.100/status"))
+}
+
+func TestPublishDoesNotBlock(t *testing.T) {
+	// Nothing drains the queue before Start, as while the broker is slow
+	publisher := newTestPublisher("tcp://127.0.0.1:1")
+
+	for i := range queueSize + 10 {
+		publisher.Publish(printer.State{ID: fmt.Sprintf("192.168.1.%d", i)})
+	}
+
+	assert.Len(t, publisher.queue, queueSize)
+	assert.Len(t, publisher.latest, queueSize+10)
+
+	publisher.Stop()
+	publisher.Publish(printer.State{ID: "192.168.1.200"})
+}
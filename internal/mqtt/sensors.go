@@ -0,0 +1,176 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// sensor is a value published under a printer's topic and announced to
+// Home Assistant as a sensor entity
+type sensor struct {
+	// key is the topic below the printer, e.g. toner/black
+	key   string
+	name  string
+	value string
+
+	unit        string
+	stateClass  string
+	deviceClass string
+	icon        string
+}
+
+// stateSensors lists the sensors of a printer's state, for the sections
+// collected so far
+func stateSensors(state printer.State) []sensor {
+	snap := state.Snapshot
+
+	var sensors []sensor
+
+	if snap.Status != nil {
+		sensors = append(sensors,
+			sensor{key: "status", name: "Status", value: snap.Status.State, icon: "mdi:printer"},
+			sensor{key: "alerts", name: "Active alerts", value: strconv.Itoa(len(snap.Alerts)), stateClass: "measurement", icon: "mdi:alert"},
+		)
+	}
+
+	for _, supply := range snap.Supplies {
+		key, name := supply.Kind, label(supply.Kind)
+		if supply.Color != "" {
+			key += "/" + supply.Color
+			name += " " + supply.Color
+		}
+
+		sensors = append(sensors, sensor{
+			key:        key,
+			name:       name,
+			value:      formatFloat(supply.Percent),
+			unit:       "%",
+			stateClass: "measurement",
+			icon:       supplyIcon(supply.Kind),
+		})
+	}
+
+	for _, part := range snap.Maintenance {
+		sensors = append(sensors, sensor{
+			key:        "maintenance/" + part.Kind,
+			name:       label(part.Kind) + " remaining pages",
+			value:      formatFloat(part.RemainingPages),
+			unit:       "pages",
+			stateClass: "measurement",
+			icon:       "mdi:wrench-clock",
+		})
+	}
+
+	for _, tray := range snap.Trays {
+		sensors = append(sensors, sensor{
+			key:   "tray/" + topicSegment(tray.Name),
+			name:  label(tray.Name),
+			value: tray.Status,
+			icon:  "mdi:tray",
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(snap.Counters)) {
+		value := snap.Counters[name]
+		sensors = append(sensors, sensor{
+			key:        "pages/" + name,
+			name:       label(name) + " pages",
+			value:      formatFloat(value),
+			unit:       "pages",
+			stateClass: "total_increasing",
+			icon:       "mdi:counter",
+		})
+	}
+
+	if !state.LastSuccess.IsZero() {
+		sensors = append(sensors, sensor{
+			key:         "last_seen",
+			name:        "Last seen",
+			value:       state.LastSuccess.UTC().Format(time.RFC3339),
+			deviceClass: "timestamp",
+		})
+	}
+
+	return sensors
+}
+
+// discoveryTopic is where a sensor's Home Assistant config is published
+func (p *Publisher) discoveryTopic(id string, s sensor) string {
+	return fmt.Sprintf("%s/sensor/brother_%s/%s/config", p.config.DiscoveryPrefix, id, strings.ReplaceAll(s.key, "/", "_"))
+}
+
+// discoveryPayload is a sensor's Home Assistant config. The sensor is
+// available while both the exporter and the printer are.
+func (p *Publisher) discoveryPayload(id string, state printer.State, s sensor) ([]byte, error) {
+	identity := state.Snapshot.Identity
+
+	name := identity.Name
+	if name == "" {
+		name = strings.TrimSpace("Brother " + identity.Model)
+	}
+
+	config := map[string]any{
+		"name":        s.name,
+		"unique_id":   fmt.Sprintf("brother_%s_%s", id, strings.ReplaceAll(s.key, "/", "_")),
+		"state_topic": p.stateTopic(id, s.key),
+		"availability": []map[string]string{
+			{"topic": p.bridgeTopic()},
+			{"topic": p.availabilityTopic(id)},
+		},
+		"availability_mode": "all",
+		"device": map[string]any{
+			"identifiers":   []string{"brother_" + id},
+			"name":          name,
+			"manufacturer":  "Brother",
+			"model":         identity.Model,
+			"serial_number": identity.Serial,
+			"sw_version":    identity.Firmware,
+		},
+	}
+
+	optional := map[string]string{
+		"unit_of_measurement": s.unit,
+		"state_class":         s.stateClass,
+		"device_class":        s.deviceClass,
+		"icon":                s.icon,
+	}
+
+	for key, value := range optional {
+		if value != "" {
+			config[key] = value
+		}
+	}
+
+	return json.Marshal(config)
+}
+
+// supplyIcon picks a Material Design icon for a supply kind
+func supplyIcon(kind string) string {
+	switch kind {
+	case "toner", "ink":
+		return "mdi:water-percent"
+	default:
+		return "mdi:printer-settings"
+	}
+}
+
+// label turns a snake case name into a sensor name, e.g. "Belt unit"
+func label(name string) string {
+	name = strings.ReplaceAll(name, "_", " ")
+	if name == "" {
+		return name
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
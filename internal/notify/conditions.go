@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Condition kinds
+const (
+	KindSupply      = "supply"
+	KindTray        = "tray"
+	KindAlert       = "alert"
+	KindUnreachable = "unreachable"
+)
+
+// condition is a problem a printer currently has. Conditions are keyed so
+// the same problem seen in successive cycles is only notified once.
+type condition struct {
+	key      string
+	kind     string
+	severity string
+	summary  string
+	// rank orders the stages of a condition, e.g. a low toner becoming
+	// critical, so a worse stage is notified again
+	rank int
+	// supply is the supply a supply condition is about
+	supply *printer.Supply
+}
+
+// supplyRanks are the supply states that are conditions, by rank
+var supplyRanks = map[string]int{"low": 1, "critical": 2, "empty": 3}
+
+// conditions lists the active conditions of a printer's state
+func conditions(state printer.State) map[string]condition {
+	active := make(map[string]condition)
+
+	if !state.Reachable {
+		active[KindUnreachable] = condition{
+			key:      KindUnreachable,
+			kind:     KindUnreachable,
+			severity: printer.SeverityCritical,
+			summary:  "Printer is unreachable",
+			rank:     1,
+		}
+	}
+
+	snap := state.Snapshot
+	if snap == nil {
+		return active
+	}
+
+	for _, supply := range snap.Supplies {
+		rank, ok := supplyRanks[supply.State]
+		if !ok {
+			continue
+		}
+
+		severity := printer.SeverityCritical
+		if supply.State == "low" {
+			severity = printer.SeverityWarning
+		}
+
+		key := supplyKey(supply)
+		active[key] = condition{
+			key:      key,
+			kind:     KindSupply,
+			severity: severity,
+			summary:  fmt.Sprintf("%s is %s (%.0f%%)", printer.SupplyName(supply), supply.State, supply.Percent),
+			rank:     rank,
+			supply:   &supply,
+		}
+	}
+
+	for _, tray := range snap.Trays {
+		if tray.Status != "empty" {
+			continue
+		}
+
+		key := KindTray + "/" + tray.Name
+		active[key] = condition{
+			key:      key,
+			kind:     KindTray,
+			severity: printer.SeverityWarning,
+			summary:  fmt.Sprintf("Paper tray %s is empty", tray.Name),
+			rank:     1,
+		}
+	}
+
+	for _, alert := range snap.Alerts {
+		key := KindAlert + "/" + alert.Code
+
+		summary := alert.Message
+		if summary == "" {
+			summary = strings.ReplaceAll(alert.Code, "_", " ")
+		}
+
+		rank := 1
+		if alert.Severity == printer.SeverityCritical {
+			rank = 2
+		}
+
+		active[key] = condition{
+			key:      key,
+			kind:     KindAlert,
+			severity: alert.Severity,
+			summary:  "Printer reports: " + summary,
+			rank:     rank,
+		}
+	}
+
+	return active
+}
+
+// resolvedSummary describes a condition that has cleared, with the
+// current level for supplies
+func resolvedSummary(c condition, state printer.State) string {
+	switch c.kind {
+	case KindUnreachable:
+		return "Printer is reachable again"
+	case KindTray:
+		return strings.Replace(c.summary, "is empty", "is no longer empty", 1)
+	case KindSupply:
+		for _, supply := range state.Snapshot.Supplies {
+			if supplyKey(supply) == c.key {
+				return fmt.Sprintf("%s is back to %s (%.0f%%)", printer.SupplyName(supply), supply.State, supply.Percent)
+			}
+		}
+
+		return fmt.Sprintf("%s is no longer reported", printer.SupplyName(*c.supply))
+	default:
+		return "Cleared: " + c.summary
+	}
+}
+
+func supplyKey(supply printer.Supply) string {
+	key := KindSupply + "/" + supply.Kind
+	if supply.Color != "" {
+		key += "/" + supply.Color
+	}
+
+	return key
+}
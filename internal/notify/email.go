@@ -0,0 +1,314 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+)
+
+// defaultSubject and defaultBody are used when no templates are configured
+const (
+	defaultSubject = `{{with index .Events 0}}[{{upper .Type}}] {{.Summary}}{{end}}` +
+		`{{if gt (len .Events) 1}} (+{{add (len .Events) -1}} more){{end}} - {{.Printer.Title}}`
+
+	defaultBody = `Printer:  {{.Printer.Title}}
+Model:    {{.Printer.Model}}
+Serial:   {{.Printer.Serial}}
+Location: {{.Printer.Location}}
+Host:     {{.Printer.Host}}
+{{range .Events}}
+[{{upper .Type}}] {{.Summary}}
+{{- with .Supply}}
+  Consumable: {{.Name}}
+  Level:      {{percent .Percent}} ({{.State}})
+{{- if .RemainingPages}}
+  Remaining:  {{pages .RemainingPages}} pages
+{{- end}}
+  Days left:  {{days .DaysLeft}}
+{{- end}}
+{{end}}`
+)
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"add":   func(a, b int) int { return a + b },
+	"percent": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64) + "%"
+	},
+	"pages": func(v *float64) string {
+		if v == nil {
+			return "unknown"
+		}
+
+		return fmt.Sprintf("%.0f", *v)
+	},
+	// days formats a projection, which is nil without enough history
+	"days": func(v *float64) string {
+		if v == nil {
+			return "unknown"
+		}
+
+		return fmt.Sprintf("~%.0f", *v)
+	},
+}
+
+// EmailData is what the subject and body templates are rendered with: the
+// events of one printer from one collection cycle
+type EmailData struct {
+	Printer Printer
+	Events  []Event
+}
+
+// Email sends events by email over SMTP, one message per batch
+type Email struct {
+	config  config.EmailConfig
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewEmail creates an email target, parsing its templates
+func NewEmail(cfg config.EmailConfig) (*Email, error) {
+	subjectText, bodyText := cfg.Subject, cfg.Body
+	if subjectText == "" {
+		subjectText = defaultSubject
+	}
+
+	if bodyText == "" {
+		bodyText = defaultBody
+	}
+
+	subject, err := template.New("subject").Funcs(templateFuncs).Parse(subjectText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email subject template: %w", err)
+	}
+
+	body, err := template.New("body").Funcs(templateFuncs).Parse(bodyText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email body template: %w", err)
+	}
+
+	return &Email{config: cfg, subject: subject, body: body}, nil
+}
+
+// Name identifies the target in logs
+func (e *Email) Name() string {
+	return "email " + e.config.Host
+}
+
+// Notify mails the events of the configured kinds to the printer's
+// recipients
+func (e *Email) Notify(ctx context.Context, events []Event) error {
+	if len(e.config.Kinds) > 0 {
+		events = slices.DeleteFunc(slices.Clone(events), func(event Event) bool {
+			return !slices.Contains(e.config.Kinds, event.Kind)
+		})
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	data := EmailData{Printer: events[0].Printer, Events: events}
+
+	recipients := e.recipients(data.Printer)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	message, err := e.message(data, recipients)
+	if err != nil {
+		return err
+	}
+
+	return e.send(ctx, recipients, message)
+}
+
+// recipients returns the default recipients and those configured for the
+// printer's host, serial, name or location
+func (e *Email) recipients(p Printer) []string {
+	recipients := slices.Clone(e.config.To)
+
+	for _, key := range []string{p.ID, p.Host, p.Serial, p.Name, p.Location} {
+		if key == "" {
+			continue
+		}
+
+		for _, recipient := range e.config.Recipients[key] {
+			if !slices.Contains(recipients, recipient) {
+				recipients = append(recipients, recipient)
+			}
+		}
+	}
+
+	return recipients
+}
+
+// message renders the templates into a MIME message
+func (e *Email) message(data EmailData, recipients []string) ([]byte, error) {
+	var subject, body bytes.Buffer
+
+	if err := e.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render email subject: %w", err)
+	}
+
+	if err := e.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	var msg bytes.Buffer
+
+	headers := []struct{ name, value string }{
+		{"From", e.config.From},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String()))},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@brother-exporter>", hex.EncodeToString(id))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header.name, header.value)
+	}
+
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write(bytes.ReplaceAll(body.Bytes(), []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+
+	return msg.Bytes(), nil
+}
+
+// send delivers a message, upgrading the connection with STARTTLS and
+// authenticating when configured
+func (e *Email) send(ctx context.Context, recipients []string, message []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         e.config.Host,
+		InsecureSkipVerify: e.config.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed relays
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.Timeout.Duration)
+	defer cancel()
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if e.config.TLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+
+	defer func() { _ = client.Close() }()
+
+	if e.config.IsStartTLSEnabled() {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if e.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := e.transmit(client, recipients, message); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (e *Email) transmit(client *smtp.Client, recipients []string, message []byte) error {
+	from, err := mailAddress(e.config.From)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+
+	for _, recipient := range recipients {
+		to, err := mailAddress(recipient)
+		if err != nil {
+			return err
+		}
+
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// mailAddress returns the bare address of "Name <address>"
+func mailAddress(s string) (string, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q: %w", s, err)
+	}
+
+	return addr.Address, nil
+}
@@ -0,0 +1,340 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP is a minimal SMTP server supporting STARTTLS and AUTH PLAIN
+type fakeSMTP struct {
+	listener net.Listener
+	tls      *tls.Config
+
+	mu       sync.Mutex
+	auth     string
+	tlsUsed  bool
+	from     string
+	rcpts    []string
+	messages []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTP{listener: listener, tls: &tls.Config{Certificates: []tls.Certificate{selfSigned(t)}}}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250-STARTTLS")
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready")
+
+			tlsConn := tls.Server(conn, s.tls)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+
+			s.mu.Lock()
+			s.tlsUsed = true
+			s.mu.Unlock()
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = line
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, line)
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+
+				data.WriteString(l)
+			}
+
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// selfSigned creates a certificate for 127.0.0.1
+func selfSigned(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func emailConfig(server *fakeSMTP) config.EmailConfig {
+	return config.EmailConfig{
+		Host:               "127.0.0.1",
+		Port:               server.port(),
+		Username:           "exporter",
+		Password:           "secret",
+		InsecureSkipVerify: true,
+		From:               "Printers <printers@example.com>",
+		To:                 []string{"it@example.com"},
+		Recipients:         map[string][]string{"Office": {"office@example.com"}},
+		Timeout:            config.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func TestEmail(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	email, err := NewEmail(emailConfig(server))
+	require.NoError(t, err)
+
+	pages, days := 1200.0, 12.4
+	events := []Event{{
+		Type:     EventFiring,
+		Kind:     KindSupply,
+		Key:      "supply/drum/black",
+		Severity: printer.SeverityWarning,
+		Summary:  "Drum black is low (8%)",
+		Printer:  Printer{ID: "192.168.1.100", Host: "192.168.1.100", Model: "HL-L3270CDW", Serial: "E78096A9N123456", Location: "Office"},
+		Supply:   &Supply{Kind: "drum", Color: "black", Name: "Drum black", Percent: 8, State: "low", RemainingPages: &pages, DaysLeft: &days},
+	}, {
+		Type:    EventFiring,
+		Kind:    KindTray,
+		Summary: "Paper tray tray1 is empty",
+		Printer: Printer{ID: "192.168.1.100", Host: "192.168.1.100", Location: "Office"},
+	}}
+
+	require.NoError(t, email.Notify(t.Context(), events))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	assert.True(t, server.tlsUsed)
+	assert.Equal(t, "\x00exporter\x00secret", server.auth)
+	assert.Equal(t, "MAIL FROM:<printers@example.com>", server.from)
+	assert.Equal(t, []string{"RCPT TO:<it@example.com>", "RCPT TO:<office@example.com>"}, server.rcpts)
+	require.Len(t, server.messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	require.NoError(t, err)
+
+	assert.Equal(t, "[FIRING] Drum black is low (8%) (+1 more) - 192.168.1.100 (HL-L3270CDW)", msg.Header.Get("Subject"))
+	assert.Equal(t, "it@example.com, office@example.com", msg.Header.Get("To"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		"Model:    HL-L3270CDW",
+		"Serial:   E78096A9N123456",
+		"Location: Office",
+		"Consumable: Drum black",
+		"Level:      8% (low)",
+		"Remaining:  1200 pages",
+		"Days left:  ~12",
+		"[FIRING] Paper tray tray1 is empty",
+	} {
+		assert.Contains(t, string(body), want)
+	}
+}
+
+func TestEmailTemplatesAndKinds(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	cfg := emailConfig(server)
+	cfg.Kinds = []string{KindSupply}
+	cfg.Subject = "Order {{(index .Events 0).Supply.Name}} for {{.Printer.Location}}"
+	cfg.Body = "{{range .Events}}{{.Supply.Name}}: {{days .Supply.DaysLeft}} days{{end}}"
+
+	email, err := NewEmail(cfg)
+	require.NoError(t, err)
+
+	// Filtered out entirely, nothing is sent
+	require.NoError(t, email.Notify(t.Context(), []Event{{Kind: KindTray, Printer: Printer{Host: "192.168.1.100"}}}))
+
+	require.NoError(t, email.Notify(t.Context(), []Event{
+		{Kind: KindTray, Printer: Printer{Host: "192.168.1.100"}},
+		{Kind: KindSupply, Printer: Printer{Host: "192.168.1.100", Location: "Office"}, Supply: &Supply{Name: "Toner cyan"}},
+	}))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	require.Len(t, server.messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	require.NoError(t, err)
+
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	assert.Equal(t, "Order Toner cyan for Office", msg.Header.Get("Subject"))
+	assert.Equal(t, "Toner cyan: unknown days", strings.TrimSpace(string(body)))
+
+	_, err = NewEmail(config.EmailConfig{Subject: "{{"})
+	assert.ErrorContains(t, err, "subject template")
+}
+
+func TestEmailStartTLSRequired(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() { _ = listener.Close() }()
+
+	// A server that doesn't offer STARTTLS
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		r := bufio.NewReader(conn)
+		_, _ = io.WriteString(conn, "220 localhost\r\n")
+		_, _ = r.ReadString('\n')
+		_, _ = io.WriteString(conn, "250 localhost\r\n")
+		_, _ = r.ReadString('\n')
+		_, _ = io.WriteString(conn, "221 bye\r\n")
+	}()
+
+	cfg := emailConfig(&fakeSMTP{listener: listener})
+
+	email, err := NewEmail(cfg)
+	require.NoError(t, err)
+
+	err = email.Notify(t.Context(), []Event{{Kind: KindUnreachable, Printer: Printer{Host: "192.168.1.100"}}})
+	assert.ErrorContains(t, err, "does not support STARTTLS")
+}
+
+func TestForecast(t *testing.T) {
+	f := newForecast()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	state := func(toner, fuserPages float64) printer.State {
+		return printer.State{ID: "p", Reachable: true, Snapshot: &printer.Snapshot{
+			Supplies:    []printer.Supply{{Kind: "toner", Color: "black", Percent: toner}},
+			Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: fuserPages}},
+		}}
+	}
+
+	toner := printer.Supply{Kind: "toner", Color: "black", Percent: 40}
+
+	f.observe(state(50, 10000), start)
+
+	// Not enough history yet
+	_, ok := f.daysLeft("p", toner, nil, start.Add(12*time.Hour))
+	assert.False(t, ok)
+
+	// 10% over 10 days leaves 40 days at 1% a day
+	f.observe(state(40, 9000), start.Add(10*24*time.Hour))
+	days, ok := f.daysLeft("p", toner, nil, start.Add(10*24*time.Hour))
+	require.True(t, ok)
+	assert.InDelta(t, 40, days, 0.01)
+
+	// Parts project from their remaining pages, 100 pages a day
+	pages := 9000.0
+	days, ok = f.daysLeft("p", printer.Supply{Kind: "fuser_unit", Percent: 90}, &pages, start.Add(10*24*time.Hour))
+	require.True(t, ok)
+	assert.InDelta(t, 90, days, 0.01)
+
+	// A replaced toner starts over
+	f.observe(state(100, 9000), start.Add(11*24*time.Hour))
+	_, ok = f.daysLeft("p", printer.Supply{Kind: "toner", Color: "black", Percent: 100}, nil, start.Add(11*24*time.Hour))
+	assert.False(t, ok)
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Usage samples are kept at most this often and for this long; the
+// projection uses the rate between the oldest sample and now
+const (
+	sampleInterval = time.Hour
+	sampleWindow   = 30 * 24 * time.Hour
+	// minSpan is how much history a projection needs
+	minSpan = 24 * time.Hour
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// forecast projects when supplies run out from how fast their level, or
+// the remaining pages of maintenance parts, went down. Samples are kept in
+// memory; with a history database they are seeded from its snapshots, so
+// a restart doesn't wait another day for a projection.
+type forecast struct {
+	samples map[string][]sample
+	history *history.DB
+	seeded  map[string]bool
+}
+
+func newForecast() *forecast {
+	return &forecast{samples: make(map[string][]sample), seeded: make(map[string]bool)}
+}
+
+// observe records the levels of a printer's supplies and parts
+func (f *forecast) observe(state printer.State, now time.Time) {
+	if !state.Reachable || state.Snapshot == nil {
+		return
+	}
+
+	if f.history != nil && !f.seeded[state.ID] {
+		f.seeded[state.ID] = true
+		f.seed(state.ID, now)
+	}
+
+	for _, supply := range state.Snapshot.Supplies {
+		f.record(state.ID+"/"+supplyKey(supply), supply.Percent, now)
+	}
+
+	for _, part := range state.Snapshot.Maintenance {
+		f.record(state.ID+"/pages/"+part.Kind, part.RemainingPages, now)
+	}
+}
+
+// seed records the levels of a printer's history snapshots in the window
+func (f *forecast) seed(id string, now time.Time) {
+	snapshots, err := f.history.Query(id, now.Add(-sampleWindow), now)
+	if err != nil {
+		slog.Error("Failed to read history for the supply forecast", "printer", id, "error", err)
+		return
+	}
+
+	for _, snap := range snapshots {
+		for _, supply := range snap.Supplies {
+			f.record(id+"/"+supplyKey(printer.Supply{Kind: supply.Kind, Color: supply.Color}), supply.Percent, snap.Time)
+		}
+
+		for kind, pages := range snap.Maintenance {
+			f.record(id+"/pages/"+kind, pages, snap.Time)
+		}
+	}
+}
+
+// record adds a sample, starting over when the value went up, i.e. the
+// supply was replaced
+func (f *forecast) record(key string, value float64, now time.Time) {
+	samples := f.samples[key]
+
+	if n := len(samples); n > 0 {
+		if value > samples[n-1].value {
+			samples = nil
+		} else if now.Sub(samples[n-1].at) < sampleInterval {
+			return
+		}
+	}
+
+	samples = append(samples, sample{at: now, value: value})
+
+	// Drop what fell out of the window, keeping one sample older than it
+	// so the span covers the whole window
+	for len(samples) > 2 && now.Sub(samples[1].at) > sampleWindow {
+		samples = samples[1:]
+	}
+
+	f.samples[key] = samples
+}
+
+// daysLeft projects the days until a supply runs out, preferring the
+// remaining pages of maintenance parts over their percentage
+func (f *forecast) daysLeft(id string, supply printer.Supply, remainingPages *float64, now time.Time) (float64, bool) {
+	if remainingPages != nil {
+		if days, ok := f.project(id+"/pages/"+supply.Kind, *remainingPages, now); ok {
+			return days, true
+		}
+	}
+
+	return f.project(id+"/"+supplyKey(supply), supply.Percent, now)
+}
+
+// project divides the current value by its rate of decrease
+func (f *forecast) project(key string, current float64, now time.Time) (float64, bool) {
+	samples := f.samples[key]
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	oldest := samples[0]
+	span := now.Sub(oldest.at)
+
+	used := oldest.value - current
+	if span < minSpan || used <= 0 {
+		return 0, false
+	}
+
+	perDay := used / (span.Hours() / 24)
+
+	return current / perDay, true
+}
@@ -0,0 +1,410 @@
+// Package notify sends notifications when a printer's consumables, trays,
+// alerts or reachability change, to webhooks and other targets.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Event types
+const (
+	// EventFiring is sent when a condition appears or gets worse
+	EventFiring = "firing"
+	// EventReminder is resent while a condition lasts
+	EventReminder = "reminder"
+	// EventResolved is sent when a notified condition clears
+	EventResolved = "resolved"
+)
+
+// Event is a notification about one condition of one printer
+type Event struct {
+	Type     string    `json:"type"`
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key"`
+	Severity string    `json:"severity"`
+	Summary  string    `json:"summary"`
+	Time     time.Time `json:"time"`
+
+	Printer Printer `json:"printer"`
+
+	// Supply is the supply of a supply event, as last collected
+	Supply *Supply `json:"supply,omitempty"`
+}
+
+// Supply is a consumable or maintenance part level. RemainingPages is set
+// for maintenance parts that report it; DaysLeft once there is enough
+// history to project when the supply runs out.
+type Supply struct {
+	Kind           string   `json:"kind"`
+	Color          string   `json:"color,omitempty"`
+	Name           string   `json:"name"`
+	Percent        float64  `json:"percent"`
+	State          string   `json:"state"`
+	RemainingPages *float64 `json:"remaining_pages,omitempty"`
+	DaysLeft       *float64 `json:"days_left,omitempty"`
+}
+
+// Printer identifies the printer an event is about
+type Printer struct {
+	ID       string `json:"id"`
+	Host     string `json:"host"`
+	Model    string `json:"model,omitempty"`
+	Serial   string `json:"serial,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// Title names the printer in messages
+func (p Printer) Title() string {
+	title := p.Host
+	if p.Name != "" {
+		title = p.Name
+	}
+
+	if p.Model != "" {
+		title += " (" + p.Model + ")"
+	}
+
+	return title
+}
+
+// Notifier delivers events to a target
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, events []Event) error
+}
+
+// queueSize bounds the batches waiting to be sent
+const queueSize = 64
+
+// Manager turns successive printer states into events and hands them to
+// the notifiers. Observe is subscribed to the printer store; delivery runs
+// on its own goroutine so a slow target doesn't hold up collection. It
+// implements the promexporter collector interface.
+type Manager struct {
+	notifiers []Notifier
+	reminder  time.Duration
+	quiet     *quietHours
+	forecast  *forecast
+	now       func() time.Time
+
+	mu       sync.Mutex
+	printers map[string]map[string]*tracked
+	stopped  bool
+
+	queue chan []Event
+	wg    sync.WaitGroup
+}
+
+// tracked is a condition seen for a printer and what was sent about it
+type tracked struct {
+	condition
+	// notified is when the last firing or reminder was sent, zero while
+	// held back by quiet hours
+	notified time.Time
+}
+
+// NewManager creates a manager for the configured targets
+func NewManager(cfg config.NotificationsConfig) (*Manager, error) {
+	quiet, err := newQuietHours(cfg.QuietHours)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		reminder: cfg.ReminderInterval.Duration,
+		quiet:    quiet,
+		forecast: newForecast(),
+		now:      time.Now,
+		printers: make(map[string]map[string]*tracked),
+		queue:    make(chan []Event, queueSize),
+	}
+
+	for _, webhook := range cfg.Webhooks {
+		m.notifiers = append(m.notifiers, NewWebhook(webhook))
+	}
+
+	if cfg.Email.Enabled() {
+		email, err := NewEmail(cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		m.notifiers = append(m.notifiers, email)
+	}
+
+	return m, nil
+}
+
+// Start delivers events in the background until Stop
+func (m *Manager) Start(ctx context.Context) {
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		for events := range m.queue {
+			m.deliver(ctx, events)
+		}
+	}()
+}
+
+// Stop delivers the queued events and stops. Later events are dropped.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	m.stopped = true
+	close(m.queue)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// Observe compares a printer's state with the previous one and queues the
+// resulting events
+func (m *Manager) Observe(state printer.State) {
+	events := m.evaluate(state)
+	if len(events) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return
+	}
+
+	select {
+	case m.queue <- events:
+	default:
+		slog.Warn("Notification queue full, dropping events", "printer", state.ID, "events", len(events))
+	}
+}
+
+// evaluate updates the tracked conditions of a printer and returns the
+// events to send. A condition is notified when it appears or gets worse,
+// reminded every reminder interval and resolved once, if it was notified.
+// During quiet hours nothing is sent; what is still pending afterwards is
+// sent with the first cycle after they end.
+func (m *Manager) evaluate(state printer.State) []Event {
+	now := m.now()
+	quiet := m.quiet.contains(now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.forecast.observe(state, now)
+
+	seen, ok := m.printers[state.ID]
+	if !ok {
+		seen = make(map[string]*tracked)
+		m.printers[state.ID] = seen
+	}
+
+	active := conditions(state)
+	target := newPrinter(state)
+
+	var events []Event
+
+	for key, c := range active {
+		t, ok := seen[key]
+
+		switch {
+		case !ok:
+			t = &tracked{condition: c}
+			seen[key] = t
+		case c.rank > t.rank:
+			// Worse than what was notified, notify again
+			t.condition = c
+			t.notified = time.Time{}
+		default:
+			// Keep the latest details, e.g. the current level
+			rank := t.rank
+			t.condition = c
+			t.rank = rank
+		}
+
+		if quiet {
+			continue
+		}
+
+		switch {
+		case t.notified.IsZero():
+			events = append(events, newEvent(EventFiring, t.condition, t.summary, target, now))
+			t.notified = now
+		case m.reminder > 0 && now.Sub(t.notified) >= m.reminder:
+			events = append(events, newEvent(EventReminder, t.condition, t.summary, target, now))
+			t.notified = now
+		}
+	}
+
+	for key, t := range seen {
+		if _, ok := active[key]; ok {
+			continue
+		}
+
+		// Never notified, so there is nothing to resolve
+		if t.notified.IsZero() {
+			delete(seen, key)
+			continue
+		}
+
+		// Held back, so it is resolved after quiet hours
+		if quiet {
+			continue
+		}
+
+		events = append(events, newEvent(EventResolved, t.condition, resolvedSummary(t.condition, state), target, now))
+		delete(seen, key)
+	}
+
+	for i := range events {
+		m.describeSupply(&events[i], state, now)
+	}
+
+	return events
+}
+
+// describeSupply sets the current level, remaining pages and projected
+// days left of a supply event's supply
+func (m *Manager) describeSupply(event *Event, state printer.State, now time.Time) {
+	if event.Supply == nil || state.Snapshot == nil {
+		return
+	}
+
+	for _, supply := range state.Snapshot.Supplies {
+		if supplyKey(supply) == event.Key {
+			event.Supply.Percent = supply.Percent
+			event.Supply.State = supply.State
+		}
+	}
+
+	for _, part := range state.Snapshot.Maintenance {
+		if part.Kind == event.Supply.Kind {
+			pages := part.RemainingPages
+			event.Supply.RemainingPages = &pages
+		}
+	}
+
+	supply := printer.Supply{Kind: event.Supply.Kind, Color: event.Supply.Color, Percent: event.Supply.Percent}
+	if days, ok := m.forecast.daysLeft(state.ID, supply, event.Supply.RemainingPages, now); ok {
+		event.Supply.DaysLeft = &days
+	}
+}
+
+// WithHistory seeds the days left projections from the snapshots of the
+// history database
+func (m *Manager) WithHistory(db *history.DB) *Manager {
+	m.forecast.history = db
+
+	return m
+}
+
+// deliver sends events to every notifier
+func (m *Manager) deliver(ctx context.Context, events []Event) {
+	for _, notifier := range m.notifiers {
+		if err := notifier.Notify(ctx, events); err != nil {
+			slog.Error("Failed to send notification", "target", notifier.Name(), "events", len(events), "error", err)
+			continue
+		}
+
+		slog.Debug("Sent notification", "target", notifier.Name(), "events", len(events))
+	}
+}
+
+func newPrinter(state printer.State) Printer {
+	p := Printer{ID: state.ID, Host: state.Host}
+
+	if state.Snapshot != nil && state.Snapshot.Identity != nil {
+		identity := state.Snapshot.Identity
+		p.Model = identity.Model
+		p.Serial = identity.Serial
+		p.Name = identity.Name
+		p.Location = identity.Location
+	}
+
+	return p
+}
+
+func newEvent(eventType string, c condition, summary string, target Printer, now time.Time) Event {
+	event := Event{
+		Type:     eventType,
+		Kind:     c.kind,
+		Key:      c.key,
+		Severity: c.severity,
+		Summary:  summary,
+		Time:     now,
+		Printer:  target,
+	}
+
+	if c.supply != nil {
+		event.Supply = &Supply{
+			Kind:    c.supply.Kind,
+			Color:   c.supply.Color,
+			Name:    printer.SupplyName(*c.supply),
+			Percent: c.supply.Percent,
+			State:   c.supply.State,
+		}
+	}
+
+	return event
+}
+
+// quietHours is a daily window in which nothing is sent
+type quietHours struct {
+	start, end int // minutes since midnight
+	location   *time.Location
+}
+
+func newQuietHours(cfg config.QuietHoursConfig) (*quietHours, error) {
+	if cfg.Start == "" {
+		return nil, nil
+	}
+
+	start, err := time.Parse("15:04", cfg.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start: %w", err)
+	}
+
+	end, err := time.Parse("15:04", cfg.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end: %w", err)
+	}
+
+	location := time.Local
+	if cfg.Timezone != "" {
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid quiet hours timezone: %w", err)
+		}
+	}
+
+	return &quietHours{
+		start:    start.Hour()*60 + start.Minute(),
+		end:      end.Hour()*60 + end.Minute(),
+		location: location,
+	}, nil
+}
+
+// contains reports whether t falls in the window, which may span midnight
+func (q *quietHours) contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	t = t.In(q.location)
+	minute := t.Hour()*60 + t.Minute()
+
+	if q.start <= q.end {
+		return minute >= q.start && minute < q.end
+	}
+
+	return minute >= q.start || minute < q.end
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a settable time source
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestManager(t *testing.T, cfg config.NotificationsConfig) (*Manager, *clock) {
+	t.Helper()
+
+	m, err := NewManager(cfg)
+	require.NoError(t, err)
+
+	c := &clock{now: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)}
+	m.now = c.Now
+
+	return m, c
+}
+
+func testState(reachable bool, toner string, level float64, trays ...printer.Tray) printer.State {
+	return printer.State{
+		ID:        "192.168.1.100",
+		Host:      "192.168.1.100",
+		Reachable: reachable,
+		Snapshot: &printer.Snapshot{
+			Identity: &printer.Identity{Model: "HL-L3270CDW", Serial: "E78096A9N123456", Location: "Office"},
+			Supplies: []printer.Supply{{Kind: "toner", Color: "black", Percent: level, State: toner}},
+			Trays:    trays,
+		},
+	}
+}
+
+func summaries(events []Event) []string {
+	var s []string
+	for _, event := range events {
+		s = append(s, event.Type+": "+event.Summary)
+	}
+
+	return s
+}
+
+func TestEvaluate(t *testing.T) {
+	m, c := newTestManager(t, config.NotificationsConfig{ReminderInterval: config.Duration{Duration: 24 * time.Hour}})
+
+	assert.Empty(t, m.evaluate(testState(true, "ok", 60)))
+
+	events := m.evaluate(testState(true, "low", 15))
+	assert.Equal(t, []string{"firing: Toner black is low (15%)"}, summaries(events))
+	assert.Equal(t, printer.SeverityWarning, events[0].Severity)
+	assert.Equal(t, "E78096A9N123456", events[0].Printer.Serial)
+	assert.Equal(t, "supply/toner/black", events[0].Key)
+	assert.Equal(t, 15.0, events[0].Supply.Percent)
+
+	// Not repeated while it lasts
+	c.now = c.now.Add(time.Hour)
+	assert.Empty(t, m.evaluate(testState(true, "low", 14)))
+
+	// Getting worse is notified again
+	events = m.evaluate(testState(true, "critical", 8))
+	assert.Equal(t, []string{"firing: Toner black is critical (8%)"}, summaries(events))
+	assert.Equal(t, printer.SeverityCritical, events[0].Severity)
+
+	// Flapping back to low isn't
+	assert.Empty(t, m.evaluate(testState(true, "low", 10)))
+	assert.Empty(t, m.evaluate(testState(true, "critical", 9)))
+
+	// Reminded after the interval
+	c.now = c.now.Add(24 * time.Hour)
+	assert.Equal(t, []string{"reminder: Toner black is critical (9%)"}, summaries(m.evaluate(testState(true, "critical", 9))))
+
+	// Trays and reachability
+	events = m.evaluate(testState(false, "critical", 9, printer.Tray{Name: "tray1", Status: "empty"}))
+	assert.ElementsMatch(t, []string{"firing: Printer is unreachable", "firing: Paper tray tray1 is empty"}, summaries(events))
+
+	events = m.evaluate(testState(true, "ok", 100, printer.Tray{Name: "tray1", Status: "ok"}))
+	assert.ElementsMatch(t, []string{
+		"resolved: Printer is reachable again",
+		"resolved: Paper tray tray1 is no longer empty",
+		"resolved: Toner black is back to ok (100%)",
+	}, summaries(events))
+
+	assert.Empty(t, m.evaluate(testState(true, "ok", 100)))
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	m, _ := newTestManager(t, config.NotificationsConfig{})
+
+	state := testState(true, "ok", 60)
+	state.Snapshot.Alerts = []printer.Alert{{Code: "jammed", Severity: printer.SeverityCritical, Message: "Paper Jam"}}
+
+	events := m.evaluate(state)
+	assert.Equal(t, []string{"firing: Printer reports: Paper Jam"}, summaries(events))
+	assert.Equal(t, KindAlert, events[0].Kind)
+
+	state.Snapshot.Alerts = nil
+	assert.Equal(t, []string{"resolved: Cleared: Printer reports: Paper Jam"}, summaries(m.evaluate(state)))
+}
+
+func TestQuietHours(t *testing.T) {
+	m, c := newTestManager(t, config.NotificationsConfig{
+		QuietHours: config.QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "UTC"},
+	})
+
+	c.now = time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	assert.Empty(t, m.evaluate(testState(true, "low", 15)))
+
+	// A condition that comes and goes within quiet hours is never sent
+	assert.Empty(t, m.evaluate(testState(false, "low", 15)))
+	assert.Empty(t, m.evaluate(testState(true, "low", 15)))
+
+	// What is still pending is sent when they end
+	c.now = time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"firing: Toner black is low (15%)"}, summaries(m.evaluate(testState(true, "low", 15))))
+
+	// Resolutions are held back too
+	c.now = time.Date(2026, 3, 3, 22, 30, 0, 0, time.UTC)
+	assert.Empty(t, m.evaluate(testState(true, "ok", 100)))
+
+	c.now = time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"resolved: Toner black is back to ok (100%)"}, summaries(m.evaluate(testState(true, "ok", 100))))
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	day, err := newQuietHours(config.QuietHoursConfig{Start: "12:00", End: "13:30", Timezone: "Europe/Dublin"})
+	require.NoError(t, err)
+
+	// Dublin is UTC+1 in summer
+	assert.True(t, day.contains(time.Date(2026, 7, 1, 11, 0, 0, 0, time.UTC)))
+	assert.False(t, day.contains(time.Date(2026, 7, 1, 12, 30, 0, 0, time.UTC)))
+
+	var none *quietHours
+	assert.False(t, none.contains(time.Now()))
+}
+
+func TestForecastSeededFromHistory(t *testing.T) {
+	db, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	m, c := newTestManager(t, config.NotificationsConfig{})
+
+	// The toner went from 25% to 20% over the last two days, so 15% lasts
+	// three more days
+	for day, level := range []float64{25, 20} {
+		require.NoError(t, db.Add("192.168.1.100", history.Snapshot{
+			Time:     c.now.Add(time.Duration(day-2) * 24 * time.Hour),
+			Supplies: []history.Supply{{Kind: "toner", Color: "black", Percent: level}},
+		}))
+	}
+
+	events := m.evaluate(testState(true, "low", 15))
+	require.Len(t, events, 1)
+	assert.Nil(t, events[0].Supply.DaysLeft, "without history")
+
+	m, _ = newTestManager(t, config.NotificationsConfig{})
+	m.WithHistory(db)
+
+	events = m.evaluate(testState(true, "low", 15))
+	require.Len(t, events, 1)
+	require.NotNil(t, events[0].Supply.DaysLeft)
+	assert.InDelta(t, 3, *events[0].Supply.DaysLeft, 0.001)
+}
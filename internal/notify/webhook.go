@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// discordMaxContent is Discord's message length limit
+const discordMaxContent = 2000
+
+// Webhook posts events to a URL, one request per batch
+type Webhook struct {
+	config config.WebhookConfig
+	client *http.Client
+}
+
+// NewWebhook creates a webhook target
+func NewWebhook(cfg config.WebhookConfig) *Webhook {
+	return &Webhook{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout.Duration},
+	}
+}
+
+// Name returns the configured target name
+func (w *Webhook) Name() string {
+	return w.config.Name
+}
+
+// Notify posts the events in the configured format
+func (w *Webhook) Notify(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(w.payload(events))
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	return nil
+}
+
+// payload builds the request body for the configured format
+func (w *Webhook) payload(events []Event) any {
+	switch w.config.Format {
+	case config.WebhookSlack:
+		return map[string]string{"text": messageText(events, "\n")}
+	case config.WebhookDiscord:
+		content := messageText(events, "\n")
+		if len(content) > discordMaxContent {
+			// Cut on a character boundary, so the message stays valid UTF-8
+			cut := discordMaxContent - len("...")
+			for cut > 0 && !utf8.RuneStart(content[cut]) {
+				cut--
+			}
+
+			content = content[:cut] + "..."
+		}
+
+		return map[string]string{"content": content}
+	case config.WebhookTeams:
+		return map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"themeColor": themeColor(events),
+			"summary":    eventLine(events[0]),
+			"title":      "Brother printer notifications",
+			// Teams markdown needs a blank line between paragraphs
+			"text": messageText(events, "\n\n"),
+		}
+	default:
+		return map[string]any{"events": events}
+	}
+}
+
+// messageText is a line per event for the chat formats
+func messageText(events []Event, separator string) string {
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		lines = append(lines, eventLine(event))
+	}
+
+	return strings.Join(lines, separator)
+}
+
+// eventLine describes an event, e.g.
+// "[FIRING] BRN001 (HL-L3270CDW) at Office: Toner black is low (15%)"
+func eventLine(event Event) string {
+	where := event.Printer.Title()
+	if event.Printer.Location != "" {
+		where += " at " + event.Printer.Location
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(event.Type), where, event.Summary)
+}
+
+// themeColor colours a Teams card by its most severe firing event
+func themeColor(events []Event) string {
+	color := "2E7D32"
+
+	for _, event := range events {
+		if event.Type == EventResolved {
+			continue
+		}
+
+		if event.Severity == printer.SeverityCritical {
+			return "C62828"
+		}
+
+		color = "EF6C00"
+	}
+
+	return color
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvents = []Event{{
+	Type:     EventFiring,
+	Kind:     KindSupply,
+	Key:      "supply/toner/black",
+	Severity: printer.SeverityWarning,
+	Summary:  "Toner black is low (15%)",
+	Time:     time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC),
+	Printer:  Printer{ID: "192.168.1.100", Host: "192.168.1.100", Model: "HL-L3270CDW", Location: "Office"},
+}}
+
+// capture serves a webhook endpoint and returns the last request body
+func capture(t *testing.T, status int) (*httptest.Server, func() map[string]any) {
+	t.Helper()
+
+	var body map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		data, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(data, &body))
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]any { return body }
+}
+
+func TestWebhookFormats(t *testing.T) {
+	line := "[FIRING] 192.168.1.100 (HL-L3270CDW) at Office: Toner black is low (15%)"
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, body map[string]any)
+	}{
+		{config.WebhookGeneric, func(t *testing.T, body map[string]any) {
+			events, _ := body["events"].([]any)
+			require.Len(t, events, 1)
+
+			event, _ := events[0].(map[string]any)
+			assert.Equal(t, "firing", event["type"])
+			assert.Equal(t, "supply/toner/black", event["key"])
+			assert.Equal(t, "2026-03-02T12:00:00Z", event["time"])
+		}},
+		{config.WebhookSlack, func(t *testing.T, body map[string]any) {
+			assert.Equal(t, map[string]any{"text": line}, body)
+		}},
+		{config.WebhookDiscord, func(t *testing.T, body map[string]any) {
+			assert.Equal(t, map[string]any{"content": line}, body)
+		}},
+		{config.WebhookTeams, func(t *testing.T, body map[string]any) {
+			assert.Equal(t, "MessageCard", body["@type"])
+			assert.Equal(t, "EF6C00", body["themeColor"])
+			assert.Equal(t, line, body["text"])
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			server, body := capture(t, http.StatusOK)
+
+			webhook := NewWebhook(config.WebhookConfig{
+				Name:    tt.format,
+				URL:     server.URL,
+				Format:  tt.format,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Timeout: config.Duration{Duration: 5 * time.Second},
+			})
+
+			require.NoError(t, webhook.Notify(t.Context(), testEvents))
+			tt.check(t, body())
+		})
+	}
+}
+
+func TestDiscordTruncatesOnCharacters(t *testing.T) {
+	webhook := NewWebhook(config.WebhookConfig{Format: config.WebhookDiscord})
+
+	// One of the two lands the cut in the middle of an "é"
+	for _, summary := range []string{strings.Repeat("é", 1000), "x" + strings.Repeat("é", 1000)} {
+		event := testEvents[0]
+		event.Summary = summary
+		event.Printer.Location = "Büro"
+
+		payload, _ := webhook.payload([]Event{event}).(map[string]string)
+		content := payload["content"]
+
+		assert.LessOrEqual(t, len(content), discordMaxContent)
+		assert.Greater(t, len(content), discordMaxContent-4)
+		assert.True(t, utf8.ValidString(content))
+		assert.True(t, strings.HasSuffix(content, "é..."))
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	server, _ := capture(t, http.StatusBadRequest)
+
+	webhook := NewWebhook(config.WebhookConfig{
+		URL:     server.URL,
+		Format:  config.WebhookSlack,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+
+	assert.ErrorContains(t, webhook.Notify(t.Context(), testEvents), "400 Bad Request")
+}
+
+func TestManagerDelivers(t *testing.T) {
+	server, body := capture(t, http.StatusOK)
+
+	m, err := NewManager(config.NotificationsConfig{Webhooks: []config.WebhookConfig{{
+		URL:     server.URL,
+		Format:  config.WebhookSlack,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}}})
+	require.NoError(t, err)
+
+	m.Start(t.Context())
+	m.Observe(testState(false, "ok", 60))
+	m.Stop()
+
+	// Observing after Stop is a no-op
+	m.Observe(testState(true, "ok", 60))
+
+	assert.Contains(t, body()["text"], "Printer is unreachable")
+}
@@ -0,0 +1,135 @@
+// Package otlp pushes the Brother metrics to an OpenTelemetry collector
+// over OTLP, gRPC or HTTP, next to the Prometheus endpoint.
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	"github.com/d0ugal/brother-exporter/internal/version"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// serviceName is the service.name of the exporter's resource
+const serviceName = "brother-exporter"
+
+// Exporter exports every Brother metric each interval. It implements the
+// promexporter collector interface.
+type Exporter struct {
+	cfg      config.OTLPConfig
+	exporter sdkmetric.Exporter
+	producer *producer
+	provider *sdkmetric.MeterProvider
+}
+
+// NewExporter creates an exporter of registry's metrics. It doesn't connect
+// until the first export.
+func NewExporter(cfg config.OTLPConfig, registry *metrics.BrotherRegistry) (*Exporter, error) {
+	exporter, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP %s exporter: %w", cfg.Protocol, err)
+	}
+
+	return &Exporter{
+		cfg:      cfg,
+		exporter: loggingExporter{Exporter: exporter, endpoint: cfg.Endpoint},
+		producer: newProducer(registry.Gatherer()),
+	}, nil
+}
+
+func newClient(cfg config.OTLPConfig) (sdkmetric.Exporter, error) {
+	ctx := context.Background()
+
+	if cfg.Protocol == config.OTLPProtocolHTTP {
+		options := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.Endpoint),
+			otlpmetrichttp.WithURLPath(cfg.URLPath),
+			otlpmetrichttp.WithTimeout(cfg.Timeout.Duration),
+		}
+
+		if cfg.Insecure {
+			options = append(options, otlpmetrichttp.WithInsecure())
+		}
+
+		if len(cfg.Headers) > 0 {
+			options = append(options, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+
+		return otlpmetrichttp.New(ctx, options...)
+	}
+
+	options := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+		otlpmetricgrpc.WithTimeout(cfg.Timeout.Duration),
+	}
+
+	if cfg.Insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlpmetricgrpc.WithHeaders(cfg.Headers))
+	}
+
+	return otlpmetricgrpc.New(ctx, options...)
+}
+
+// Start exports the metrics every interval in the background
+func (e *Exporter) Start(_ context.Context) {
+	slog.Info("Starting OTLP metrics exporter", "protocol", e.cfg.Protocol, "endpoint", e.cfg.Endpoint, "interval", e.cfg.Interval.Duration)
+
+	reader := sdkmetric.NewPeriodicReader(e.exporter,
+		sdkmetric.WithInterval(e.cfg.Interval.Duration),
+		sdkmetric.WithTimeout(e.cfg.Timeout.Duration),
+		sdkmetric.WithProducer(e.producer),
+	)
+
+	e.provider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(version.Version),
+		)),
+	)
+}
+
+// Stop exports the metrics a last time and closes the connection
+func (e *Exporter) Stop() {
+	if e.provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.Timeout.Duration)
+	defer cancel()
+
+	if err := e.provider.Shutdown(ctx); err != nil {
+		slog.Error("OTLP exporter shutdown error", "error", err)
+	}
+}
+
+// loggingExporter logs failed exports, which the reader would otherwise
+// hand to the global OpenTelemetry error handler
+type loggingExporter struct {
+	sdkmetric.Exporter
+
+	endpoint string
+}
+
+func (e loggingExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if err := e.Exporter.Export(ctx, rm); err != nil {
+		slog.Warn("Failed to export OTLP metrics", "endpoint", e.endpoint, "error", err)
+		return nil
+	}
+
+	slog.Debug("Exported OTLP metrics", "endpoint", e.endpoint)
+
+	return nil
+}
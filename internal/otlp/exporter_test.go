@@ -0,0 +1,162 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// receiver is an in-process OTLP gRPC metrics service
+type receiver struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+
+	requests chan *collectormetrics.ExportMetricsServiceRequest
+	headers  chan metadata.MD
+}
+
+func (r *receiver) Export(ctx context.Context, req *collectormetrics.ExportMetricsServiceRequest) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.headers <- md
+	r.requests <- req
+
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+func exportConfig(protocol, endpoint string) config.OTLPConfig {
+	return config.OTLPConfig{
+		Enabled:  true,
+		Protocol: protocol,
+		Endpoint: endpoint,
+		URLPath:  "/v1/metrics",
+		Insecure: true,
+		Headers:  map[string]string{"authorization": "Bearer secret"},
+		// Stop exports a last time, so the test doesn't wait for an interval
+		Interval: config.Duration{Duration: time.Hour},
+		Timeout:  config.Duration{Duration: 5 * time.Second},
+	}
+}
+
+// export starts and stops an exporter of the test registry
+func export(t *testing.T, cfg config.OTLPConfig) {
+	t.Helper()
+
+	exporter, err := NewExporter(cfg, newRegistry())
+	require.NoError(t, err)
+
+	exporter.Start(t.Context())
+	exporter.Stop()
+}
+
+func attributeValue(attrs []*commonpb.KeyValue, key string) string {
+	for _, kv := range attrs {
+		if kv.GetKey() == key {
+			return kv.GetValue().GetStringValue()
+		}
+	}
+
+	return ""
+}
+
+func checkRequest(t *testing.T, req *collectormetrics.ExportMetricsServiceRequest) {
+	t.Helper()
+
+	require.Len(t, req.GetResourceMetrics(), 1)
+
+	rm := req.GetResourceMetrics()[0]
+	assert.Equal(t, serviceName, attributeValue(rm.GetResource().GetAttributes(), "service.name"))
+
+	var toner *metricspb.Metric
+
+	for _, scope := range rm.GetScopeMetrics() {
+		for _, m := range scope.GetMetrics() {
+			if m.GetName() == "brother_printer_toner_level_percent" {
+				toner = m
+			}
+		}
+	}
+
+	require.NotNil(t, toner)
+
+	point := toner.GetGauge().GetDataPoints()[0]
+	assert.Equal(t, 60.0, point.GetAsDouble())
+	assert.Equal(t, "192.168.1.100", attributeValue(point.GetAttributes(), "host.name"))
+	assert.Equal(t, "E78096A9N123456", attributeValue(point.GetAttributes(), "device.serial"))
+}
+
+func TestExportGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	r := &receiver{
+		requests: make(chan *collectormetrics.ExportMetricsServiceRequest, 1),
+		headers:  make(chan metadata.MD, 1),
+	}
+
+	server := grpc.NewServer()
+	collectormetrics.RegisterMetricsServiceServer(server, r)
+
+	go func() { _ = server.Serve(listener) }()
+
+	defer server.Stop()
+
+	export(t, exportConfig(config.OTLPProtocolGRPC, listener.Addr().String()))
+
+	select {
+	case req := <-r.requests:
+		checkRequest(t, req)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no export received")
+	}
+
+	assert.Equal(t, []string{"Bearer secret"}, (<-r.headers).Get("authorization"))
+}
+
+func TestExportHTTP(t *testing.T) {
+	requests := make(chan *collectormetrics.ExportMetricsServiceRequest, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		req := &collectormetrics.ExportMetricsServiceRequest{}
+		if !assert.NoError(t, proto.Unmarshal(body, req)) {
+			return
+		}
+
+		requests <- req
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		response, _ := proto.Marshal(&collectormetrics.ExportMetricsServiceResponse{})
+		_, _ = w.Write(response)
+	}))
+	defer server.Close()
+
+	export(t, exportConfig(config.OTLPProtocolHTTP, strings.TrimPrefix(server.URL, "http://")))
+
+	select {
+	case req := <-requests:
+		checkRequest(t, req)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no export received")
+	}
+}
@@ -0,0 +1,256 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/version"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// scopeName is the instrumentation scope of the exported metrics
+const scopeName = "github.com/d0ugal/brother-exporter"
+
+// infoMetric holds each printer's identity, added to all of its series
+const infoMetric = "brother_printer_info"
+
+// manufacturer is the device.manufacturer of every printer
+const manufacturer = "Brother"
+
+// deviceSerialKey is the printer's serial number. Semantic conventions have
+// no attribute for it, it follows their device.* naming.
+const deviceSerialKey = attribute.Key("device.serial")
+
+// labelKeys renames Prometheus labels to their semantic convention
+// attributes. Other labels keep their name.
+var labelKeys = map[string]attribute.Key{
+	"host":   semconv.HostNameKey,
+	"model":  semconv.DeviceModelNameKey,
+	"serial": deviceSerialKey,
+}
+
+// units maps metric name suffixes to UCUM units
+var units = []struct {
+	suffix string
+	unit   string
+}{
+	{"_percent", "%"},
+	{"_seconds", "s"},
+	{"_timestamp", "s"},
+	{"_pages", "{page}"},
+}
+
+// producer converts the Prometheus metrics of a gatherer to OpenTelemetry
+// metrics. It implements the SDK's metric.Producer.
+type producer struct {
+	gatherer prometheus.Gatherer
+	// start is the start time of counters without a created timestamp
+	start time.Time
+	now   func() time.Time
+}
+
+func newProducer(gatherer prometheus.Gatherer) *producer {
+	return &producer{gatherer: gatherer, start: time.Now(), now: time.Now}
+}
+
+// Produce gathers the metrics and converts them
+func (p *producer) Produce(_ context.Context) ([]metricdata.ScopeMetrics, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	identities := identities(families)
+	now := p.now()
+
+	scope := metricdata.ScopeMetrics{
+		Scope: instrumentation.Scope{Name: scopeName, Version: version.Version},
+	}
+
+	for _, family := range families {
+		converted, ok := p.convert(family, identities, now)
+		if ok {
+			scope.Metrics = append(scope.Metrics, converted)
+		}
+	}
+
+	return []metricdata.ScopeMetrics{scope}, nil
+}
+
+// convert converts one metric family. Summaries, which the registry has
+// none of, are skipped.
+func (p *producer) convert(family *dto.MetricFamily, identities map[string][]attribute.KeyValue, now time.Time) (metricdata.Metrics, bool) {
+	converted := metricdata.Metrics{
+		Name:        family.GetName(),
+		Description: family.GetHelp(),
+		Unit:        unit(family.GetName()),
+	}
+
+	switch family.GetType() {
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		gauge := metricdata.Gauge[float64]{}
+
+		for _, metric := range family.GetMetric() {
+			value := metric.GetGauge().GetValue()
+			if family.GetType() == dto.MetricType_UNTYPED {
+				value = metric.GetUntyped().GetValue()
+			}
+
+			gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: attributes(metric.GetLabel(), identities),
+				Time:       now,
+				Value:      value,
+			})
+		}
+
+		converted.Data = gauge
+	case dto.MetricType_COUNTER:
+		sum := metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+
+		for _, metric := range family.GetMetric() {
+			sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: attributes(metric.GetLabel(), identities),
+				StartTime:  p.startTime(metric.GetCounter().GetCreatedTimestamp().AsTime()),
+				Time:       now,
+				Value:      metric.GetCounter().GetValue(),
+			})
+		}
+
+		converted.Data = sum
+	case dto.MetricType_HISTOGRAM:
+		histogram := metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+
+		for _, metric := range family.GetMetric() {
+			point := histogramPoint(metric.GetHistogram())
+			point.Attributes = attributes(metric.GetLabel(), identities)
+			point.StartTime = p.startTime(metric.GetHistogram().GetCreatedTimestamp().AsTime())
+			point.Time = now
+
+			histogram.DataPoints = append(histogram.DataPoints, point)
+		}
+
+		converted.Data = histogram
+	default:
+		return converted, false
+	}
+
+	return converted, true
+}
+
+// startTime is the created timestamp of a cumulative metric, or when the
+// exporter started if it has none
+func (p *producer) startTime(created time.Time) time.Time {
+	if created.Unix() <= 0 {
+		return p.start
+	}
+
+	return created
+}
+
+// histogramPoint converts cumulative Prometheus buckets to per-bucket
+// counts. The +Inf bucket is implicit in OpenTelemetry.
+func histogramPoint(histogram *dto.Histogram) metricdata.HistogramDataPoint[float64] {
+	point := metricdata.HistogramDataPoint[float64]{
+		Count: histogram.GetSampleCount(),
+		Sum:   histogram.GetSampleSum(),
+	}
+
+	var previous uint64
+
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+
+		point.Bounds = append(point.Bounds, bucket.GetUpperBound())
+		point.BucketCounts = append(point.BucketCounts, bucket.GetCumulativeCount()-previous)
+		previous = bucket.GetCumulativeCount()
+	}
+
+	point.BucketCounts = append(point.BucketCounts, histogram.GetSampleCount()-previous)
+
+	return point
+}
+
+// identities returns the device attributes of each printer, by host, from
+// the info metric
+func identities(families []*dto.MetricFamily) map[string][]attribute.KeyValue {
+	identities := make(map[string][]attribute.KeyValue)
+
+	for _, family := range families {
+		if family.GetName() != infoMetric {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			var host string
+
+			identity := []attribute.KeyValue{semconv.DeviceManufacturer(manufacturer)}
+
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "host":
+					host = label.GetValue()
+				case "model", "serial":
+					if label.GetValue() != "" {
+						identity = append(identity, labelKeys[label.GetName()].String(label.GetValue()))
+					}
+				}
+			}
+
+			identities[host] = identity
+		}
+	}
+
+	return identities
+}
+
+// attributes converts labels to attributes, adding the identity of the
+// printer in the host label
+func attributes(labels []*dto.LabelPair, identities map[string][]attribute.KeyValue) attribute.Set {
+	var kvs []attribute.KeyValue
+
+	for _, label := range labels {
+		if label.GetValue() == "" {
+			continue
+		}
+
+		key, ok := labelKeys[label.GetName()]
+		if !ok {
+			key = attribute.Key(label.GetName())
+		}
+
+		kvs = append(kvs, key.String(label.GetValue()))
+
+		if label.GetName() == "host" {
+			identity, ok := identities[label.GetValue()]
+			if !ok {
+				identity = []attribute.KeyValue{semconv.DeviceManufacturer(manufacturer)}
+			}
+
+			kvs = append(kvs, identity...)
+		}
+	}
+
+	return attribute.NewSet(kvs...)
+}
+
+func unit(name string) string {
+	name = strings.TrimSuffix(name, "_total")
+
+	for _, u := range units {
+		if strings.HasSuffix(name, u.suffix) {
+			return u.unit
+		}
+	}
+
+	return ""
+}
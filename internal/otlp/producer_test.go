@@ -0,0 +1,120 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/metrics"
+	promexporter_metrics "github.com/d0ugal/promexporter/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newRegistry() *metrics.BrotherRegistry {
+	registry := metrics.NewBrotherRegistry(promexporter_metrics.NewRegistry("brother_exporter_info_test"), "site")
+
+	labels := func(extra prometheus.Labels) prometheus.Labels {
+		extra["host"] = "192.168.1.100"
+		extra["site"] = "office"
+
+		return extra
+	}
+
+	registry.PrinterInfo.With(labels(prometheus.Labels{
+		"model": "HL-L3270CDW", "serial": "E78096A9N123456", "firmware": "1.20", "type": "laser", "mac": "",
+	})).Set(1)
+	registry.TonerLevel.With(labels(prometheus.Labels{"color": "black"})).Set(60)
+	registry.PrinterConnectionErrors.With(labels(prometheus.Labels{"error_type": "timeout"})).Add(3)
+	registry.CollectionDuration.With(labels(prometheus.Labels{"step": "supplies"})).Observe(0.2)
+
+	return registry
+}
+
+func find(t *testing.T, scopes []metricdata.ScopeMetrics, name string) metricdata.Metrics {
+	t.Helper()
+
+	for _, scope := range scopes {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+
+	require.Failf(t, "metric not found", name)
+
+	return metricdata.Metrics{}
+}
+
+func value(set attribute.Set, key string) string {
+	v, _ := set.Value(attribute.Key(key))
+	return v.AsString()
+}
+
+func TestProduce(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	p := newProducer(newRegistry().Gatherer())
+	p.now = func() time.Time { return now }
+
+	scopes, err := p.Produce(t.Context())
+	require.NoError(t, err)
+	require.Len(t, scopes, 1)
+	assert.Equal(t, scopeName, scopes[0].Scope.Name)
+
+	// Only the Brother metrics, not the Go runtime ones
+	for _, m := range scopes[0].Metrics {
+		assert.NotContains(t, m.Name, "go_")
+	}
+
+	toner := find(t, scopes, "brother_printer_toner_level_percent")
+	assert.Equal(t, "%", toner.Unit)
+
+	gauge, ok := toner.Data.(metricdata.Gauge[float64])
+	require.True(t, ok)
+	require.Len(t, gauge.DataPoints, 1)
+
+	point := gauge.DataPoints[0]
+	assert.Equal(t, 60.0, point.Value)
+	assert.Equal(t, now, point.Time)
+	assert.Equal(t, "192.168.1.100", value(point.Attributes, "host.name"))
+	assert.Equal(t, "HL-L3270CDW", value(point.Attributes, "device.model.name"))
+	assert.Equal(t, "E78096A9N123456", value(point.Attributes, "device.serial"))
+	assert.Equal(t, "Brother", value(point.Attributes, "device.manufacturer"))
+	assert.Equal(t, "black", value(point.Attributes, "color"))
+	assert.Equal(t, "office", value(point.Attributes, "site"))
+	assert.False(t, point.Attributes.HasValue("host"))
+
+	// Empty labels are left out
+	info := find(t, scopes, "brother_printer_info").Data.(metricdata.Gauge[float64]).DataPoints[0]
+	assert.False(t, info.Attributes.HasValue("mac"))
+	assert.Equal(t, "1.20", value(info.Attributes, "firmware"))
+
+	errors, ok := find(t, scopes, "brother_printer_connection_errors_total").Data.(metricdata.Sum[float64])
+	require.True(t, ok)
+	assert.True(t, errors.IsMonotonic)
+	assert.Equal(t, metricdata.CumulativeTemporality, errors.Temporality)
+	assert.Equal(t, 3.0, errors.DataPoints[0].Value)
+	assert.False(t, errors.DataPoints[0].StartTime.IsZero())
+
+	duration := find(t, scopes, "brother_exporter_collection_duration_seconds")
+	assert.Equal(t, "s", duration.Unit)
+
+	histogram, ok := duration.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+
+	hp := histogram.DataPoints[0]
+	assert.Equal(t, uint64(1), hp.Count)
+	assert.InDelta(t, 0.2, hp.Sum, 1e-9)
+	assert.Len(t, hp.BucketCounts, len(hp.Bounds)+1)
+
+	var total uint64
+	for _, count := range hp.BucketCounts {
+		total += count
+	}
+
+	assert.Equal(t, uint64(1), total)
+}
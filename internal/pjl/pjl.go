@@ -0,0 +1,262 @@
+// Package pjl queries printer status over the raw printing port (9100)
+// using Printer Job Language INFO commands.
+package pjl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// uel is the Universal Exit Language sequence that enters and leaves PJL
+const uel = "\x1b%-12345X"
+
+// Commands are the INFO categories queried each time
+var Commands = []string{"ID", "STATUS", "PAGECOUNT", "USTATUS"}
+
+// ErrBusy is returned when the port refuses the connection or holds it open
+// without answering. The raw port serves one connection at a time, so a
+// printer does this while printing, but so does one with the port closed
+// or PJL disabled: it is no proof the printer is printing.
+var ErrBusy = errors.New("printer port is busy or closed")
+
+// Info is the parsed answer to the INFO queries
+type Info struct {
+	// ID is the model string, e.g. "Brother HL-5250DN series"
+	ID string
+
+	// StatusCode is the PJL status code, e.g. 10001 for ready
+	StatusCode int
+	// Display is the front panel message
+	Display string
+	// Online is false when the printer has been taken offline
+	Online bool
+
+	// PageCount is the total page count, -1 when not reported
+	PageCount int
+
+	// UStatus holds the unsolicited status settings, e.g. DEVICE=OFF
+	UStatus map[string]string
+}
+
+// Client queries a single printer
+type Client struct {
+	// Address is the printer's host:port
+	Address string
+
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+}
+
+// NewClient creates a client for address. A busy port is retried up to
+// retries times, retryDelay apart, before ErrBusy is returned.
+func NewClient(address string, timeout time.Duration, retries int, retryDelay time.Duration) *Client {
+	return &Client{
+		Address:    address,
+		timeout:    timeout,
+		retries:    retries,
+		retryDelay: retryDelay,
+	}
+}
+
+// Query sends the INFO commands and parses the answers
+func (c *Client) Query(ctx context.Context) (*Info, error) {
+	var err error
+
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay):
+			}
+		}
+
+		var info *Info
+
+		info, err = c.query(ctx)
+		if !errors.Is(err, ErrBusy) {
+			return info, err
+		}
+	}
+
+	return nil, fmt.Errorf("no answer after %d attempts: %w", c.retries+1, err)
+}
+
+func (c *Client) query(ctx context.Context) (*Info, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", c.Address)
+	if err != nil {
+		// A refused connection may be a print job holding the port; a
+		// timeout means the host isn't answering at all
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w: %w", ErrBusy, err)
+		}
+
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.Address, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := io.WriteString(conn, Request()); err != nil {
+		return nil, fmt.Errorf("failed to send PJL request: %w", err)
+	}
+
+	responses, err := readResponses(bufio.NewReader(conn), len(Commands))
+	if err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) && len(responses) == 0 {
+			return nil, fmt.Errorf("%w: no answer before timeout", ErrBusy)
+		}
+
+		if len(responses) == 0 {
+			return nil, fmt.Errorf("failed to read PJL response: %w", err)
+		}
+	}
+
+	return Parse(responses), nil
+}
+
+// Request returns the PJL job sent to the printer
+func Request() string {
+	var builder strings.Builder
+
+	builder.WriteString(uel + "@PJL\r\n")
+
+	for _, command := range Commands {
+		builder.WriteString("@PJL INFO " + command + "\r\n")
+	}
+
+	builder.WriteString(uel)
+
+	return builder.String()
+}
+
+// readResponses reads up to n form-feed terminated answers
+func readResponses(r *bufio.Reader, n int) ([]string, error) {
+	var responses []string
+
+	for len(responses) < n {
+		block, err := r.ReadString('\f')
+		if err != nil {
+			return responses, err
+		}
+
+		responses = append(responses, strings.TrimSuffix(block, "\f"))
+	}
+
+	return responses, nil
+}
+
+// Parse maps INFO answers to Info. Each answer starts with the echoed
+// "@PJL INFO <category>" line.
+func Parse(responses []string) *Info {
+	info := &Info{PageCount: -1, Online: true, UStatus: make(map[string]string)}
+
+	for _, response := range responses {
+		lines := splitLines(response)
+
+		// Skip anything ahead of the echoed command, e.g. a stray UEL
+		for len(lines) > 0 && !strings.HasPrefix(lines[0], "@PJL INFO") {
+			lines = lines[1:]
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		category := strings.TrimSpace(strings.TrimPrefix(lines[0], "@PJL INFO"))
+		body := lines[1:]
+
+		switch category {
+		case "ID":
+			if len(body) > 0 {
+				info.ID = strings.Trim(body[0], `"`)
+			}
+		case "STATUS":
+			for key, value := range keyValues(body) {
+				switch key {
+				case "CODE":
+					info.StatusCode, _ = strconv.Atoi(value)
+				case "DISPLAY":
+					info.Display = strings.Trim(value, `"`)
+				case "ONLINE":
+					info.Online = strings.EqualFold(value, "TRUE")
+				}
+			}
+		case "PAGECOUNT":
+			if len(body) > 0 {
+				// Either "PAGECOUNT=123" or a bare "123"
+				_, value, found := strings.Cut(body[0], "=")
+				if !found {
+					value = body[0]
+				}
+
+				if count, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+					info.PageCount = count
+				}
+			}
+		case "USTATUS":
+			info.UStatus = keyValues(body)
+		}
+	}
+
+	return info
+}
+
+// keyValues parses KEY=VALUE lines, ignoring the indented option lists
+// that follow enumerated values
+func keyValues(lines []string) map[string]string {
+	values := make(map[string]string)
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		// Drop type annotations such as "OFF [3 ENUMERATED]"
+		if i := strings.Index(value, " ["); i >= 0 {
+			value = value[:i]
+		}
+
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return values
+}
+
+func splitLines(s string) []string {
+	var lines []string
+
+	for line := range bytes.Lines([]byte(s)) {
+		trimmed := strings.TrimRight(string(line), "\r\n")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+
+		lines = append(lines, trimmed)
+	}
+
+	return lines
+}
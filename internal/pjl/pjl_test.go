@@ -0,0 +1,131 @@
+package pjl
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answers modelled on an HL-5250DN, one per INFO command
+const answers = "@PJL INFO ID\r\n\"Brother HL-5250DN series\"\r\n\f" +
+	"@PJL INFO STATUS\r\nCODE=10001\r\nDISPLAY=\"Ready\"\r\nONLINE=TRUE\r\n\f" +
+	"@PJL INFO PAGECOUNT\r\nPAGECOUNT=12345\r\n\f" +
+	"@PJL INFO USTATUS\r\nDEVICE=OFF [3 ENUMERATED]\r\n\tOFF\r\n\tON\r\n\tVERBOSE\r\nJOB=OFF [2 ENUMERATED]\r\n\tOFF\r\n\tON\r\n\f"
+
+// newStandIn listens on a local port and handles each connection with
+// handle until the test ends
+func newStandIn(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// answerPJL reads the request up to the closing UEL and writes the answers
+func answerPJL(t *testing.T, reply string) func(net.Conn) {
+	return func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+
+		var request strings.Builder
+
+		for !strings.HasSuffix(request.String(), "\r\n"+uel) {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return
+			}
+
+			request.WriteByte(b)
+		}
+
+		assert.Equal(t, Request(), request.String())
+
+		_, _ = conn.Write([]byte(reply))
+	}
+}
+
+func TestQuery(t *testing.T) {
+	address := newStandIn(t, answerPJL(t, answers))
+
+	info, err := NewClient(address, 2*time.Second, 0, 0).Query(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Brother HL-5250DN series", info.ID)
+	assert.Equal(t, 10001, info.StatusCode)
+	assert.Equal(t, "Ready", info.Display)
+	assert.True(t, info.Online)
+	assert.Equal(t, 12345, info.PageCount)
+	assert.Equal(t, map[string]string{"DEVICE": "OFF", "JOB": "OFF"}, info.UStatus)
+}
+
+func TestQuery_BusySilent(t *testing.T) {
+	// While printing the port accepts the connection but doesn't answer
+	address := newStandIn(t, func(conn net.Conn) {
+		_, _ = conn.Read(make([]byte, 1024))
+		time.Sleep(time.Second)
+	})
+
+	_, err := NewClient(address, 100*time.Millisecond, 1, 10*time.Millisecond).Query(t.Context())
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestQuery_BusyRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	_, err = NewClient(address, time.Second, 0, 0).Query(t.Context())
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestQuery_BusyThenReady(t *testing.T) {
+	var attempts atomic.Int32
+
+	address := newStandIn(t, func(conn net.Conn) {
+		if attempts.Add(1) == 1 {
+			_, _ = conn.Read(make([]byte, 1024))
+			time.Sleep(300 * time.Millisecond)
+
+			return
+		}
+
+		answerPJL(t, answers)(conn)
+	})
+
+	info, err := NewClient(address, 100*time.Millisecond, 2, 10*time.Millisecond).Query(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 12345, info.PageCount)
+}
+
+func TestParse_BarePageCount(t *testing.T) {
+	info := Parse([]string{"@PJL INFO PAGECOUNT\r\n678\r\n"})
+
+	assert.Equal(t, 678, info.PageCount)
+	assert.Empty(t, info.ID)
+}
@@ -0,0 +1,423 @@
+// Package printer holds the printer data model every data source produces,
+// independent of how the data is exported.
+package printer
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sections of a snapshot. A source is asked for, and merged by, sections.
+const (
+	SectionIdentity    = "identity"
+	SectionStatus      = "status"
+	SectionUptime      = "uptime"
+	SectionSupplies    = "supplies"
+	SectionMaintenance = "maintenance"
+	SectionTrays       = "trays"
+	SectionCounters    = "counters"
+)
+
+// Sections lists every snapshot section
+var Sections = []string{
+	SectionIdentity,
+	SectionStatus,
+	SectionUptime,
+	SectionSupplies,
+	SectionMaintenance,
+	SectionTrays,
+	SectionCounters,
+}
+
+// Source reads a snapshot from a printer. Fetch is given the sections the
+// caller still needs and may skip the rest; it returns an error when the
+// printer could not be read at all.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, sections []string) (*Snapshot, error)
+}
+
+// Counter names
+const (
+	CounterTotal       = "total"
+	CounterBlack       = "black"
+	CounterColor       = "color"
+	CounterDuplex      = "duplex"
+	CounterDrumBlack   = "drum_black"
+	CounterDrumCyan    = "drum_cyan"
+	CounterDrumMagenta = "drum_magenta"
+	CounterDrumYellow  = "drum_yellow"
+)
+
+// Snapshot is the data read from a printer in one collection cycle. Nil or
+// empty fields were not collected.
+type Snapshot struct {
+	// CollectedAt is when the cycle that read the snapshot started
+	CollectedAt time.Time
+	// Updated holds when each section was last refreshed, set by the Store
+	Updated map[string]time.Time
+
+	Identity *Identity
+	Status   *Status
+	// Alerts are the conditions the printer reports, part of the status
+	// section
+	Alerts []Alert
+
+	// BootTime is when the printer last restarted
+	BootTime time.Time
+
+	// Supplies are consumable and maintenance part levels in percent
+	Supplies []Supply
+	// NearEnd holds the printer's own near-end flag per supply kind
+	NearEnd map[string]bool
+	// Maintenance holds the remaining pages of maintenance parts
+	Maintenance []Part
+
+	Trays    []Tray
+	Counters map[string]float64
+}
+
+// Identity describes the printer
+type Identity struct {
+	Model       string
+	Serial      string
+	Firmware    string
+	MAC         string
+	Name        string
+	Location    string
+	Contact     string
+	Description string
+}
+
+// Status is the printer state: ready, printing, warmup, stopped or unknown
+type Status struct {
+	State   string
+	Message string
+}
+
+// Alert severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a condition reported by the printer. Code is a normalised name
+// such as no_paper, jammed or low_toner; Message is the printer's own text
+// where it has one.
+type Alert struct {
+	Code     string
+	Severity string
+	Message  string
+}
+
+// Supply is the remaining level of a consumable (toner, drum, ink) or a
+// maintenance part (belt_unit, fuser_unit, laser_unit, paper_feeding_kit).
+// Color is empty for parts that aren't per colour. State is the level
+// against the configured thresholds (ok, low, critical or empty), set by
+// the exporter rather than the source.
+type Supply struct {
+	Kind    string
+	Color   string
+	Percent float64
+	State   string
+}
+
+// SupplyName names a supply, e.g. "Toner black" or "Fuser unit"
+func SupplyName(supply Supply) string {
+	name := strings.ReplaceAll(supply.Kind, "_", " ")
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+
+	if supply.Color != "" {
+		name += " " + supply.Color
+	}
+
+	return name
+}
+
+// Part is the remaining page count of a maintenance part
+type Part struct {
+	Kind           string
+	RemainingPages float64
+}
+
+// Tray is a paper tray status: ok, low, empty or unknown
+type Tray struct {
+	Name   string
+	Status string
+}
+
+// Has reports whether the snapshot holds data for section
+func (s *Snapshot) Has(section string) bool {
+	switch section {
+	case SectionIdentity:
+		return s.Identity != nil
+	case SectionStatus:
+		return s.Status != nil
+	case SectionUptime:
+		return !s.BootTime.IsZero()
+	case SectionSupplies:
+		return len(s.Supplies) > 0 || len(s.NearEnd) > 0
+	case SectionMaintenance:
+		return len(s.Maintenance) > 0
+	case SectionTrays:
+		return len(s.Trays) > 0
+	case SectionCounters:
+		return len(s.Counters) > 0
+	default:
+		return false
+	}
+}
+
+// Missing returns the sections of want the snapshot has no data for
+func (s *Snapshot) Missing(want []string) []string {
+	var missing []string
+
+	for _, section := range want {
+		if !s.Has(section) {
+			missing = append(missing, section)
+		}
+	}
+
+	return missing
+}
+
+// Incomplete returns the sections of want the snapshot has no data for, or
+// only part of the data another source may add: an identity without a
+// model, serial, firmware or MAC, consumables of only some of the CMYK
+// colours, toner without a drum, and counters without the total
+func (s *Snapshot) Incomplete(want []string) []string {
+	var incomplete []string
+
+	for _, section := range want {
+		if s.Gap(section) != "" {
+			incomplete = append(incomplete, section)
+		}
+	}
+
+	return incomplete
+}
+
+// Gap describes what a section lacks, "all" when it has no data and empty
+// when it is complete. Equal gaps mean another source is asked for the same
+// values, so the collector can skip a source that couldn't fill them.
+func (s *Snapshot) Gap(section string) string {
+	if !s.Has(section) {
+		return "all"
+	}
+
+	var gap []string
+
+	switch section {
+	case SectionIdentity:
+		i := s.Identity
+		for _, field := range []struct{ name, value string }{
+			{"model", i.Model}, {"serial", i.Serial}, {"firmware", i.Firmware}, {"mac", i.MAC},
+		} {
+			if field.value == "" {
+				gap = append(gap, field.name)
+			}
+		}
+	case SectionSupplies:
+		if len(s.Supplies) == 0 {
+			return "levels"
+		}
+
+		colors := make(map[string][]string)
+		for _, supply := range s.Supplies {
+			colors[supply.Kind] = append(colors[supply.Kind], supply.Color)
+		}
+
+		for _, kind := range []string{"toner", "ink"} {
+			have := colors[kind]
+			if len(have) > 1 || (len(have) == 1 && have[0] != "black") {
+				for _, color := range []string{"black", "cyan", "magenta", "yellow"} {
+					if !slices.Contains(have, color) {
+						gap = append(gap, kind+" "+color)
+					}
+				}
+			}
+		}
+
+		if _, hasDrum := colors["drum"]; len(colors["toner"]) > 0 && !hasDrum {
+			gap = append(gap, "drum")
+		}
+	case SectionCounters:
+		if _, ok := s.Counters[CounterTotal]; !ok {
+			gap = append(gap, CounterTotal)
+		}
+	}
+
+	return strings.Join(gap, ",")
+}
+
+// Merge fills gaps in s from other, for the given sections only. Values
+// already in s win; within a section other only adds what s lacks, e.g. a
+// supply colour or counter s doesn't have.
+func (s *Snapshot) Merge(other *Snapshot, sections []string) {
+	if other == nil {
+		return
+	}
+
+	if slices.Contains(sections, SectionIdentity) && other.Identity != nil {
+		if s.Identity == nil {
+			identity := *other.Identity
+			s.Identity = &identity
+		} else {
+			s.Identity.fill(other.Identity)
+		}
+	}
+
+	if slices.Contains(sections, SectionStatus) && s.Status == nil && other.Status != nil {
+		status := *other.Status
+		s.Status = &status
+		s.Alerts = slices.Clone(other.Alerts)
+	}
+
+	if slices.Contains(sections, SectionUptime) && s.BootTime.IsZero() {
+		s.BootTime = other.BootTime
+	}
+
+	if slices.Contains(sections, SectionSupplies) {
+		for _, supply := range other.Supplies {
+			if !slices.ContainsFunc(s.Supplies, func(have Supply) bool {
+				return have.Kind == supply.Kind && have.Color == supply.Color
+			}) {
+				s.Supplies = append(s.Supplies, supply)
+			}
+		}
+
+		for kind, nearEnd := range other.NearEnd {
+			if _, ok := s.NearEnd[kind]; !ok {
+				if s.NearEnd == nil {
+					s.NearEnd = make(map[string]bool)
+				}
+
+				s.NearEnd[kind] = nearEnd
+			}
+		}
+	}
+
+	if slices.Contains(sections, SectionMaintenance) {
+		for _, part := range other.Maintenance {
+			if !slices.ContainsFunc(s.Maintenance, func(have Part) bool { return have.Kind == part.Kind }) {
+				s.Maintenance = append(s.Maintenance, part)
+			}
+		}
+	}
+
+	if slices.Contains(sections, SectionTrays) {
+		for _, tray := range other.Trays {
+			if !slices.ContainsFunc(s.Trays, func(have Tray) bool { return have.Name == tray.Name }) {
+				s.Trays = append(s.Trays, tray)
+			}
+		}
+	}
+
+	if slices.Contains(sections, SectionCounters) {
+		for name, value := range other.Counters {
+			if _, ok := s.Counters[name]; !ok {
+				s.SetCounter(name, value)
+			}
+		}
+	}
+}
+
+// Apply replaces the sections of s that other holds, newer data winning,
+// and records when each was refreshed
+func (s *Snapshot) Apply(other *Snapshot) {
+	if s.Updated == nil {
+		s.Updated = make(map[string]time.Time)
+	}
+
+	s.CollectedAt = other.CollectedAt
+
+	for _, section := range Sections {
+		if !other.Has(section) {
+			continue
+		}
+
+		s.Updated[section] = other.CollectedAt
+
+		switch section {
+		case SectionIdentity:
+			identity := *other.Identity
+			s.Identity = &identity
+		case SectionStatus:
+			status := *other.Status
+			s.Status = &status
+			s.Alerts = slices.Clone(other.Alerts)
+		case SectionUptime:
+			s.BootTime = other.BootTime
+		case SectionSupplies:
+			s.Supplies = slices.Clone(other.Supplies)
+			s.NearEnd = maps.Clone(other.NearEnd)
+		case SectionMaintenance:
+			s.Maintenance = slices.Clone(other.Maintenance)
+		case SectionTrays:
+			s.Trays = slices.Clone(other.Trays)
+		case SectionCounters:
+			s.Counters = maps.Clone(other.Counters)
+		}
+	}
+}
+
+// Clone returns a deep copy of s
+func (s *Snapshot) Clone() *Snapshot {
+	clone := &Snapshot{
+		CollectedAt: s.CollectedAt,
+		Updated:     maps.Clone(s.Updated),
+		Alerts:      slices.Clone(s.Alerts),
+		BootTime:    s.BootTime,
+		Supplies:    slices.Clone(s.Supplies),
+		NearEnd:     maps.Clone(s.NearEnd),
+		Maintenance: slices.Clone(s.Maintenance),
+		Trays:       slices.Clone(s.Trays),
+		Counters:    maps.Clone(s.Counters),
+	}
+
+	if s.Identity != nil {
+		identity := *s.Identity
+		clone.Identity = &identity
+	}
+
+	if s.Status != nil {
+		status := *s.Status
+		clone.Status = &status
+	}
+
+	return clone
+}
+
+// SetCounter sets a page counter
+func (s *Snapshot) SetCounter(name string, value float64) {
+	if s.Counters == nil {
+		s.Counters = make(map[string]float64)
+	}
+
+	s.Counters[name] = value
+}
+
+// fill copies the fields of other that i has empty
+func (i *Identity) fill(other *Identity) {
+	fields := []struct{ dst, src *string }{
+		{&i.Model, &other.Model},
+		{&i.Serial, &other.Serial},
+		{&i.Firmware, &other.Firmware},
+		{&i.MAC, &other.MAC},
+		{&i.Name, &other.Name},
+		{&i.Location, &other.Location},
+		{&i.Contact, &other.Contact},
+		{&i.Description, &other.Description},
+	}
+
+	for _, field := range fields {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+}
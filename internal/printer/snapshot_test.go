@@ -0,0 +1,97 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	snap := &Snapshot{
+		Identity: &Identity{Model: "HL-L3270CDW", Serial: "E78096A9N123456"},
+		Supplies: []Supply{{Kind: "toner", Color: "black", Percent: 60}},
+		Counters: map[string]float64{CounterTotal: 5432},
+	}
+
+	snap.Merge(&Snapshot{
+		Identity: &Identity{Model: "HL-L3270CDW series", MAC: "00:80:92:7A:FB:CE"},
+		Status:   &Status{State: "ready"},
+		BootTime: time.Unix(1700000000, 0),
+		Supplies: []Supply{
+			{Kind: "toner", Color: "black", Percent: 50},
+			{Kind: "toner", Color: "cyan", Percent: 80},
+		},
+		NearEnd:  map[string]bool{"toner": true},
+		Counters: map[string]float64{CounterTotal: 5400, CounterColor: 1432},
+	}, []string{SectionIdentity, SectionSupplies, SectionCounters})
+
+	// Existing values win, gaps are filled
+	assert.Equal(t, &Identity{Model: "HL-L3270CDW", Serial: "E78096A9N123456", MAC: "00:80:92:7A:FB:CE"}, snap.Identity)
+	assert.Equal(t, []Supply{
+		{Kind: "toner", Color: "black", Percent: 60},
+		{Kind: "toner", Color: "cyan", Percent: 80},
+	}, snap.Supplies)
+	assert.Equal(t, map[string]bool{"toner": true}, snap.NearEnd)
+	assert.Equal(t, map[string]float64{CounterTotal: 5432, CounterColor: 1432}, snap.Counters)
+
+	// Sections that weren't merged are left alone
+	assert.Nil(t, snap.Status)
+	assert.True(t, snap.BootTime.IsZero())
+}
+
+func TestMissing(t *testing.T) {
+	snap := &Snapshot{
+		Status: &Status{State: "ready"},
+		Trays:  []Tray{{Name: "main", Status: "ok"}},
+	}
+
+	assert.Equal(t, []string{SectionIdentity, SectionUptime, SectionSupplies, SectionMaintenance, SectionCounters}, snap.Missing(Sections))
+	assert.Empty(t, snap.Missing([]string{SectionStatus, SectionTrays}))
+}
+
+func TestIncomplete(t *testing.T) {
+	snap := &Snapshot{
+		Identity: &Identity{Model: "HL-L3270CDW", Serial: "E78096A9N123456", Firmware: "ZC"},
+		Status:   &Status{State: "ready"},
+		Supplies: []Supply{
+			{Kind: "toner", Color: "black", Percent: 60},
+			{Kind: "toner", Color: "cyan", Percent: 80},
+			{Kind: "drum", Color: "black", Percent: 9},
+		},
+		Counters: map[string]float64{CounterColor: 1432},
+	}
+
+	// No MAC, two of the four toners and no total page count
+	assert.Equal(t, []string{SectionIdentity, SectionSupplies, SectionCounters}, snap.Incomplete([]string{
+		SectionIdentity, SectionStatus, SectionSupplies, SectionCounters,
+	}))
+
+	assert.Equal(t, "mac", snap.Gap(SectionIdentity))
+	assert.Equal(t, "toner magenta,toner yellow", snap.Gap(SectionSupplies))
+	assert.Equal(t, "all", snap.Gap(SectionTrays))
+
+	snap.Identity.MAC = "00:80:92:7A:FB:CE"
+	snap.Supplies = append(snap.Supplies, Supply{Kind: "toner", Color: "magenta"}, Supply{Kind: "toner", Color: "yellow"})
+	snap.SetCounter(CounterTotal, 5432)
+
+	assert.Empty(t, snap.Incomplete([]string{SectionIdentity, SectionStatus, SectionSupplies, SectionCounters}))
+	assert.Equal(t, []string{SectionTrays}, snap.Incomplete([]string{SectionTrays}))
+
+	// A mono laser needs its black toner and a drum
+	mono := &Snapshot{Supplies: []Supply{{Kind: "toner", Color: "black", Percent: 60}}}
+	assert.Equal(t, []string{SectionSupplies}, mono.Incomplete([]string{SectionSupplies}))
+
+	mono.Supplies = append(mono.Supplies, Supply{Kind: "drum", Color: "black", Percent: 80})
+	assert.Empty(t, mono.Incomplete([]string{SectionSupplies}))
+
+	// Near end flags alone are no levels
+	flags := &Snapshot{NearEnd: map[string]bool{"toner": false}}
+	assert.Equal(t, []string{SectionSupplies}, flags.Incomplete([]string{SectionSupplies}))
+}
+
+func TestSupplyName(t *testing.T) {
+	assert.Equal(t, "Toner cyan", SupplyName(Supply{Kind: "toner", Color: "cyan"}))
+	assert.Equal(t, "Fuser unit", SupplyName(Supply{Kind: "fuser_unit"}))
+	assert.Empty(t, SupplyName(Supply{}))
+}
@@ -0,0 +1,150 @@
+package printer
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State is the latest known state of a printer, built up from the
+// snapshots of successive collection cycles
+type State struct {
+	// ID identifies the printer, currently its configured host
+	ID   string
+	Host string
+
+	// Snapshot holds the most recent value of every section collected so
+	// far, with when each was refreshed in Snapshot.Updated
+	Snapshot *Snapshot
+
+	// Reachable is whether the last cycle reached the printer
+	Reachable bool
+	// LastCollection is when the last cycle ran, LastSuccess when one last
+	// reached the printer
+	LastCollection time.Time
+	LastSuccess    time.Time
+	// Errors are the errors of the last cycle
+	Errors []string
+}
+
+// Store keeps the latest state of each printer in memory
+type Store struct {
+	mu          sync.RWMutex
+	printers    map[string]*State
+	subscribers []func(State)
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{printers: make(map[string]*State)}
+}
+
+// Subscribe registers fn to be called with a copy of a printer's state
+// after every collection cycle. fn runs on the collection goroutine, so it
+// should not block for long.
+func (s *Store) Subscribe(fn func(State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Update records a collection cycle and notifies the subscribers. snap is
+// nil when no source reached the printer, in which case the previous data
+// is kept.
+func (s *Store) Update(id, host string, snap *Snapshot, collectedAt time.Time, errs []error) {
+	state, subscribers := s.update(id, host, snap, collectedAt, errs)
+
+	for _, fn := range subscribers {
+		fn(state.clone())
+	}
+}
+
+func (s *Store) update(id, host string, snap *Snapshot, collectedAt time.Time, errs []error) (State, []func(State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.printers[id]
+	if !ok {
+		state = &State{ID: id, Host: host, Snapshot: &Snapshot{}}
+		s.printers[id] = state
+	}
+
+	state.LastCollection = collectedAt
+	state.Reachable = snap != nil
+	state.Errors = nil
+
+	for _, err := range errs {
+		state.Errors = append(state.Errors, err.Error())
+	}
+
+	if snap != nil {
+		state.LastSuccess = collectedAt
+		state.Snapshot.Apply(snap)
+	}
+
+	return state.clone(), slices.Clone(s.subscribers)
+}
+
+// Patch merges a partial snapshot received outside a collection cycle, such
+// as the status from an SNMP trap, and notifies the subscribers. It leaves
+// the reachability and errors of the last cycle alone.
+func (s *Store) Patch(id, host string, snap *Snapshot) {
+	state, subscribers := s.patch(id, host, snap)
+
+	for _, fn := range subscribers {
+		fn(state.clone())
+	}
+}
+
+func (s *Store) patch(id, host string, snap *Snapshot) (State, []func(State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.printers[id]
+	if !ok {
+		state = &State{ID: id, Host: host, Snapshot: &Snapshot{}}
+		s.printers[id] = state
+	}
+
+	state.Snapshot.Apply(snap)
+
+	return state.clone(), slices.Clone(s.subscribers)
+}
+
+// Get returns a copy of a printer's state
+func (s *Store) Get(id string) (State, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.printers[id]
+	if !ok {
+		return State{}, false
+	}
+
+	return state.clone(), true
+}
+
+// List returns a copy of every printer's state, ordered by ID
+func (s *Store) List() []State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make([]State, 0, len(s.printers))
+	for _, state := range s.printers {
+		states = append(states, state.clone())
+	}
+
+	slices.SortFunc(states, func(a, b State) int { return strings.Compare(a.ID, b.ID) })
+
+	return states
+}
+
+func (st *State) clone() State {
+	clone := *st
+	clone.Snapshot = st.Snapshot.Clone()
+	clone.Errors = slices.Clone(st.Errors)
+
+	return clone
+}
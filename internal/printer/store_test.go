@@ -0,0 +1,112 @@
+package printer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+
+	first := time.Unix(1700000000, 0)
+	store.Update("printer", "10.0.0.5", &Snapshot{
+		CollectedAt: first,
+		Status:      &Status{State: "stopped"},
+		Alerts:      []Alert{{Code: "jammed", Severity: SeverityCritical}},
+		Counters:    map[string]float64{CounterTotal: 100},
+	}, first, nil)
+
+	second := first.Add(time.Minute)
+	store.Update("printer", "10.0.0.5", &Snapshot{
+		CollectedAt: second,
+		Status:      &Status{State: "ready"},
+	}, second, []error{errors.New("snmp: timeout")})
+
+	state, ok := store.Get("printer")
+	assert.True(t, ok)
+	assert.True(t, state.Reachable)
+	assert.Equal(t, second, state.LastSuccess)
+	assert.Equal(t, []string{"snmp: timeout"}, state.Errors)
+
+	// The status section was replaced along with its alerts, the counters
+	// kept from the first cycle
+	assert.Equal(t, "ready", state.Snapshot.Status.State)
+	assert.Empty(t, state.Snapshot.Alerts)
+	assert.Equal(t, 100.0, state.Snapshot.Counters[CounterTotal])
+	assert.Equal(t, map[string]time.Time{SectionStatus: second, SectionCounters: first}, state.Snapshot.Updated)
+
+	// Unreachable cycles keep the data
+	third := second.Add(time.Minute)
+	store.Update("printer", "10.0.0.5", nil, third, []error{errors.New("snmp: no answer")})
+
+	state, _ = store.Get("printer")
+	assert.False(t, state.Reachable)
+	assert.Equal(t, third, state.LastCollection)
+	assert.Equal(t, second, state.LastSuccess)
+	assert.Equal(t, 100.0, state.Snapshot.Counters[CounterTotal])
+
+	// Copies don't share data with the store
+	state.Snapshot.Counters[CounterTotal] = 0
+
+	state, _ = store.Get("printer")
+	assert.Equal(t, 100.0, state.Snapshot.Counters[CounterTotal])
+
+	assert.Len(t, store.List(), 1)
+
+	_, ok = store.Get("other")
+	assert.False(t, ok)
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	store := NewStore()
+
+	var got []State
+
+	store.Subscribe(func(state State) { got = append(got, state) })
+
+	now := time.Unix(1700000000, 0)
+	store.Update("printer", "10.0.0.5", &Snapshot{CollectedAt: now, Status: &Status{State: "ready"}}, now, nil)
+	store.Update("printer", "10.0.0.5", nil, now.Add(time.Minute), nil)
+
+	if assert.Len(t, got, 2) {
+		assert.True(t, got[0].Reachable)
+		assert.Equal(t, "ready", got[0].Snapshot.Status.State)
+		assert.False(t, got[1].Reachable)
+		assert.Equal(t, "ready", got[1].Snapshot.Status.State)
+	}
+}
+
+func TestStorePatch(t *testing.T) {
+	store := NewStore()
+
+	var got []State
+
+	store.Subscribe(func(state State) { got = append(got, state) })
+
+	now := time.Unix(1700000000, 0)
+	store.Update("printer", "10.0.0.5", &Snapshot{
+		CollectedAt: now,
+		Status:      &Status{State: "ready"},
+		Trays:       []Tray{{Name: "tray1", Status: "ok"}},
+	}, now, []error{assert.AnError})
+
+	store.Patch("printer", "10.0.0.5", &Snapshot{
+		CollectedAt: now.Add(time.Minute),
+		Status:      &Status{State: "stopped"},
+		Alerts:      []Alert{{Code: "jammed", Severity: SeverityCritical}},
+	})
+
+	if assert.Len(t, got, 2) {
+		patched := got[1]
+		assert.True(t, patched.Reachable)
+		assert.Equal(t, now, patched.LastSuccess)
+		assert.Len(t, patched.Errors, 1)
+		assert.Equal(t, "stopped", patched.Snapshot.Status.State)
+		assert.Equal(t, []Alert{{Code: "jammed", Severity: SeverityCritical}}, patched.Snapshot.Alerts)
+		assert.Equal(t, []Tray{{Name: "tray1", Status: "ok"}}, patched.Snapshot.Trays)
+		assert.Equal(t, now.Add(time.Minute), patched.Snapshot.Updated[SectionStatus])
+	}
+}
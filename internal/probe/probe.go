@@ -0,0 +1,218 @@
+// Package probe reports what one collection of every section read from a
+// printer, for diagnosing models the exporter doesn't read correctly.
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/api"
+	"github.com/d0ugal/brother-exporter/internal/collectors"
+)
+
+// Report formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Report is the outcome of a probe. Printer is the decoded state as the
+// JSON API serves it; OIDs and Blobs are the SNMP answers behind it.
+type Report struct {
+	Host            string                 `json:"host"`
+	StartedAt       time.Time              `json:"started_at"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Printer         api.Printer            `json:"printer"`
+	OIDs            []collectors.ProbeOID  `json:"oids"`
+	Blobs           []collectors.ProbeBlob `json:"blobs"`
+}
+
+// NewReport builds the report of a probe of host that started at
+// startedAt and took duration
+func NewReport(host string, startedAt time.Time, duration time.Duration, probe collectors.Probe) Report {
+	r := Report{
+		Host:            host,
+		StartedAt:       startedAt,
+		DurationSeconds: duration.Seconds(),
+		Printer:         api.NewPrinter(probe.State),
+		OIDs:            probe.OIDs,
+		Blobs:           probe.Blobs,
+	}
+
+	if r.OIDs == nil {
+		r.OIDs = []collectors.ProbeOID{}
+	}
+
+	if r.Blobs == nil {
+		r.Blobs = []collectors.ProbeBlob{}
+	}
+
+	return r
+}
+
+// Write writes the report as text or JSON
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(r)
+	case FormatText:
+		return r.writeText(w)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeText writes the report for reading: a section per part of the
+// printer state, then the OIDs and the blob records
+func (r Report) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	p := r.Printer
+
+	reachable := "no"
+	if p.Reachable {
+		reachable = "yes"
+	}
+
+	fmt.Fprintf(tw, "Probe of %s at %s, took %.1fs\n", r.Host, r.StartedAt.Format(time.RFC3339), r.DurationSeconds)
+	fmt.Fprintf(tw, "Reachable:\t%s\n", reachable)
+
+	for _, err := range p.Errors {
+		fmt.Fprintf(tw, "Error:\t%s\n", err)
+	}
+
+	section(tw, "Identity")
+
+	if info := p.Info; info != nil {
+		for _, field := range [][2]string{
+			{"Model", info.Model},
+			{"Serial", info.Serial},
+			{"Firmware", info.Firmware},
+			{"MAC", info.MAC},
+			{"Name", info.Name},
+			{"Location", info.Location},
+			{"Contact", info.Contact},
+			{"Description", info.Description},
+		} {
+			fmt.Fprintf(tw, "  %s:\t%s\n", field[0], field[1])
+		}
+	} else {
+		fmt.Fprintln(tw, "  not collected")
+	}
+
+	section(tw, "Status")
+
+	if status := p.Status; status != nil {
+		fmt.Fprintf(tw, "  State:\t%s\n", status.State)
+
+		if status.Message != "" {
+			fmt.Fprintf(tw, "  Message:\t%s\n", status.Message)
+		}
+
+		for _, alert := range status.Alerts {
+			fmt.Fprintf(tw, "  Alert:\t%s (%s)\t%s\n", alert.Code, alert.Severity, alert.Message)
+		}
+	} else {
+		fmt.Fprintln(tw, "  not collected")
+	}
+
+	if p.BootTime != nil {
+		fmt.Fprintf(tw, "  Boot time:\t%s\n", p.BootTime.Format(time.RFC3339))
+	}
+
+	section(tw, "Supplies")
+
+	for _, supply := range p.Supplies {
+		name := strings.TrimSpace(supply.Kind + " " + supply.Color)
+
+		nearEnd := ""
+		if supply.NearEnd != nil {
+			nearEnd = "near end: " + strconv.FormatBool(*supply.NearEnd)
+		}
+
+		fmt.Fprintf(tw, "  %s\t%s%%\t%s\t%s\n", name, strconv.FormatFloat(supply.Percent, 'f', -1, 64), supply.State, nearEnd)
+	}
+
+	empty(tw, len(p.Supplies))
+
+	section(tw, "Maintenance parts")
+
+	for _, part := range p.Maintenance {
+		fmt.Fprintf(tw, "  %s\t%s pages remaining\n", part.Kind, strconv.FormatFloat(part.RemainingPages, 'f', -1, 64))
+	}
+
+	empty(tw, len(p.Maintenance))
+
+	section(tw, "Trays")
+
+	for _, tray := range p.Trays {
+		fmt.Fprintf(tw, "  %s\t%s\n", tray.Name, tray.Status)
+	}
+
+	empty(tw, len(p.Trays))
+
+	section(tw, "Counters")
+
+	for _, name := range slices.Sorted(maps.Keys(p.Counters)) {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, strconv.FormatFloat(p.Counters[name], 'f', -1, 64))
+	}
+
+	empty(tw, len(p.Counters))
+
+	failed := 0
+
+	for _, oid := range r.OIDs {
+		if oid.Error != "" {
+			failed++
+		}
+	}
+
+	section(tw, fmt.Sprintf("SNMP OIDs, %d answered, %d failed", len(r.OIDs)-failed, failed))
+
+	for _, oid := range r.OIDs {
+		if oid.Error != "" {
+			fmt.Fprintf(tw, "  FAIL\t%s\t%s\t%s\n", oid.OID, oid.Name, oid.Error)
+		} else {
+			fmt.Fprintf(tw, "  ok\t%s\t%s\t%s: %s\n", oid.OID, oid.Name, oid.Type, oid.Value)
+		}
+	}
+
+	empty(tw, len(r.OIDs))
+
+	for _, blob := range r.Blobs {
+		section(tw, fmt.Sprintf("Brother %s data (%s)", blob.Name, blob.OID))
+		fmt.Fprintf(tw, "  raw:\t%s\n", blob.Raw)
+		fmt.Fprintln(tw, "  code\trecord\tvalue\tname\tdecoded")
+
+		for _, record := range blob.Records {
+			name := record.Name
+			if name == "" {
+				name = "-"
+			}
+
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%s\t%s\n", record.Code, record.Raw, record.Value, name, record.Decoded)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func section(w io.Writer, title string) {
+	fmt.Fprintf(w, "\n%s\n", title)
+}
+
+// empty notes a section without entries
+func empty(w io.Writer, n int) {
+	if n == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+}
@@ -0,0 +1,83 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/collectors"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testProbe() collectors.Probe {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	return collectors.Probe{
+		State: printer.State{
+			ID:             "10.0.0.5",
+			Host:           "10.0.0.5",
+			Reachable:      true,
+			LastCollection: at,
+			LastSuccess:    at,
+			Snapshot: &printer.Snapshot{
+				Identity:    &printer.Identity{Model: "HL-L3270CDW series", Serial: "E123"},
+				Status:      &printer.Status{State: "ready"},
+				Supplies:    []printer.Supply{{Kind: "toner", Color: "black", Percent: 60, State: "ok"}},
+				NearEnd:     map[string]bool{"toner": false},
+				Maintenance: []printer.Part{{Kind: "fuser_unit", RemainingPages: 48000}},
+				Trays:       []printer.Tray{{Name: "tray1", Status: "ok"}},
+				Counters:    map[string]float64{printer.CounterTotal: 5432},
+			},
+		},
+		OIDs: []collectors.ProbeOID{
+			{OID: "1.3.6.1.2.1.1.5.0", Name: "sysName", Type: "OctetString", Value: "BRN123"},
+			{OID: "1.3.6.1.2.1.1.6.0", Name: "sysLocation", Type: "NoSuchObject", Error: "NoSuchObject"},
+		},
+		Blobs: []collectors.ProbeBlob{{
+			OID:  "1.3.6.1.4.1.2435.2.3.9.4.2.1.5.5.8.0",
+			Name: "maintenance",
+			Raw:  "6f010400001770ff",
+			Records: []collectors.ProbeRecord{
+				{Code: "6f", Raw: "6f010400001770", Value: 6000, Name: "black_toner_remaining", Decoded: "60%"},
+				{Code: "99", Raw: "9901040000002a", Value: 42},
+			},
+		}},
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	report := NewReport("10.0.0.5", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), 2300*time.Millisecond, testProbe())
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, FormatText))
+
+	text := buf.String()
+	assert.Contains(t, text, "Probe of 10.0.0.5 at 2026-03-01T12:00:00Z, took 2.3s\n")
+	assert.Contains(t, text, "  Model:        HL-L3270CDW series\n")
+	assert.Contains(t, text, "  toner black  60%  ok  near end: false\n")
+	assert.Contains(t, text, "  fuser_unit  48000 pages remaining\n")
+	assert.Contains(t, text, "SNMP OIDs, 1 answered, 1 failed\n")
+	assert.Contains(t, text, "  FAIL  1.3.6.1.2.1.1.6.0  sysLocation  NoSuchObject\n")
+	assert.Contains(t, text, "Brother maintenance data (1.3.6.1.4.1.2435.2.3.9.4.2.1.5.5.8.0)\n")
+	assert.Contains(t, text, "  99    9901040000002a  42     -")
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := NewReport("10.0.0.5", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), time.Second, collectors.Probe{State: printer.State{Host: "10.0.0.5"}})
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, FormatJSON))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+	assert.Equal(t, "10.0.0.5", decoded["host"])
+	assert.Equal(t, []any{}, decoded["oids"])
+	assert.Equal(t, []any{}, decoded["blobs"])
+	assert.Equal(t, false, decoded["printer"].(map[string]any)["reachable"])
+
+	assert.Error(t, report.Write(&buf, "xml"))
+}
@@ -0,0 +1,145 @@
+package push
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// label is a series label, __name__ included
+type label struct {
+	name  string
+	value string
+}
+
+// series is one sample of one time series
+type series struct {
+	labels    []label
+	value     float64
+	timestamp int64
+}
+
+// toSeries flattens metric families into samples at one time, the way
+// they are exposed for scraping: histograms become their _bucket, _sum and
+// _count series. external labels are added where a series lacks them.
+// Summaries, which the registry has none of, are skipped.
+func toSeries(families []*dto.MetricFamily, external map[string]string, at time.Time) []series {
+	var all []series
+
+	timestamp := at.UnixMilli()
+
+	add := func(name string, labels []*dto.LabelPair, value float64, extra ...label) {
+		s := series{value: value, timestamp: timestamp}
+		s.labels = append(s.labels, label{name: "__name__", value: name})
+
+		for _, pair := range labels {
+			s.labels = append(s.labels, label{name: pair.GetName(), value: pair.GetValue()})
+		}
+
+		s.labels = append(s.labels, extra...)
+
+		for name, value := range external {
+			if !slices.ContainsFunc(s.labels, func(l label) bool { return l.name == name }) {
+				s.labels = append(s.labels, label{name: name, value: value})
+			}
+		}
+
+		// Remote write requires labels sorted by name, without empty values
+		s.labels = slices.DeleteFunc(s.labels, func(l label) bool { return l.value == "" })
+		slices.SortFunc(s.labels, func(a, b label) int { return strings.Compare(a.name, b.name) })
+
+		all = append(all, s)
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				add(name, metric.GetLabel(), metric.GetGauge().GetValue())
+			case dto.MetricType_COUNTER:
+				add(name, metric.GetLabel(), metric.GetCounter().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, metric.GetLabel(), metric.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				infinite := false
+
+				for _, bucket := range histogram.GetBucket() {
+					infinite = infinite || math.IsInf(bucket.GetUpperBound(), 1)
+					add(name+"_bucket", metric.GetLabel(), float64(bucket.GetCumulativeCount()),
+						label{name: "le", value: formatBound(bucket.GetUpperBound())})
+				}
+
+				// The +Inf bucket is implicit in the client library
+				if !infinite {
+					add(name+"_bucket", metric.GetLabel(), float64(histogram.GetSampleCount()), label{name: "le", value: "+Inf"})
+				}
+
+				add(name+"_sum", metric.GetLabel(), histogram.GetSampleSum())
+				add(name+"_count", metric.GetLabel(), float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+
+	return all
+}
+
+func formatBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
+
+// Field numbers of the remote-write WriteRequest protobuf message
+const (
+	writeRequestTimeseries = 1
+	timeSeriesLabels       = 1
+	timeSeriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+// encodeWriteRequest encodes samples as a remote-write 1.0 WriteRequest
+func encodeWriteRequest(all []series) []byte {
+	var request []byte
+
+	for _, s := range all {
+		var ts []byte
+
+		for _, l := range s.labels {
+			var encoded []byte
+			encoded = protowire.AppendTag(encoded, labelName, protowire.BytesType)
+			encoded = protowire.AppendString(encoded, l.name)
+			encoded = protowire.AppendTag(encoded, labelValue, protowire.BytesType)
+			encoded = protowire.AppendString(encoded, l.value)
+
+			ts = protowire.AppendTag(ts, timeSeriesLabels, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, encoded)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, sampleValue, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, sampleTimestamp, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+
+		ts = protowire.AppendTag(ts, timeSeriesSamples, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		request = protowire.AppendTag(request, writeRequestTimeseries, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+
+	return request
+}
@@ -0,0 +1,70 @@
+package push
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	toner := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "brother_printer_toner_level_percent"}, []string{"host", "color", "site"})
+	toner.WithLabelValues("192.168.1.100", "black", "").Set(60)
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "brother_exporter_collection_duration_seconds",
+		Buckets: []float64{0.1, 1},
+	}, []string{"host"})
+	duration.WithLabelValues("192.168.1.100").Observe(0.5)
+
+	registry.MustRegister(toner, duration)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	all := toSeries(families, map[string]string{"job": "brother-exporter", "color": "ignored"}, at)
+
+	byName := make(map[string][]series)
+	for _, s := range all {
+		assert.Equal(t, at.UnixMilli(), s.timestamp)
+		byName[s.labels[0].value] = append(byName[s.labels[0].value], s)
+	}
+
+	// Sorted labels, empty ones dropped and the series' own label winning
+	// over an external one
+	assert.Equal(t, []series{{
+		labels: []label{
+			{"__name__", "brother_printer_toner_level_percent"},
+			{"color", "black"},
+			{"host", "192.168.1.100"},
+			{"job", "brother-exporter"},
+		},
+		value:     60,
+		timestamp: at.UnixMilli(),
+	}}, byName["brother_printer_toner_level_percent"])
+
+	buckets := byName["brother_exporter_collection_duration_seconds_bucket"]
+	require.Len(t, buckets, 3)
+
+	le := func(s series) string {
+		for _, l := range s.labels {
+			if l.name == "le" {
+				return l.value
+			}
+		}
+
+		return ""
+	}
+
+	assert.Equal(t, "0.1", le(buckets[0]))
+	assert.Equal(t, 0.0, buckets[0].value)
+	assert.Equal(t, "+Inf", le(buckets[2]))
+	assert.Equal(t, 1.0, buckets[2].value)
+	assert.Equal(t, 0.5, byName["brother_exporter_collection_duration_seconds_sum"][0].value)
+	assert.Equal(t, 1.0, byName["brother_exporter_collection_duration_seconds_count"][0].value)
+}
@@ -0,0 +1,119 @@
+package push
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/prometheus/client_golang/prometheus"
+	prometheus_push "github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Pushgateway replaces each printer's group on a Pushgateway with the
+// current metrics after each cycle. Only the latest metrics matter, so a
+// failed push is not retried, the next cycle pushes again. It implements
+// the promexporter collector interface.
+type Pushgateway struct {
+	cfg      config.PushgatewayConfig
+	gatherer prometheus.Gatherer
+	client   *http.Client
+
+	mu sync.Mutex
+	// pending are the printers to push, a slow push coalescing cycles
+	pending []string
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPushgateway creates a pusher of gatherer's metrics
+func NewPushgateway(cfg config.PushgatewayConfig, gatherer prometheus.Gatherer) *Pushgateway {
+	return &Pushgateway{
+		cfg:      cfg,
+		gatherer: gatherer,
+		client:   &http.Client{Timeout: cfg.Timeout.Duration},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Start pushes in the background
+func (p *Pushgateway) Start(ctx context.Context) {
+	slog.Info("Starting Pushgateway pusher", "url", p.cfg.URL, "job", p.cfg.Job)
+
+	ctx, p.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wake:
+			}
+
+			p.mu.Lock()
+			pending := p.pending
+			p.pending = nil
+			p.mu.Unlock()
+
+			for _, id := range pending {
+				p.push(ctx, id)
+			}
+		}
+	}()
+}
+
+// Stop stops pushing. The groups stay on the Pushgateway.
+func (p *Pushgateway) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+
+	p.wg.Wait()
+}
+
+// Observe schedules a push of the printer's group, called after each cycle
+func (p *Pushgateway) Observe(state printer.State) {
+	p.mu.Lock()
+
+	if !slices.Contains(p.pending, state.ID) {
+		p.pending = append(p.pending, state.ID)
+	}
+
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// push replaces the group of job and the printer as instance
+func (p *Pushgateway) push(ctx context.Context, id string) {
+	header := http.Header{"User-Agent": {userAgent}}
+	authorize(header, p.cfg.PushAuth)
+
+	err := prometheus_push.New(p.cfg.URL, p.cfg.Job).
+		Gatherer(p.gatherer).
+		Grouping("instance", id).
+		Client(p.client).
+		Header(header).
+		PushContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("Failed to push to the Pushgateway", "url", p.cfg.URL, "printer", id, "error", err)
+		}
+
+		return
+	}
+
+	slog.Debug("Pushed to the Pushgateway", "url", p.cfg.URL, "printer", id)
+}
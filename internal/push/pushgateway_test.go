@@ -0,0 +1,65 @@
+package push
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pushRequest struct {
+	method string
+	path   string
+	auth   string
+	body   []byte
+}
+
+func TestPushgateway(t *testing.T) {
+	requests := make(chan pushRequest, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		requests <- pushRequest{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization"), body: body}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "brother_printer_toner_level_percent"})
+	gauge.Set(60)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(gauge)
+
+	pusher := NewPushgateway(config.PushgatewayConfig{
+		URL:      server.URL,
+		PushAuth: config.PushAuth{BearerToken: "secret"},
+		Job:      "brother-exporter",
+		Timeout:  config.Duration{Duration: 5 * time.Second},
+	}, registry)
+
+	pusher.Start(t.Context())
+	defer pusher.Stop()
+
+	pusher.Observe(printer.State{ID: "192.168.1.100"})
+
+	select {
+	case req := <-requests:
+		// The whole group is replaced
+		assert.Equal(t, http.MethodPut, req.method)
+		assert.Equal(t, "/metrics/job/brother-exporter/instance/192.168.1.100", req.path)
+		assert.Equal(t, "Bearer secret", req.auth)
+		assert.Contains(t, string(req.body), "brother_printer_toner_level_percent")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no push received")
+	}
+}
@@ -0,0 +1,263 @@
+// Package push sends the Brother metrics after each collection cycle to a
+// Prometheus remote-write endpoint or a Pushgateway, for printers that
+// can't be scraped.
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/d0ugal/brother-exporter/internal/version"
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// userAgent identifies the exporter to push targets
+var userAgent = "brother-exporter/" + version.Version
+
+// batch is one cycle's samples, encoded and compressed
+type batch struct {
+	body []byte
+}
+
+// RemoteWriter sends the metrics with the remote-write protocol after each
+// cycle. Batches wait in memory while the endpoint can't be reached and
+// are sent oldest first, so nothing is lost unless the queue overflows or
+// the exporter stops. It implements the promexporter collector interface.
+type RemoteWriter struct {
+	cfg      config.RemoteWriteConfig
+	gatherer prometheus.Gatherer
+	external map[string]string
+	client   *http.Client
+	now      func() time.Time
+
+	mu    sync.Mutex
+	queue []*batch
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRemoteWriter creates a remote writer of gatherer's metrics
+func NewRemoteWriter(cfg config.RemoteWriteConfig, gatherer prometheus.Gatherer) *RemoteWriter {
+	external := map[string]string{"job": cfg.Job}
+	maps.Copy(external, cfg.ExternalLabels)
+
+	return &RemoteWriter{
+		cfg:      cfg,
+		gatherer: gatherer,
+		external: external,
+		client:   &http.Client{Timeout: cfg.Timeout.Duration},
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Start sends the queued batches in the background
+func (w *RemoteWriter) Start(ctx context.Context) {
+	slog.Info("Starting remote write", "url", w.cfg.URL)
+
+	ctx, w.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.wake:
+			}
+
+			w.drain(ctx)
+		}
+	}()
+}
+
+// Stop stops sending, discarding what is still queued
+func (w *RemoteWriter) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) > 0 {
+		slog.Warn("Discarding unsent remote write batches", "count", len(w.queue))
+	}
+}
+
+// Observe queues the current metrics, called after each cycle
+func (w *RemoteWriter) Observe(_ printer.State) {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		slog.Error("Failed to gather metrics for remote write", "error", err)
+		return
+	}
+
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(toSeries(families, w.external, w.now())))
+
+	w.mu.Lock()
+
+	if len(w.queue) >= w.cfg.QueueSize {
+		w.queue = w.queue[1:]
+		slog.Warn("Remote write queue is full, dropped the oldest batch", "queue_size", w.cfg.QueueSize)
+	}
+
+	w.queue = append(w.queue, &batch{body: body})
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drain sends the queue until it is empty, retrying with backoff
+func (w *RemoteWriter) drain(ctx context.Context) {
+	backoff := w.cfg.MinBackoff.Duration
+
+	for {
+		next := w.peek()
+		if next == nil {
+			return
+		}
+
+		err := w.send(ctx, next.body)
+		if err == nil || !retryable(err) {
+			if err != nil {
+				slog.Error("Remote write rejected a batch, dropping it", "url", w.cfg.URL, "error", err)
+			}
+
+			w.remove(next)
+
+			backoff = w.cfg.MinBackoff.Duration
+
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Remote write failed, retrying", "url", w.cfg.URL, "error", err, "backoff", backoff, "queued", w.queued())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, w.cfg.MaxBackoff.Duration)
+	}
+}
+
+func (w *RemoteWriter) peek() *batch {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) == 0 {
+		return nil
+	}
+
+	return w.queue[0]
+}
+
+// remove drops a sent batch, unless a full queue dropped it meanwhile
+func (w *RemoteWriter) remove(sent *batch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) > 0 && w.queue[0] == sent {
+		w.queue = w.queue[1:]
+	}
+}
+
+func (w *RemoteWriter) queued() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.queue)
+}
+
+// statusError is a response other than 2xx
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.code, e.message)
+}
+
+// retryable reports whether a failed send may succeed later: network
+// errors, throttling and server errors are, other rejections are not
+func retryable(err error) bool {
+	var status *statusError
+	if !errors.As(err, &status) {
+		return true
+	}
+
+	return status.code == http.StatusTooManyRequests || status.code >= 500
+}
+
+func (w *RemoteWriter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &statusError{message: err.Error()}
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	for name, value := range w.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	authorize(req.Header, w.cfg.PushAuth)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode/100 != 2 {
+		return &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+
+	return nil
+}
+
+// authorize adds basic auth or a bearer token to a request's headers
+func authorize(header http.Header, auth config.PushAuth) {
+	switch {
+	case auth.BearerToken != "":
+		header.Set("Authorization", "Bearer "+auth.BearerToken)
+	case auth.Username != "":
+		req := http.Request{Header: header}
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
@@ -0,0 +1,226 @@
+package push
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeWriteRequest decodes a WriteRequest into its series
+func decodeWriteRequest(t *testing.T, b []byte) []series {
+	t.Helper()
+
+	// fields calls fn with each length-delimited or scalar field of b
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, scalar uint64)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+
+			switch typ {
+			case protowire.BytesType:
+				value, n := protowire.ConsumeBytes(b)
+				require.GreaterOrEqual(t, n, 0)
+				fn(num, typ, value, 0)
+				b = b[n:]
+			case protowire.Fixed64Type:
+				value, n := protowire.ConsumeFixed64(b)
+				require.GreaterOrEqual(t, n, 0)
+				fn(num, typ, nil, value)
+				b = b[n:]
+			case protowire.VarintType:
+				value, n := protowire.ConsumeVarint(b)
+				require.GreaterOrEqual(t, n, 0)
+				fn(num, typ, nil, value)
+				b = b[n:]
+			default:
+				require.Failf(t, "unexpected wire type", "%v", typ)
+			}
+		}
+	}
+
+	var all []series
+
+	fields(b, func(_ protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		var s series
+
+		fields(ts, func(num protowire.Number, _ protowire.Type, value []byte, _ uint64) {
+			switch num {
+			case timeSeriesLabels:
+				var l label
+
+				fields(value, func(num protowire.Number, _ protowire.Type, value []byte, _ uint64) {
+					if num == labelName {
+						l.name = string(value)
+					} else {
+						l.value = string(value)
+					}
+				})
+
+				s.labels = append(s.labels, l)
+			case timeSeriesSamples:
+				fields(value, func(num protowire.Number, _ protowire.Type, _ []byte, scalar uint64) {
+					if num == sampleValue {
+						s.value = math.Float64frombits(scalar)
+					} else {
+						s.timestamp = int64(scalar)
+					}
+				})
+			}
+		})
+
+		all = append(all, s)
+	})
+
+	return all
+}
+
+// writeReceiver answers remote-write requests with the given statuses in
+// turn, then with 204, and records the requests it accepts
+type writeReceiver struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	statuses []int
+	accepted [][]series
+	received chan struct{}
+}
+
+func (r *writeReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	assert.Equal(r.t, "snappy", req.Header.Get("Content-Encoding"))
+	assert.Equal(r.t, "0.1.0", req.Header.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(r.t, "tenant-1", req.Header.Get("X-Scope-OrgID"))
+
+	username, password, ok := req.BasicAuth()
+	assert.True(r.t, ok)
+	assert.Equal(r.t, "branch", username)
+	assert.Equal(r.t, "secret", password)
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(r.t, err)
+
+	decoded, err := s2.Decode(nil, body)
+	require.NoError(r.t, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+
+	if status != http.StatusNoContent {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	r.accepted = append(r.accepted, decodeWriteRequest(r.t, decoded))
+	w.WriteHeader(status)
+	r.received <- struct{}{}
+}
+
+func TestRemoteWriter(t *testing.T) {
+	receiver := &writeReceiver{
+		t: t,
+		// The first batch is retried twice, the second rejected for good
+		statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusNoContent, http.StatusBadRequest},
+		received: make(chan struct{}, 10),
+	}
+
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "brother_printer_toner_level_percent"})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(gauge)
+
+	writer := NewRemoteWriter(config.RemoteWriteConfig{
+		URL:            server.URL,
+		PushAuth:       config.PushAuth{Username: "branch", Password: "secret"},
+		Headers:        map[string]string{"X-Scope-OrgID": "tenant-1"},
+		ExternalLabels: map[string]string{"site": "branch-1"},
+		Job:            "brother-exporter",
+		QueueSize:      10,
+		MinBackoff:     config.Duration{Duration: time.Millisecond},
+		MaxBackoff:     config.Duration{Duration: 5 * time.Millisecond},
+		Timeout:        config.Duration{Duration: 5 * time.Second},
+	}, registry)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := start
+	writer.now = func() time.Time { return at }
+
+	// Queued before the writer starts, as if the endpoint was down
+	for _, level := range []float64{60, 59, 58} {
+		gauge.Set(level)
+		writer.Observe(printer.State{ID: "192.168.1.100"})
+		at = at.Add(time.Minute)
+	}
+
+	writer.Start(t.Context())
+	defer writer.Stop()
+
+	for range 2 {
+		select {
+		case <-receiver.received:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "remote write not received")
+		}
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	sample := func(value float64, at time.Time) []series {
+		return []series{{
+			labels: []label{
+				{"__name__", "brother_printer_toner_level_percent"},
+				{"job", "brother-exporter"},
+				{"site", "branch-1"},
+			},
+			value:     value,
+			timestamp: at.UnixMilli(),
+		}}
+	}
+
+	// The first batch went through on its third attempt, the second was
+	// rejected and dropped, the third was sent with its own time
+	assert.Equal(t, [][]series{sample(60, start), sample(58, start.Add(2*time.Minute))}, receiver.accepted)
+	assert.Empty(t, receiver.statuses)
+}
+
+func TestRemoteWriterQueueFull(t *testing.T) {
+	writer := NewRemoteWriter(config.RemoteWriteConfig{URL: "http://127.0.0.1:1", QueueSize: 2}, prometheus.NewRegistry())
+
+	for range 3 {
+		writer.Observe(printer.State{ID: "192.168.1.100"})
+	}
+
+	first := writer.peek()
+	assert.Equal(t, 2, writer.queued())
+
+	// Removing a batch a full queue already dropped leaves the queue alone
+	writer.Observe(printer.State{ID: "192.168.1.100"})
+	writer.remove(first)
+	assert.Equal(t, 2, writer.queued())
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(io.ErrUnexpectedEOF))
+	assert.True(t, retryable(&statusError{code: http.StatusBadGateway}))
+	assert.True(t, retryable(&statusError{code: http.StatusTooManyRequests}))
+	assert.False(t, retryable(&statusError{code: http.StatusBadRequest}))
+}
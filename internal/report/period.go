@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+// monthLayout is the layout of a month period
+const monthLayout = "2006-01"
+
+// Period returns the period to report, in UTC, with to excluded: the given
+// month (YYYY-MM), or the days from and to (YYYY-MM-DD, both included), or
+// else the month before now
+func Period(month, from, to string, now time.Time) (time.Time, time.Time, error) {
+	if month != "" && (from != "" || to != "") {
+		return time.Time{}, time.Time{}, fmt.Errorf("month can't be combined with from and to")
+	}
+
+	if from != "" || to != "" {
+		if from == "" || to == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("from and to must be given together")
+		}
+
+		start, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from %q, expected YYYY-MM-DD", from)
+		}
+
+		end, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to %q, expected YYYY-MM-DD", to)
+		}
+
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("to %s is before from %s", to, from)
+		}
+
+		return start, end.AddDate(0, 0, 1), nil
+	}
+
+	var start time.Time
+
+	if month == "" {
+		now = now.UTC()
+		start = time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		var err error
+
+		start, err = time.Parse(monthLayout, month)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+		}
+	}
+
+	return start, start.AddDate(0, 1, 0), nil
+}
@@ -0,0 +1,249 @@
+// Package report computes the pages each printer printed over a period from
+// the history snapshots, for billing.
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/d0ugal/brother-exporter/internal/printer"
+)
+
+// Report formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Report is the usage of every printer with history over a period
+type Report struct {
+	// From and To bound the period, To excluded
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Currency string    `json:"currency,omitempty"`
+	Printers []Usage   `json:"printers"`
+}
+
+// Usage is one printer's pages and cost over the period
+type Usage struct {
+	ID       string `json:"id"`
+	Model    string `json:"model"`
+	Serial   string `json:"serial"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+
+	// FirstSnapshot and LastSnapshot are the snapshots the pages were
+	// counted between, which show when a printer was added or removed
+	// during the period or history is missing
+	FirstSnapshot time.Time `json:"first_snapshot"`
+	LastSnapshot  time.Time `json:"last_snapshot"`
+
+	Mono   int64 `json:"mono_pages"`
+	Color  int64 `json:"color_pages"`
+	Duplex int64 `json:"duplex_pages"`
+	Total  int64 `json:"total_pages"`
+	// CounterResets is how many times a counter went backwards, e.g.
+	// after a main board replacement
+	CounterResets int `json:"counter_resets"`
+
+	MonoCost  float64 `json:"mono_cost"`
+	ColorCost float64 `json:"color_cost"`
+	TotalCost float64 `json:"total_cost"`
+}
+
+// Generator builds reports from the history
+type Generator struct {
+	db  *history.DB
+	cfg config.ReportConfig
+}
+
+// NewGenerator creates a generator reading db and pricing pages with cfg
+func NewGenerator(db *history.DB, cfg config.ReportConfig) *Generator {
+	return &Generator{db: db, cfg: cfg}
+}
+
+// Generate reports the usage from from to to, to excluded. A printer's
+// pages are counted from its first snapshot in the period to its first
+// after it, or its last in the period if there is none yet. Printers
+// without a snapshot in the period are left out.
+func (g *Generator) Generate(from, to time.Time) (*Report, error) {
+	ids, err := g.db.Printers()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{From: from, To: to, Currency: g.cfg.Currency, Printers: []Usage{}}
+
+	for _, id := range ids {
+		snaps, err := g.db.Query(id, from, to.Add(-time.Nanosecond))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(snaps) == 0 {
+			continue
+		}
+
+		next, found, err := g.db.Next(id, to)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			snaps = append(snaps, next)
+		}
+
+		report.Printers = append(report.Printers, g.usage(id, snaps))
+	}
+
+	return report, nil
+}
+
+// usage sums the counter increases between consecutive snapshots
+func (g *Generator) usage(id string, snaps []history.Snapshot) Usage {
+	usage := Usage{
+		ID:            id,
+		FirstSnapshot: snaps[0].Time,
+		LastSnapshot:  snaps[len(snaps)-1].Time,
+	}
+
+	deltas := make(map[string]float64)
+
+	for _, name := range []string{printer.CounterTotal, printer.CounterBlack, printer.CounterColor, printer.CounterDuplex} {
+		delta, resets, ok := counterDelta(snaps, name)
+		if ok {
+			deltas[name] = delta
+		}
+
+		usage.CounterResets += resets
+	}
+
+	usage.Total = int64(deltas[printer.CounterTotal])
+	usage.Color = int64(deltas[printer.CounterColor])
+	usage.Duplex = int64(deltas[printer.CounterDuplex])
+
+	// Mono models may only report the total
+	if black, ok := deltas[printer.CounterBlack]; ok {
+		usage.Mono = int64(black)
+	} else {
+		usage.Mono = max(usage.Total-usage.Color, 0)
+	}
+
+	// The latest identity wins, e.g. after the printer was moved
+	for _, snap := range snaps {
+		usage.Model = cmp.Or(snap.Model, usage.Model)
+		usage.Serial = cmp.Or(snap.Serial, usage.Serial)
+		usage.Name = cmp.Or(snap.Name, usage.Name)
+		usage.Location = cmp.Or(snap.Location, usage.Location)
+	}
+
+	usage.MonoCost = roundCents(float64(usage.Mono) * g.cfg.CostPerPage.Mono)
+	usage.ColorCost = roundCents(float64(usage.Color) * g.cfg.CostPerPage.Color)
+	usage.TotalCost = roundCents(usage.MonoCost + usage.ColorCost)
+
+	return usage
+}
+
+// counterDelta sums the increases of a counter over the snapshots that
+// have it. A counter lower than before was reset and counted from zero
+// again. ok is false when fewer than two snapshots have the counter.
+func counterDelta(snaps []history.Snapshot, name string) (float64, int, bool) {
+	var (
+		delta, previous float64
+		resets, seen    int
+	)
+
+	for _, snap := range snaps {
+		value, ok := snap.Counters[name]
+		if !ok {
+			continue
+		}
+
+		if seen > 0 {
+			if value >= previous {
+				delta += value - previous
+			} else {
+				delta += value
+				resets++
+			}
+		}
+
+		previous = value
+		seen++
+	}
+
+	return delta, resets, seen > 1
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// csvHeader names the CSV columns
+var csvHeader = []string{
+	"id", "model", "serial", "name", "location",
+	"period_start", "period_end", "first_snapshot", "last_snapshot",
+	"mono_pages", "color_pages", "duplex_pages", "total_pages", "counter_resets",
+	"mono_cost", "color_cost", "total_cost", "currency",
+}
+
+// Write writes the report in format, csv or json
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown report format %q, must be %s or %s", format, FormatCSV, FormatJSON)
+	}
+}
+
+// WriteCSV writes a header and a row per printer
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, usage := range r.Printers {
+		err := writer.Write([]string{
+			usage.ID, usage.Model, usage.Serial, usage.Name, usage.Location,
+			r.From.Format(time.RFC3339), r.To.Format(time.RFC3339),
+			usage.FirstSnapshot.Format(time.RFC3339), usage.LastSnapshot.Format(time.RFC3339),
+			strconv.FormatInt(usage.Mono, 10), strconv.FormatInt(usage.Color, 10),
+			strconv.FormatInt(usage.Duplex, 10), strconv.FormatInt(usage.Total, 10),
+			strconv.Itoa(usage.CounterResets),
+			formatCost(usage.MonoCost), formatCost(usage.ColorCost), formatCost(usage.TotalCost),
+			r.Currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d0ugal/brother-exporter/internal/config"
+	"github.com/d0ugal/brother-exporter/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func counters(total, black, color, duplex float64) map[string]float64 {
+	return map[string]float64{"total": total, "black": black, "color": color, "duplex": duplex}
+}
+
+func newGenerator(t *testing.T) (*Generator, *history.DB) {
+	t.Helper()
+
+	db, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return NewGenerator(db, config.ReportConfig{
+		Currency:    "EUR",
+		CostPerPage: config.PageCosts{Mono: 0.01, Color: 0.05},
+	}), db
+}
+
+func TestGenerate(t *testing.T) {
+	g, db := newGenerator(t)
+
+	march := time.Date(2026, 3, 1, 0, 0, 30, 0, time.UTC)
+	add := func(id string, day int, c map[string]float64) {
+		require.NoError(t, db.Add(id, history.Snapshot{
+			Time:     march.AddDate(0, 0, day),
+			Model:    "HL-L3270CDW",
+			Serial:   "SN-" + id,
+			Location: "Office",
+			Counters: c,
+		}))
+	}
+
+	// A full month: February's last snapshot is outside the period, April's
+	// first closes it
+	add("office", -1, counters(900, 700, 200, 50))
+	add("office", 0, counters(1000, 800, 200, 60))
+	add("office", 15, counters(1500, 1100, 400, 100))
+	add("office", 31, counters(2000, 1400, 600, 150))
+	add("office", 32, counters(2100, 1500, 600, 150))
+
+	// Added mid-month, its main board replaced, and a mono model without
+	// colour or black counters
+	add("reception", 10, counters(50000, 50000, 0, 0))
+	add("reception", 20, counters(50300, 50300, 0, 0))
+	add("reception", 21, counters(20, 20, 0, 0))
+	add("mono", 5, map[string]float64{"total": 100})
+	add("mono", 25, map[string]float64{"total": 400})
+
+	// Nothing in March
+	add("retired", -20, counters(10, 10, 0, 0))
+
+	from, to, err := Period("2026-03", "", "", time.Now())
+	require.NoError(t, err)
+
+	usage, err := g.Generate(from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), usage.From)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), usage.To)
+	require.Len(t, usage.Printers, 3)
+
+	mono, office, reception := usage.Printers[0], usage.Printers[1], usage.Printers[2]
+
+	assert.Equal(t, Usage{
+		ID:            "office",
+		Model:         "HL-L3270CDW",
+		Serial:        "SN-office",
+		Location:      "Office",
+		FirstSnapshot: march,
+		LastSnapshot:  march.AddDate(0, 0, 31),
+		Mono:          600,
+		Color:         400,
+		Duplex:        90,
+		Total:         1000,
+		MonoCost:      6,
+		ColorCost:     20,
+		TotalCost:     26,
+	}, office)
+
+	assert.Equal(t, march.AddDate(0, 0, 10), reception.FirstSnapshot)
+	assert.Equal(t, int64(320), reception.Total)
+	assert.Equal(t, int64(320), reception.Mono)
+	assert.Equal(t, 2, reception.CounterResets)
+
+	assert.Equal(t, int64(300), mono.Total)
+	assert.Equal(t, int64(300), mono.Mono)
+	assert.Equal(t, int64(0), mono.Color)
+}
+
+func TestWriteCSV(t *testing.T) {
+	usage := &Report{
+		From:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		Currency: "EUR",
+		Printers: []Usage{{
+			ID:            "office",
+			Model:         "HL-L3270CDW",
+			Serial:        "E123",
+			Location:      "Office, 2nd floor",
+			FirstSnapshot: time.Date(2026, 3, 1, 0, 0, 30, 0, time.UTC),
+			LastSnapshot:  time.Date(2026, 4, 1, 0, 0, 30, 0, time.UTC),
+			Mono:          600,
+			Color:         400,
+			Total:         1000,
+			MonoCost:      6,
+			ColorCost:     20,
+			TotalCost:     26,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, usage.Write(&buf, FormatCSV))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"office", "HL-L3270CDW", "E123", "", "Office, 2nd floor",
+		"2026-03-01T00:00:00Z", "2026-04-01T00:00:00Z", "2026-03-01T00:00:30Z", "2026-04-01T00:00:30Z",
+		"600", "400", "0", "1000", "0",
+		"6.00", "20.00", "26.00", "EUR",
+	}, records[1])
+
+	assert.Error(t, usage.Write(&buf, "xml"))
+}
+
+func TestPeriod(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	from, to, err := Period("", "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), to)
+
+	from, to, err = Period("", "2026-01-01", "2026-03-31", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = Period("2026-13", "", "", now)
+	assert.Error(t, err)
+
+	_, _, err = Period("2026-01", "2026-01-01", "2026-01-31", now)
+	assert.Error(t, err)
+
+	_, _, err = Period("", "2026-01-01", "", now)
+	assert.Error(t, err)
+
+	_, _, err = Period("", "2026-02-01", "2026-01-01", now)
+	assert.Error(t, err)
+}
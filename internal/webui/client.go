@@ -0,0 +1,210 @@
+package webui
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxPageSize bounds how much of a page is read
+const maxPageSize = 2 << 20
+
+// ErrLoginRequired is returned when a page needs the admin password and
+// none is configured
+var ErrLoginRequired = errors.New("web UI requires an admin login but no password is configured")
+
+// Client scrapes a single printer's web UI, logging in when needed. The
+// session cookie is kept between fetches.
+type Client struct {
+	// BaseURL is the printer's web server, e.g. http://192.168.1.100:80
+	BaseURL string
+
+	password   string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the printer at baseURL. insecureSkipVerify
+// disables certificate checks, as printers usually ship with a self-signed
+// certificate.
+func NewClient(baseURL, password string, timeout time.Duration, insecureSkipVerify bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // opt-in for self-signed printer certificates
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	jar, _ := cookiejar.New(nil) // only fails with non-nil options
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		password:   password,
+		httpClient: &http.Client{Timeout: timeout, Transport: transport, Jar: jar},
+	}
+}
+
+// Fetch reads the status and information pages and parses them with the
+// first matching generation parser
+func (c *Client) Fetch(ctx context.Context) (*Data, error) {
+	status, err := c.page(ctx, StatusPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The status page is enough for levels and status; identity and
+	// counters are best effort
+	information, err := c.page(ctx, InformationPath)
+	if err != nil {
+		slog.Debug("Failed to read web UI information page", "url", c.BaseURL, "error", err)
+
+		information = nil
+	}
+
+	for _, parser := range Parsers {
+		if !parser.Match(status) {
+			continue
+		}
+
+		data := newData()
+		data.Generation = parser.Name()
+		parser.Parse(status, information, data)
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("unrecognised web UI layout at %s%s", c.BaseURL, StatusPath)
+}
+
+// page fetches and parses a page, logging in first if it is behind the
+// admin login form
+func (c *Client) page(ctx context.Context, path string) (*html.Node, error) {
+	pageURL := c.BaseURL + path
+
+	doc, err := c.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	form := loginForm(doc)
+	if form == nil {
+		return doc, nil
+	}
+
+	if c.password == "" {
+		return nil, ErrLoginRequired
+	}
+
+	if err := c.login(ctx, pageURL, form); err != nil {
+		return nil, err
+	}
+
+	doc, err = c.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if loginForm(doc) != nil {
+		return nil, errors.New("web UI login failed, check the admin password")
+	}
+
+	return doc, nil
+}
+
+func (c *Client) get(ctx context.Context, pageURL string) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	return c.do(req)
+}
+
+// login submits the login form with the configured password. Brother
+// randomises the password field name and adds a CSRF token, so every input
+// of the form is sent back as served.
+func (c *Client) login(ctx context.Context, pageURL string, form *html.Node) error {
+	values := url.Values{}
+
+	for _, input := range findAll(form, isElement("input")) {
+		name := attr(input, "name")
+		if name == "" {
+			continue
+		}
+
+		if strings.EqualFold(attr(input, "type"), "password") {
+			values.Set(name, c.password)
+			continue
+		}
+
+		values.Set(name, attr(input, "value"))
+	}
+
+	action, err := url.Parse(pageURL)
+	if err != nil {
+		return fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	if ref := attr(form, "action"); ref != "" {
+		target, err := url.Parse(ref)
+		if err != nil {
+			return fmt.Errorf("invalid login form action %q: %w", ref, err)
+		}
+
+		action = action.ResolveReference(target)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.String(), strings.NewReader(values.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create login request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if _, err := c.do(req); err != nil {
+		return fmt.Errorf("web UI login failed: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) do(req *http.Request) (*html.Node, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed with HTTP status %d", req.URL.Path, resp.StatusCode)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", req.URL.Path, err)
+	}
+
+	return doc, nil
+}
+
+// loginForm returns the form holding a password input, or nil
+func loginForm(doc *html.Node) *html.Node {
+	for _, form := range findAll(doc, isElement("form")) {
+		passwords := findAll(form, func(n *html.Node) bool {
+			return n.Data == "input" && strings.EqualFold(attr(n, "type"), "password")
+		})
+		if len(passwords) > 0 {
+			return form
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package webui
+
+import (
+	"golang.org/x/net/html"
+)
+
+// legacyParser reads the table-based web UI of older models, where each
+// value is a two-cell table row: <tr><td>Model Name</td><td>...</td></tr>.
+// Toner levels are only available as remaining life percentages.
+type legacyParser struct{}
+
+func (legacyParser) Name() string { return "legacy" }
+
+func (legacyParser) Match(status *html.Node) bool {
+	return len(tableFields(status)) > 0
+}
+
+func (legacyParser) Parse(status, information *html.Node, data *Data) {
+	applyFields(tableFields(status), data)
+
+	if information != nil {
+		applyFields(tableFields(information), data)
+	}
+}
+
+// tableFields returns the label/value pairs of two-cell table rows
+func tableFields(doc *html.Node) []field {
+	var fields []field
+
+	for _, tr := range findAll(doc, isElement("tr")) {
+		var cells []*html.Node
+
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+				cells = append(cells, c)
+			}
+		}
+
+		if len(cells) != 2 {
+			continue
+		}
+
+		label := text(cells[0])
+		if label == "" {
+			continue
+		}
+
+		fields = append(fields, field{label: label, value: text(cells[1])})
+	}
+
+	return fields
+}
@@ -0,0 +1,76 @@
+package webui
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// tonerGaugeHeight is the height in pixels of a full toner gauge image on
+// the modern status page
+const tonerGaugeHeight = 56
+
+// modernParser reads the web UI used by models from around 2012 onwards.
+// Values are laid out as <dl class="items"> definition lists, the status
+// message sits in #moni_data and toner levels are drawn as
+// <img class="tonerremain"> gauges whose height is the level.
+type modernParser struct{}
+
+func (modernParser) Name() string { return "modern" }
+
+func (modernParser) Match(status *html.Node) bool {
+	return len(findAll(status, func(n *html.Node) bool {
+		return n.Data == "dl" && hasClass(n, "items")
+	})) > 0
+}
+
+func (p modernParser) Parse(status, information *html.Node, data *Data) {
+	if moni := findAll(status, func(n *html.Node) bool { return attr(n, "id") == "moni_data" }); len(moni) > 0 {
+		data.Status = text(moni[0])
+	}
+
+	applyFields(definitionFields(status), data)
+
+	if information != nil {
+		applyFields(definitionFields(information), data)
+	}
+
+	// Gauges are only an approximation; prefer percentages from the
+	// information page's remaining life section
+	for _, img := range findAll(status, func(n *html.Node) bool {
+		return n.Data == "img" && hasClass(n, "tonerremain")
+	}) {
+		color := labelColor(strings.ToLower(attr(img, "alt")))
+		if _, ok := data.Toner[color]; ok {
+			continue
+		}
+
+		height, err := strconv.Atoi(attr(img, "height"))
+		if err != nil {
+			continue
+		}
+
+		data.Toner[color] = min(float64(height)*100/tonerGaugeHeight, 100)
+	}
+}
+
+// definitionFields returns the dt/dd pairs of every definition list
+func definitionFields(doc *html.Node) []field {
+	var fields []field
+
+	for _, dt := range findAll(doc, isElement("dt")) {
+		dd := dt.NextSibling
+		for dd != nil && dd.Type != html.ElementNode {
+			dd = dd.NextSibling
+		}
+
+		if dd == nil || dd.Data != "dd" {
+			continue
+		}
+
+		fields = append(fields, field{label: text(dt), value: text(dd)})
+	}
+
+	return fields
+}
@@ -0,0 +1,245 @@
+// Package webui reads printer data from the status and information pages of
+// Brother's embedded web server, for printers with SNMP and IPP disabled.
+package webui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Pages scraped from the embedded web server
+const (
+	StatusPath      = "/general/status.html"
+	InformationPath = "/general/information.html"
+)
+
+// Data is the printer data read from the web UI. Maps are keyed by colour
+// (toner, drum) or maintenance part (parts) and hold remaining percentages.
+type Data struct {
+	Generation string
+
+	Model    string
+	Serial   string
+	Firmware string
+	MAC      string
+	NodeName string
+	Location string
+	Contact  string
+
+	// Status is the device status message, e.g. "Sleep" or "Paper Jam"
+	Status string
+
+	Toner map[string]float64
+	Drum  map[string]float64
+	Parts map[string]float64
+
+	// Counters holds "total", "black" and "color" page counts
+	Counters map[string]float64
+}
+
+func newData() *Data {
+	return &Data{
+		Toner:    make(map[string]float64),
+		Drum:     make(map[string]float64),
+		Parts:    make(map[string]float64),
+		Counters: make(map[string]float64),
+	}
+}
+
+// Parser reads one generation of the web UI. Brother has shipped several
+// page layouts over the years; each parser recognises its own.
+type Parser interface {
+	// Name identifies the generation in logs
+	Name() string
+	// Match reports whether the status page is from this generation
+	Match(status *html.Node) bool
+	// Parse fills data from the status and (possibly nil) information pages
+	Parse(status, information *html.Node, data *Data)
+}
+
+// Parsers are tried in order against the status page
+var Parsers = []Parser{modernParser{}, legacyParser{}}
+
+// field is a label/value pair from a page
+type field struct {
+	label string
+	value string
+}
+
+var (
+	percentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	numberPattern  = regexp.MustCompile(`^\d[\d,]*$`)
+	colors         = []string{"black", "cyan", "magenta", "yellow"}
+)
+
+// applyFields maps label/value pairs shared by every generation
+func applyFields(fields []field, data *Data) {
+	for _, f := range fields {
+		label := normaliseLabel(f.label)
+
+		switch label {
+		case "model name", "model":
+			data.Model = strings.TrimSuffix(strings.TrimPrefix(f.value, "Brother "), " series")
+		case "serial no.", "serial no", "serial number":
+			data.Serial = f.value
+		case "main firmware version", "firmware version", "firmware":
+			data.Firmware = f.value
+		case "mac address", "node address":
+			data.MAC = f.value
+		case "node name":
+			data.NodeName = f.value
+		case "location":
+			data.Location = f.value
+		case "contact":
+			data.Contact = f.value
+		case "device status", "status":
+			if data.Status == "" {
+				data.Status = f.value
+			}
+		case "page counter", "total page count", "total pages printed":
+			setCounter(data, "total", f.value)
+		case "color", "colour", "color page count":
+			setCounter(data, "color", f.value)
+		case "black & white", "black&white", "monochrome", "b&w":
+			setCounter(data, "black", f.value)
+		default:
+			applyRemainingLife(label, f.value, data)
+		}
+	}
+}
+
+// applyRemainingLife maps "% of life remaining" values such as
+// "Drum Unit*" / "(91%)"
+func applyRemainingLife(label, value string, data *Data) {
+	percent, ok := parsePercent(value)
+	if !ok {
+		return
+	}
+
+	switch {
+	case strings.Contains(label, "toner"):
+		data.Toner[labelColor(label)] = percent
+	case strings.Contains(label, "drum"):
+		data.Drum[labelColor(label)] = percent
+	case strings.Contains(label, "belt"):
+		data.Parts["belt_unit"] = percent
+	case strings.Contains(label, "fuser"):
+		data.Parts["fuser_unit"] = percent
+	case strings.Contains(label, "laser"):
+		data.Parts["laser_unit"] = percent
+	case strings.Contains(label, "pf kit"), strings.Contains(label, "paper feeding kit"):
+		data.Parts["paper_feeding_kit"] = percent
+	}
+}
+
+func setCounter(data *Data, name, value string) {
+	value = strings.TrimSpace(value)
+	if !numberPattern.MatchString(value) {
+		return
+	}
+
+	count, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err == nil {
+		data.Counters[name] = count
+	}
+}
+
+// labelColor finds the colour a label names, defaulting to black for
+// single-colour parts
+func labelColor(label string) string {
+	for _, color := range colors {
+		if strings.Contains(label, color) {
+			return color
+		}
+	}
+
+	return "black"
+}
+
+func parsePercent(value string) (float64, bool) {
+	match := percentPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+
+	percent, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return min(percent, 100), true
+}
+
+// normaliseLabel lower-cases a label and drops the footnote asterisks and
+// trailing colons Brother pages use
+func normaliseLabel(label string) string {
+	label = strings.ToLower(label)
+	label = strings.TrimRight(strings.TrimSpace(label), "*:")
+
+	return strings.Join(strings.Fields(label), " ")
+}
+
+// text returns the whitespace-collapsed text content of n
+func text(n *html.Node) string {
+	var builder strings.Builder
+
+	var walk func(*html.Node)
+
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			builder.WriteByte(' ')
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(n)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// findAll returns every element below n for which match returns true
+func findAll(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+
+	var walk func(*html.Node)
+
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && match(n) {
+			found = append(found, n)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(n)
+
+	return found
+}
+
+// isElement matches elements by tag name
+func isElement(tag string) func(*html.Node) bool {
+	return func(n *html.Node) bool { return n.Data == tag }
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return strings.Contains(" "+attr(n, "class")+" ", " "+class+" ")
+}
@@ -0,0 +1,124 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPrinter serves the saved pages of one web UI generation from testdata.
+// With a password set, pages redirect to the saved login page until the
+// login form is posted with it.
+func newPrinter(t *testing.T, generation, password string) *httptest.Server {
+	t.Helper()
+
+	page := func(name string) []byte {
+		data, err := os.ReadFile("testdata/" + generation + "/" + name)
+		require.NoError(t, err)
+
+		return data
+	}
+
+	pages := map[string][]byte{
+		StatusPath:      page("status.html"),
+		InformationPath: page("information.html"),
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if password != "" {
+			if r.Method == http.MethodPost {
+				assert.NoError(t, r.ParseForm())
+
+				if r.PostForm.Get("B1d2b") == password && r.PostForm.Get("CSRFToken") == "c2VjcmV0LXRva2Vu" {
+					http.SetCookie(w, &http.Cookie{Name: "AuthCookie", Value: "ok", Path: "/"})
+				}
+			}
+
+			if cookie, err := r.Cookie("AuthCookie"); err != nil || cookie.Value != "ok" {
+				_, _ = w.Write(page("login.html"))
+				return
+			}
+		}
+
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestFetch_Modern(t *testing.T) {
+	server := newPrinter(t, "modern", "")
+	defer server.Close()
+
+	data, err := NewClient(server.URL, "", 5*time.Second, false).Fetch(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, "modern", data.Generation)
+	assert.Equal(t, "Sleep", data.Status)
+	assert.Equal(t, "HL-L3270CDW", data.Model)
+	assert.Equal(t, "E78096A9N123456", data.Serial)
+	assert.Equal(t, "ZC", data.Firmware)
+	assert.Equal(t, "00:80:92:7A:FB:CE", data.MAC)
+	assert.Equal(t, "BRW0080927AFBCE", data.NodeName)
+	assert.Equal(t, "Office", data.Location)
+	assert.Equal(t, "IT Desk", data.Contact)
+
+	assert.Equal(t, map[string]float64{"black": 50, "cyan": 75, "magenta": 100, "yellow": 25}, data.Toner)
+	assert.Equal(t, map[string]float64{"black": 91}, data.Drum)
+	assert.Equal(t, map[string]float64{
+		"belt_unit": 95, "fuser_unit": 97, "laser_unit": 98, "paper_feeding_kit": 99,
+	}, data.Parts)
+	assert.Equal(t, map[string]float64{"total": 5432, "color": 1432, "black": 4000}, data.Counters)
+}
+
+func TestFetch_Legacy(t *testing.T) {
+	server := newPrinter(t, "legacy", "")
+	defer server.Close()
+
+	data, err := NewClient(server.URL, "", 5*time.Second, false).Fetch(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, "legacy", data.Generation)
+	assert.Equal(t, "Ready", data.Status)
+	assert.Equal(t, "HL-5350DN", data.Model)
+	assert.Equal(t, "A2J123456", data.Serial)
+	assert.Equal(t, "1.12", data.Firmware)
+	assert.Equal(t, "00:1B:A9:12:34:56", data.MAC)
+	assert.Equal(t, map[string]float64{"black": 40}, data.Toner)
+	assert.Equal(t, map[string]float64{"black": 62}, data.Drum)
+	assert.Equal(t, map[string]float64{"total": 12345}, data.Counters)
+}
+
+func TestFetch_Login(t *testing.T) {
+	server := newPrinter(t, "modern", "initpass")
+	defer server.Close()
+
+	data, err := NewClient(server.URL, "initpass", 5*time.Second, false).Fetch(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "HL-L3270CDW", data.Model)
+}
+
+func TestFetch_LoginRequired(t *testing.T) {
+	server := newPrinter(t, "modern", "initpass")
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", 5*time.Second, false).Fetch(t.Context())
+	assert.ErrorIs(t, err, ErrLoginRequired)
+}
+
+func TestFetch_WrongPassword(t *testing.T) {
+	server := newPrinter(t, "modern", "initpass")
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "wrong", 5*time.Second, false).Fetch(t.Context())
+	assert.ErrorContains(t, err, "login failed")
+}